@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"                                 // Exit codes
+	"wallet_system/internal/audit"       // Custom import path (Audit log)
+	"wallet_system/internal/config"      // Custom import path (Config)
+	database "wallet_system/internal/db" // Custom import path (Database connection)
+	"wallet_system/internal/logging"     // Custom import path (Logging)
+
+	"github.com/sirupsen/logrus" // Logrus for structured logging
+)
+
+// Main entry point for audit log verification. Exits non-zero if the chain is broken.
+func main() {
+	cfg, err := config.LoadConfig() // Load and validate configuration
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if err := logging.Setup(cfg); err != nil {
+		logrus.Fatalf("failed to set up logging: %v", err)
+	}
+
+	// Connect to the database selected by DB_DRIVER
+	db, err := database.Open(cfg)
+	if err != nil {
+		logrus.Fatalf("failed to connect to DB: %v", err) // Fatal error if DB connection fails
+	}
+
+	result, err := audit.Verify(db) // Walk the whole chain
+	if err != nil {
+		logrus.Fatalf("verification failed to run: %v", err)
+	}
+	if !result.Valid {
+		// Report the first broken record
+		logrus.WithFields(logrus.Fields{
+			"checked":   result.Checked,  // Records checked before the break
+			"broken_id": result.BrokenID, // First broken record
+			"reason":    result.Reason,   // Why it failed
+		}).Error("Audit log chain is broken")
+		os.Exit(1)
+	}
+	logrus.WithField("checked", result.Checked).Info("Audit log chain verified.")
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"                           // Catalogue buffer
+	"flag"                            // Command line flags
+	"os"                              // Output file
+	"wallet_system/internal/apierror" // Custom import path (Error codes)
+
+	"github.com/sirupsen/logrus" // Logrus for structured logging
+)
+
+// Main entry point for the error catalogue generator. Run it through go generate ./internal/apierror.
+func main() {
+	out := flag.String("o", "docs/errors.md", "file to write the catalogue to")
+	flag.Parse()
+
+	var buf bytes.Buffer // Whole catalogue, so a failure leaves the old file intact
+	if err := apierror.WriteCatalogue(&buf); err != nil {
+		logrus.Fatalf("failed to render catalogue: %v", err)
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		logrus.Fatalf("failed to write catalogue: %v", err)
+	}
+}
@@ -1,15 +1,149 @@
 package main
 
 import (
-	"wallet_system/internal/config" // Custom import path (Config)
-	"wallet_system/internal/db"     // Custom import path (Database)
+	"flag"                                 // Command-line flags
+	"fmt"                                  // Output
+	"os"                                   // Arguments and exit codes
+	"strconv"                              // Argument parsing
+	"text/tabwriter"                       // Status table
+	"wallet_system/internal/config"        // Custom import path (Config)
+	"wallet_system/internal/db"            // Custom import path (Database)
+	"wallet_system/internal/db/migrations" // Custom import path (Migrations)
+	"wallet_system/internal/logging"       // Custom import path (Logging)
+	"wallet_system/internal/migrate"       // Custom import path (Migration framework)
+
+	"github.com/sirupsen/logrus" // Logrus for structured logging
 )
 
+// usage describes the subcommands
+const usage = `usage: migrate <command> [arguments]
+
+commands:
+  up                 apply every pending migration
+  down [N]           revert the newest N applied migrations (default 1)
+  status             list migrations and whether they are applied
+  goto VERSION       migrate up or down to exactly VERSION (0 reverts everything)
+  create [-go] NAME  create empty migration files named NAME
+  force VERSION      mark the schema as being at VERSION without running anything,
+                     after fixing a failed migration by hand
+`
+
+// migrationsDir is where create writes new migrations, relative to the repository root
+const migrationsDir = "internal/db/migrations"
+
 // Main entry point for migration
 func main() {
-	cfg := config.LoadConfig() // Load configuration
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cmd, args := os.Args[1], os.Args[2:] // Subcommand and its arguments
+
+	// create only writes files, so it needs no database
+	if cmd == "create" {
+		fs := flag.NewFlagSet("create", flag.ExitOnError)
+		goMigration := fs.Bool("go", false, "create a Go migration instead of SQL files")
+		_ = fs.Parse(args)
+		if fs.NArg() != 1 {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		// SQL files are written once per dialect
+		paths, err := migrate.Create(migrationsDir, fs.Arg(0), *goMigration, migrations.Dialects...)
+		if err != nil {
+			logrus.Fatalf("create failed: %v", err)
+		}
+		for _, p := range paths {
+			fmt.Println("Created " + p)
+		}
+		return
+	}
+
+	cfg, err := config.LoadConfig() // Load and validate configuration
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if err := logging.Setup(cfg); err != nil {
+		logrus.Fatalf("failed to set up logging: %v", err)
+	}
+
+	// Connect to the database selected by DB_DRIVER
+	conn, err := db.Open(cfg)
+	if err != nil {
+		logrus.Fatalf("failed to connect to DB: %v", err) // Fatal error if DB connection fails
+	}
+	m, err := db.Migrator(conn) // Migrations for this database
+	if err != nil {
+		logrus.Fatalf("failed to load migrations: %v", err)
+	}
+
+	var done []migrate.Migration // Migrations applied or reverted
+	switch cmd {
+	case "up":
+		done, err = m.Up()
+	case "down":
+		steps := 1 // Revert one migration by default
+		if len(args) > 0 {
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				logrus.Fatal("down takes a positive number of migrations")
+			}
+		}
+		done, err = m.Down(steps)
+	case "goto":
+		done, err = m.Goto(versionArg(args))
+	case "force":
+		err = m.Force(versionArg(args))
+	case "status":
+		printStatus(m)
+		return
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	for _, mig := range done {
+		logrus.WithField("version", mig.Version).Info("Migrated " + mig.Name)
+	}
+	if err != nil {
+		logrus.Fatalf("migration failed: %v", err)
+	}
+	logrus.Info("Migration completed.") // Log successful migration
+}
+
+// versionArg parses the single VERSION argument of goto and force
+func versionArg(args []string) int64 {
+	if len(args) != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	v, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || v < 0 {
+		logrus.Fatalf("invalid version %q", args[0])
+	}
+	return v
+}
 
-	// Database Source Name (DSN) for MySQL connection
-	dsn := cfg.DBUser + ":" + cfg.DBPassword + "@tcp(" + cfg.DBHost + ":" + cfg.DBPort + ")/" + cfg.DBName + "?parseTime=true"
-	db.Migrate(dsn)
+// printStatus prints one line per migration
+func printStatus(m *migrate.Migrator) {
+	statuses, err := m.Status()
+	if err != nil {
+		logrus.Fatalf("status failed: %v", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, s := range statuses {
+		state, at := "pending", ""
+		switch {
+		case s.Dirty:
+			state = "dirty"
+		case s.Missing:
+			state = "applied, unknown to this binary"
+		case s.Applied:
+			state = "applied"
+		}
+		if s.AppliedAt != nil {
+			at = s.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, state, at)
+	}
+	_ = w.Flush()
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"                      // JSON report output
+	"flag"                               // Command-line flags
+	"os"                                 // Exit codes and output
+	"wallet_system/internal/audit"       // Custom import path (Audit log)
+	"wallet_system/internal/config"      // Custom import path (Config)
+	database "wallet_system/internal/db" // Custom import path (Database connection)
+	"wallet_system/internal/domain"      // Custom import path (Domain models)
+	"wallet_system/internal/logging"     // Custom import path (Logging)
+	"wallet_system/internal/reconcile"   // Custom import path (Ledger reconciliation)
+
+	"github.com/sirupsen/logrus" // Logrus for structured logging
+)
+
+// Main entry point for ledger reconciliation. Exits non-zero if the ledger is inconsistent
+// after any repairs.
+func main() {
+	repair := flag.Bool("repair", false, "post correcting adjustments for wallets out of balance")
+	actor := flag.String("actor", "", "username of the admin responsible for repairs, recorded in the audit log")
+	asJSON := flag.Bool("json", false, "print the report as JSON instead of logging it")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig() // Load and validate configuration
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if err := logging.Setup(cfg); err != nil {
+		logrus.Fatalf("failed to set up logging: %v", err)
+	}
+
+	// Connect to the database selected by DB_DRIVER
+	db, err := database.Open(cfg)
+	if err != nil {
+		logrus.Fatalf("failed to connect to DB: %v", err) // Fatal error if DB connection fails
+	}
+
+	// Repairs move money, so they need an accountable admin allowed to adjust balances
+	meta := audit.Entry{UserAgent: "cmd/reconcile"} // Audit details for repairs
+	if *repair {
+		var user domain.User // Admin responsible for the repairs
+		if *actor == "" || db.Where("username = ?", *actor).First(&user).Error != nil {
+			logrus.Fatal("-repair needs -actor with the username of an existing admin")
+		}
+		if !domain.HasPermission(user.Role, domain.PermTxAdjust) {
+			logrus.Fatalf("%s lacks the %s permission", user.Username, domain.PermTxAdjust)
+		}
+		meta.ActorID = &user.ID
+	}
+
+	report, err := reconcile.Check(db) // Recompute every balance
+	if err != nil {
+		logrus.Fatalf("reconciliation failed to run: %v", err)
+	}
+	if *repair && len(report.Discrepancies) > 0 {
+		for _, d := range report.Discrepancies {
+			if d.Missing || d.IsSystem {
+				logrus.WithField("wallet_id", d.WalletID).Warn("Skipping wallet; reconcile it manually")
+				continue
+			}
+			t, err := reconcile.Repair(db, d.WalletID, meta)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"wallet_id": d.WalletID,  // Wallet
+					"error":     err.Error(), // Error message
+				}).Error("Failed to repair wallet")
+				continue
+			}
+			if t != nil {
+				logrus.WithFields(logrus.Fields{
+					"wallet_id":      d.WalletID, // Wallet
+					"transaction_id": t.ID,       // Correcting entry
+					"amount":         t.Amount,   // Amount booked
+				}).Info("Posted reconciliation adjustment")
+			}
+		}
+		// Report the state after the repairs
+		if report, err = reconcile.Check(db); err != nil {
+			logrus.Fatalf("reconciliation failed to run: %v", err)
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	} else {
+		reconcile.Log(report)
+	}
+	if !report.Consistent() {
+		os.Exit(1)
+	}
+}
@@ -1,84 +1,167 @@
 package main
 
 import (
-	"context"                           // context package is needed for Redis operations
-	"log"                               // log package is needed for logging
-	"wallet_system/internal/api"        // Custom package for API handlers
-	"wallet_system/internal/config"     // Custom package for configuration
-	"wallet_system/internal/middleware" // Custom package for middleware
+	"context"                            // context package is needed for Redis operations and shutdown
+	"errors"                             // errors package is needed to recognize a closed server
+	"flag"                               // flag package is needed for command line options
+	"fmt"                                // fmt package is needed to print the configuration
+	"net/http"                           // net/http package is needed for the HTTP server
+	"os"                                 // os package is needed for the exit status
+	"os/signal"                          // os/signal package is needed to catch shutdown signals
+	"sync"                               // sync package is needed to wait for background jobs
+	"syscall"                            // syscall package is needed for SIGTERM
+	"time"                               // time package is needed for background job intervals
+	"wallet_system/internal/approval"    // Custom package for the approval workflow
+	"wallet_system/internal/config"      // Custom package for configuration
+	database "wallet_system/internal/db" // Custom package for database connections
+	"wallet_system/internal/logging"     // Custom package for structured logging
+	"wallet_system/internal/metrics"     // Custom package for Prometheus metrics
+	"wallet_system/internal/reconcile"   // Custom package for ledger reconciliation
+	"wallet_system/internal/server"      // Custom package for the HTTP router
+	"wallet_system/internal/statement"   // Custom package for account statements
+	"wallet_system/internal/tracing"     // Custom package for OpenTelemetry tracing
+	"wallet_system/internal/utils"       // Custom package for token lifetimes
 
 	// For loading .env files
 	"github.com/gin-gonic/gin"     // Gin web framework
 	"github.com/redis/go-redis/v9" // Redis client
 	"github.com/sirupsen/logrus"   // Logrus for structured logging
-	"gorm.io/driver/mysql"         // MySQL driver for GORM
-	"gorm.io/gorm"                 // GORM ORM library
 )
 
 // Main function to set up and run the server
 func main() {
-	cfg := config.LoadConfig() // Load configuration
+	configFile := flag.String("config", "", "YAML or TOML config file, instead of CONFIG_FILE; environment variables take precedence")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted, then exit")
+	flag.Parse()
 
-	// Setup logger
-	logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	// Load and validate configuration; every problem is reported at once
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *printConfig {
+		if err := cfg.PrintTo(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Setup Data Source Name (DSN) and connect to the database
-	dsn := cfg.DBUser + ":" + cfg.DBPassword + "@tcp(" + cfg.DBHost + ":" + cfg.DBPort + ")/" + cfg.DBName + "?parseTime=true"
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// Setup logger: one structured format for the app, Gin and the standard library
+	if err := logging.Setup(cfg); err != nil {
+		logrus.Fatalf("failed to set up logging: %v", err)
+	}
+	logrus.AddHook(tracing.LogHook{})                                               // Add trace IDs to request logs
+	gin.DefaultWriter = logrus.StandardLogger().WriterLevel(logrus.DebugLevel)      // Gin's route listing in debug mode
+	gin.DefaultErrorWriter = logrus.StandardLogger().WriterLevel(logrus.ErrorLevel) // Gin's own errors
+
+	// Setup tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		logrus.Fatalf("failed to set up tracing: %v", err)
+	}
+
+	// Connect to the database selected by DB_DRIVER
+	db, err := database.Open(cfg)
 	if err != nil {
 		logrus.Fatalf("failed to connect to DB: %v", err) // Fatal error if DB connection fails
 	}
 
 	// Setup Redis client
 	redisClient := redis.NewClient(&redis.Options{
-		Addr:     cfg.RedisAddr, // Redis server address
-		Password: cfg.RedisPass, // Redis password
-		DB:       cfg.RedisDB,   // Redis database number
+		Addr:     cfg.RedisAddr,     // Redis server address
+		Password: cfg.RedisPass,     // Redis password
+		DB:       cfg.RedisDB,       // Redis database number
+		PoolSize: cfg.RedisPoolSize, // Most connections, zero for the default
 	})
 
+	// Trace queries and Redis commands
+	if err := tracing.InstrumentDB(db); err != nil {
+		logrus.Fatalf("failed to instrument DB: %v", err)
+	}
+	if err := tracing.InstrumentRedis(redisClient); err != nil {
+		logrus.Fatalf("failed to instrument Redis: %v", err)
+	}
+
 	// Test Redis connection
 	_, err = redisClient.Ping(context.Background()).Result()
 	if err != nil {
 		logrus.Fatalf("failed to connect to Redis: %v", err)
 	}
 
+	// Export connection pool statistics
+	sqlDB, err := db.DB()
+	if err != nil {
+		logrus.Fatalf("failed to get DB pool: %v", err)
+	}
+	if err := metrics.RegisterPools(sqlDB, redisClient); err != nil {
+		logrus.Fatalf("failed to register pool metrics: %v", err)
+	}
+
 	// Set Mode to Release if in production
 	if cfg.IsProd {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Setup Gin
-	r := gin.Default() // Gin router instance
+	// Token lifetimes from the configuration, set once before any token is issued
+	utils.SetTokenTTLs(cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
 
-	// Set trusted proxies for Gin
-	if err := r.SetTrustedProxies([]string{"127.0.0.1"}); err != nil {
-		logrus.Fatalf("failed to set trusted proxies: %v", err)
+	// Setup Gin with every route
+	r, err := server.NewRouter(cfg, db, redisClient)
+	if err != nil {
+		logrus.Fatalf("failed to set up router: %v", err)
 	}
 
-	// Auth routes
-	r.POST("/user", api.RegisterHandler(db))            // Registration endpoint
-	r.GET("/user", api.LoginHandler(db, cfg.JWTSecret)) // Login endpoint
+	// Cancelled on SIGINT or SIGTERM, which starts the shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	// Wallet routes (protected by JWT)
-	walletGroup := r.Group("/wallet")
-	// Protect wallet routes with JWT middleware and inject Redis client into context
-	walletGroup.Use(middleware.JWTAuthMiddleware(cfg.JWTSecret), func(c *gin.Context) {
-		c.Set("redisClient", redisClient)
-		c.Next()
-	})
-	walletGroup.POST("", api.CreateWalletHandler(db))                                   // Create wallet endpoint
-	walletGroup.GET("", api.GetWalletHandler(db, redisClient))                          // Get wallet endpoint
-	walletGroup.POST("/deposit", api.DepositHandler(db))                                // Deposit endpoint
-	walletGroup.POST("/transfer", api.TransferHandler(db))                              // Transfer endpoint
-	walletGroup.GET("/transactions", api.GetTransactionHistoryHandler(db, redisClient)) // Transaction history endpoint
-
-	// Admin routes (protected, admin only)
-	adminGroup := r.Group("/admin")
-	// Protect admin routes with JWT and AdminOnly middleware
-	adminGroup.Use(middleware.JWTAuthMiddleware(cfg.JWTSecret), middleware.AdminOnlyMiddleware(db))
-	adminGroup.GET("/users", api.ListUsersHandler(db, redisClient))               // List users endpoint
-	adminGroup.GET("/transactions", api.ListTransactionsHandler(db, redisClient)) // List transactions endpoint
-
-	log.Println("Server running on " + cfg.AppPort) // Log server start
-	r.Run(":" + cfg.AppPort)                        // Start the server on port cfg.AppPort
+	var jobs sync.WaitGroup // Background jobs, waited for before the pools close
+	runJob := func(job func()) {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			job()
+		}()
+	}
+	// Expire stale proposals in the background
+	runJob(func() { approval.RunExpiry(ctx, db, time.Minute) })
+	// Generate last month's statements once it has ended
+	runJob(func() { statement.RunMonthly(ctx, db, cfg.Currency, time.Hour) })
+	// Check wallet balances against the ledger
+	runJob(func() { reconcile.Run(ctx, db, cfg.ReconcileInterval) })
+
+	srv := server.NewHTTPServer(cfg, r) // HTTP server with timeouts
+	go func() {
+		logrus.Infof("Server running on %s", cfg.AppPort) // Log server start
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Fatalf("server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done() // Wait for a shutdown signal
+	stop()       // A second signal kills the process immediately
+	logrus.Info("Shutting down; draining in-flight requests")
+
+	// Stop accepting connections and let in-flight requests, such as transfers, finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logrus.Errorf("graceful shutdown timed out: %v", err)
+	}
+	jobs.Wait() // Let a running job finish its current pass
+
+	// Close the connection pools
+	if err := sqlDB.Close(); err != nil {
+		logrus.Errorf("failed to close DB: %v", err)
+	}
+	if err := redisClient.Close(); err != nil {
+		logrus.Errorf("failed to close Redis: %v", err)
+	}
+	// Flush buffered spans
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logrus.Errorf("failed to flush traces: %v", err)
+	}
+	logrus.Info("Server stopped")
 }
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"                          // Error inspection
+	"net/http"                        // HTTP status codes
+	"strings"                         // Reason code list
+	"wallet_system/internal/apierror" // Error responses
+	"wallet_system/internal/audit"    // Audit log
+	"wallet_system/internal/domain"   // Importing domain models
+	"wallet_system/internal/service"  // Business logic
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// AdjustmentRequest represents a manual balance adjustment request
+type AdjustmentRequest struct {
+	Direction  string  `json:"direction" binding:"required,oneof=credit debit"` // credit or debit
+	Amount     float64 `json:"amount" binding:"required,gt=0"`                  // Amount to move
+	ReasonCode string  `json:"reason_code" binding:"required"`                  // One of domain.AdjustmentReasons
+	Note       string  `json:"note" binding:"required,max=255"`                 // Free-text explanation
+}
+
+// AdjustWalletHandler proposes a manual credit or debit of a wallet. Every adjustment
+// needs a second admin's approval before it is posted.
+func AdjustWalletHandler(txs service.TransactionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		walletID, ok := parseIDParam(c, "id") // Parse wallet ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid wallet ID")
+			return
+		}
+		var req AdjustmentRequest // Bind JSON request to struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.AbortInvalid(c, err, "Invalid request")
+			return
+		}
+		action, err := txs.ProposeAdjustment(c.Request.Context(), walletID, service.Adjustment{
+			Direction:  req.Direction,  // credit or debit
+			Amount:     req.Amount,     // Amount
+			ReasonCode: req.ReasonCode, // Reason code
+			Note:       req.Note,       // Note
+		}, audit.FromRequest(c))
+		switch {
+		case errors.Is(err, service.ErrUnknownReason):
+			apierror.AbortWith(c, &apierror.Error{
+				Code:    apierror.UnknownReasonCode,
+				Message: "Unknown reason code",
+				Details: []apierror.FieldError{{Field: "reason_code", Message: "must be one of " + strings.Join(domain.AdjustmentReasons, ", ")}},
+				Meta:    gin.H{"reason_codes": domain.AdjustmentReasons}, // Accepted codes
+			})
+		case errors.Is(err, service.ErrWalletNotFound):
+			apierror.Abort(c, apierror.WalletNotFound, "Wallet not found")
+		case errors.Is(err, service.ErrSystemWallet):
+			apierror.Abort(c, apierror.SystemWallet, "Cannot adjust a system wallet")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to propose adjustment")
+		default:
+			respond(c, http.StatusAccepted, gin.H{"message": "Adjustment awaiting approval", "approval": toPendingActionResponse(action)})
+		}
+	}
+}
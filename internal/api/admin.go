@@ -1,48 +1,26 @@
 package api
 
 import (
-	"context"                       // Context for Redis operations
-	"net/http"                      // HTTP status codes
-	"strconv"                       // String conversion
-	"strings"                       // String manipulation
-	"time"                          // Time durations
-	"wallet_system/internal/domain" // Importing domain models
-	"wallet_system/internal/utils"  // Utility functions
+	"errors"                            // Error inspection
+	"net/http"                          // HTTP status codes
+	"strconv"                           // String conversion
+	"strings"                           // String manipulation
+	"wallet_system/internal/apierror"   // Error responses
+	"wallet_system/internal/audit"      // Audit log
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/ledger"     // Ledger errors
+	"wallet_system/internal/repository" // Query filters
+	"wallet_system/internal/service"    // Business logic
 
-	"github.com/gin-gonic/gin"     // Gin web framework
-	"github.com/redis/go-redis/v9" // Redis client
-	"gorm.io/gorm"                 // GORM ORM library
+	"github.com/gin-gonic/gin" // Gin web framework
 )
 
-// ListUsersHandler returns all users with their wallet info
-func ListUsersHandler(db *gorm.DB, rdb *redis.Client) gin.HandlerFunc {
+// ListUsersHandler returns users with their wallet info. It supports searching by username
+// prefix, role, status and balance range, and sorting by id, username or balance.
+func ListUsersHandler(users service.UserService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx := context.Background() // Use background context for Redis
-		// Create a cache key based on pagination parameters
-		cacheKey := "admin:users:page=" + c.DefaultQuery("page", "1") + ":size=" + c.DefaultQuery("page_size", "20")
-		// Try to get cached response
-		var cached struct {
-			Users      []UserAdminResponse `json:"users"`       // List of users
-			Page       int                 `json:"page"`        // Current page
-			PageSize   int                 `json:"page_size"`   // Page size
-			Total      int64               `json:"total"`       // Total number of users
-			TotalPages int                 `json:"total_pages"` // Total pages
-		}
-		// If cached data found, return it
-		found, err := utils.GetCache(ctx, rdb, cacheKey, &cached)
-		if err == nil && found {
-			c.JSON(http.StatusOK, gin.H{
-				"users":       cached.Users,      // List of users
-				"page":        cached.Page,       // Current page
-				"page_size":   cached.PageSize,   // Page size
-				"total":       cached.Total,      // Total number of users
-				"total_pages": cached.TotalPages, // Total pages
-				"cached":      true,              // Indicate response is from cache
-			})
-			return
-		}
-		page := 1      // Default page number
-		pageSize := 20 // Default page size
+		page := 1                           // Default page number
+		pageSize := service.DefaultPageSize // Default page size
 		if p := c.Query("page"); p != "" {
 			if v, err := strconv.Atoi(p); err == nil && v > 0 {
 				page = v // Set page if valid
@@ -51,148 +29,261 @@ func ListUsersHandler(db *gorm.DB, rdb *redis.Client) gin.HandlerFunc {
 		// Check and set page size within limits
 		if ps := c.Query("page_size"); ps != "" {
 			// If valid, set page size
-			if v, err := strconv.Atoi(ps); err == nil && v > 0 && v <= 100 {
+			if v, err := strconv.Atoi(ps); err == nil && v > 0 && v <= service.MaxPageSize {
 				pageSize = v // Set page size
 			}
 		}
-		offset := (page - 1) * pageSize // Calculate offset for pagination
-		var total int64                 // Total user count
-		// Fetch total user count and paginated users with wallet info
-		if err := db.Model(&domain.User{}).Count(&total).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count users"}) // Return on error
-			return
+		filter := repository.UserFilter{
+			UsernamePrefix: strings.ToLower(c.Query("username")), // Filter by username prefix
+			Role:           c.Query("role"),                      // Filter by role
+			Status:         c.Query("status"),                    // Filter by status
+			Sort:           c.DefaultQuery("sort", "id"),         // Sort column
 		}
-		var users []domain.User // Slice to hold users
-		// Preload Wallet relation, apply offset and limit for pagination
-		if err := db.Preload("Wallet").Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"}) // Return on error
+		if filter.Status != "" && !domain.IsValidStatus(filter.Status) {
+			apierror.AbortField(c, "status", "Invalid status")
 			return
 		}
-		// The total number of pages
-		totalPages := (int(total) + pageSize - 1) / pageSize // Calculate total pages
-		// Prepare response data
-		resp := make([]UserAdminResponse, len(users))
-		// Map users to response format
-		for i, u := range users {
-			resp[i] = UserAdminResponse{
-				ID:       u.ID,       // User ID
-				Username: u.Username, // Username
-				Role:     u.Role,     // User role
-				Wallet:   u.Wallet,   // Associated wallet
+		// Balance range filters
+		for param, dst := range map[string]**float64{"min_balance": &filter.MinBalance, "max_balance": &filter.MaxBalance} {
+			if v := c.Query(param); v != "" {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					apierror.AbortField(c, param, "Invalid "+param)
+					return
+				}
+				*dst = &f
 			}
 		}
-		// Prepare final response data
-		respData := gin.H{
-			"users":       resp,       // List of users
-			"page":        page,       // Current page
-			"page_size":   pageSize,   // Page size
-			"total":       total,      // Total number of users
-			"total_pages": totalPages, // Total pages
-			"cached":      false,      // Indicate response is not from cache
-		}
-		// Cache the response for future requests
-		_ = utils.SetCache(ctx, rdb, cacheKey, respData, 60*time.Second)
-		c.JSON(http.StatusOK, respData) // Return the response
+		if filter.Sort != "id" && filter.Sort != "username" && filter.Sort != "balance" {
+			apierror.AbortField(c, "sort", "Invalid sort; use id, username or balance")
+			return
+		}
+		order := strings.ToLower(c.DefaultQuery("order", "asc")) // Sort direction
+		if order != "asc" && order != "desc" {
+			apierror.AbortField(c, "order", "Invalid order; use asc or desc")
+			return
+		}
+		filter.Desc = order == "desc"
+		list, cached, err := users.List(c.Request.Context(), filter, page, pageSize)
+		if err != nil {
+			apierror.Abort(c, apierror.Internal, "Failed to fetch users") // Return on error
+			return
+		}
+		respond(c, http.StatusOK, gin.H{
+			"users":       list.Users,      // List of users
+			"page":        list.Page,       // Current page
+			"page_size":   list.PageSize,   // Page size
+			"total":       list.Total,      // Total number of users
+			"total_pages": list.TotalPages, // Total pages
+			"cached":      cached,          // Whether the response is from cache
+		})
 	}
 }
 
-// UserAdminResponse represents the user data returned to admin
-type UserAdminResponse struct {
-	ID       uint          `json:"id"`       // User ID
-	Username string        `json:"username"` // Username
-	Role     string        `json:"role"`     // User role
-	Wallet   domain.Wallet `json:"wallet"`   // Associated wallet
+// txSortColumns are the accepted values of the sort query parameter
+var txSortColumns = map[string]bool{
+	"created_at": true, // Posting time
+	"amount":     true, // Amount
+	"id":         true, // Insertion order
 }
 
-// ListTransactionsHandler returns all transactions, with optional filtering by user, type, or date
-func ListTransactionsHandler(db *gorm.DB, rdb *redis.Client) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		ctx := context.Background()
-		// Build cache key from all query params
-		var keyParts []string // Parts of the cache key
-		// Append each query parameter to the key parts
-		for _, k := range []string{"user_id", "type", "from", "to", "page", "page_size"} {
-			keyParts = append(keyParts, k+"="+c.DefaultQuery(k, "")) // Append key-value pair
-		}
-		// Join key parts to form the final cache key
-		cacheKey := "admin:txs:" + strings.Join(keyParts, ":")
-		var cached struct {
-			Transactions []domain.Transaction `json:"transactions"` // List of transactions
-			Page         int                  `json:"page"`         // Current page
-			PageSize     int                  `json:"page_size"`    // Page size
-			Total        int64                `json:"total"`        // Total number of transactions
-			TotalPages   int                  `json:"total_pages"`  // Total pages
+// transactionFilter reads the admin transaction filters from the request, writing an error
+// response if any is invalid
+func transactionFilter(c *gin.Context) (repository.TransactionFilter, bool) {
+	var f repository.TransactionFilter // Filters to apply
+	if v := c.Query("user_id"); v != "" {
+		userID, err := strconv.Atoi(v)
+		if err != nil || userID <= 0 {
+			apierror.AbortField(c, "user_id", "Invalid user_id")
+			return f, false
 		}
-
-		// If cached data found, return it
-		found, err := utils.GetCache(ctx, rdb, cacheKey, &cached)
-		if err == nil && found {
-			c.JSON(http.StatusOK, gin.H{
-				"transactions": cached.Transactions, // List of transactions
-				"page":         cached.Page,         // Current page
-				"page_size":    cached.PageSize,     // Page size
-				"total":        cached.Total,        // Total number of transactions
-				"total_pages":  cached.TotalPages,   // Total pages
-				"cached":       true,                // Indicate response is from cache
-			})
-			return
+		id := uint(userID)
+		f.UserID = &id // Filter by the user's wallet
+	}
+	if v := c.Query("wallet_id"); v != "" {
+		walletID, err := strconv.Atoi(v)
+		if err != nil || walletID <= 0 {
+			apierror.AbortField(c, "wallet_id", "Invalid wallet_id")
+			return f, false
 		}
-		page := 1      // Default page number
-		pageSize := 20 // Default page size
-		// Check and set page number and size from query params
-		if p := c.Query("page"); p != "" {
-			// If valid, set page number
-			if v, err := strconv.Atoi(p); err == nil && v > 0 {
-				page = v // Set page if valid
-			}
+		id := uint(walletID)
+		f.WalletID = &id // Filter by wallet
+	}
+	f.Username = strings.ToLower(c.Query("username")) // Filter by the user's wallet
+	if v := c.Query("type"); v != "" {
+		types, ok := parseSetParam(v, domain.IsValidTransactionType)
+		if !ok {
+			apierror.AbortField(c, "type", "Invalid type; use "+strings.Join(domain.TransactionTypes, ", "))
+			return f, false
 		}
-		// Check and set page size within limits
-		if ps := c.Query("page_size"); ps != "" {
-			// If valid, set page size
-			if v, err := strconv.Atoi(ps); err == nil && v > 0 && v <= 100 {
-				pageSize = v // Set page size
+		f.Types = types // Filter by transaction types
+	}
+	if v := c.Query("status"); v != "" {
+		statuses, ok := parseSetParam(v, domain.IsValidTransactionStatus)
+		if !ok {
+			apierror.AbortField(c, "status", "Invalid status; use completed, reversed")
+			return f, false
+		}
+		f.Statuses = statuses // Filter by transaction statuses
+	}
+	// Amount range filters
+	for param, dst := range map[string]**float64{"min_amount": &f.MinAmount, "max_amount": &f.MaxAmount} {
+		if v := c.Query(param); v != "" {
+			amount, err := strconv.ParseFloat(v, 64)
+			if err != nil || amount < 0 {
+				apierror.AbortField(c, param, "Invalid "+param)
+				return f, false
 			}
+			*dst = &amount
 		}
-		offset := (page - 1) * pageSize          // Calculate offset for pagination
-		query := db.Model(&domain.Transaction{}) // Start building the query
-		if userID := c.Query("user_id"); userID != "" {
-			query = query.Where("from_wallet_id = ? OR to_wallet_id = ?", userID, userID) // Filter by user ID
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount {
+		apierror.AbortField(c, "min_amount", "min_amount must not exceed max_amount")
+		return f, false
+	}
+	// Date range filters, RFC 3339 or epoch milliseconds
+	from, to, ok := dateRangeParams(c)
+	if !ok {
+		return f, false
+	}
+	f.From, f.To = from, to
+	return f, true
+}
+
+// ListTransactionsHandler returns transactions with optional filtering by user, wallet, username,
+// type, status, amount and date range, sorted by time, amount or ID and paginated by cursor
+func ListTransactionsHandler(txs service.TransactionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := service.TransactionQuery{
+			Sort:         c.DefaultQuery("sort", "created_at"), // Sort column
+			Cursor:       c.Query("cursor"),                    // Position in the list
+			PageSize:     pageSizeParam(c),                     // Page size
+			IncludeTotal: c.Query("include_total") == "true",   // Counting is opt-in
 		}
-		if txType := c.Query("type"); txType != "" {
-			query = query.Where("type = ?", txType) // Filter by transaction type
+		if !txSortColumns[q.Sort] {
+			apierror.AbortField(c, "sort", "Invalid sort; use created_at, amount or id")
+			return
 		}
-		if from := c.Query("from"); from != "" {
-			query = query.Where("created_at >= ?", from) // Filter by start date
+		order := strings.ToLower(c.DefaultQuery("order", "desc")) // Sort direction
+		if order != "asc" && order != "desc" {
+			apierror.AbortField(c, "order", "Invalid order; use asc or desc")
+			return
 		}
-		if to := c.Query("to"); to != "" {
-			query = query.Where("created_at <= ?", to) // Filter by end date
+		q.Desc = order == "desc"
+		filter, ok := transactionFilter(c) // Apply filters
+		if !ok {
+			return
 		}
-		var total int64 // Total transaction count
-		// Get total count of transactions matching the filters
-		if err := query.Count(&total).Error; err != nil {
+		list, cached, err := txs.List(c.Request.Context(), filter, q)
+		if errors.Is(err, service.ErrInvalidCursor) {
+			apierror.Abort(c, apierror.InvalidCursor, "Invalid cursor")
+			return
+		} else if err != nil {
 			// If error occurs, return internal server error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count transactions"})
+			apierror.Abort(c, apierror.Internal, "Failed to fetch transactions")
 			return
 		}
-		var txs []domain.Transaction // Slice to hold transactions
-		// Fetch paginated transactions with filters applied
-		if err := query.Order("created_at desc").Offset(offset).Limit(pageSize).Find(&txs).Error; err != nil {
-			// If error occurs, return internal server error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transactions"})
+		resp := gin.H{
+			"transactions": list.Transactions, // List of transactions
+			"page_size":    list.PageSize,     // Page size
+			"next_cursor":  list.NextCursor,   // Cursor to the next page
+			"prev_cursor":  list.PrevCursor,   // Cursor to the previous page
+			"cached":       cached,            // Whether the response is from cache
+		}
+		if list.Total != nil {
+			resp["total"] = *list.Total // Total number of transactions
+		}
+		respond(c, http.StatusOK, resp) // Return the response
+	}
+}
+
+// AssignRoleRequest represents a role assignment request
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"` // New role for the user
+	Note string `json:"note" binding:"max=255"`  // Why the role is changed
+}
+
+// ListRolesHandler returns every role with the permissions it grants
+func ListRolesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		respond(c, http.StatusOK, gin.H{"roles": domain.RolePermissions}) // Return the role catalogue
+	}
+}
+
+// AssignRoleHandler proposes a role change. The change is applied once a second admin approves it;
+// after that the user's existing tokens stop passing permission checks until they log in again.
+func AssignRoleHandler(users service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("userID"); !exists {
+			// If the acting admin is unknown, return unauthorized
+			apierror.Abort(c, apierror.Unauthenticated, "Unauthorized")
 			return
 		}
-		// The total number of pages
-		totalPages := (int(total) + pageSize - 1) / pageSize
-		respData := gin.H{
-			"transactions": txs,        // List of transactions
-			"page":         page,       // Current page
-			"page_size":    pageSize,   // Page size
-			"total":        total,      // Total number of transactions
-			"total_pages":  totalPages, // Total pages
-			"cached":       false,      // Indicate response is not from cache
-		}
-		// Cache the response for future requests
-		_ = utils.SetCache(ctx, rdb, cacheKey, respData, 60*time.Second)
-		c.JSON(http.StatusOK, respData) // Return the response
+		targetID, ok := parseIDParam(c, "id") // Parse target user ID
+		if !ok {
+			// If invalid, return bad request
+			apierror.AbortField(c, "id", "Invalid user ID")
+			return
+		}
+		var req AssignRoleRequest // Bind JSON request to struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			// If binding fails, return bad request
+			apierror.AbortInvalid(c, err, "Invalid request")
+			return
+		}
+		action, err := users.ProposeRole(c.Request.Context(), targetID, req.Role, req.Note, audit.FromRequest(c))
+		switch {
+		case errors.Is(err, service.ErrUnknownRole):
+			apierror.Abort(c, apierror.UnknownRole, "Unknown role")
+		case errors.Is(err, service.ErrSelfAction):
+			// Admins cannot lock themselves out
+			apierror.Abort(c, apierror.SelfActionForbidden, "Cannot change your own role")
+		case errors.Is(err, service.ErrUserNotFound):
+			apierror.Abort(c, apierror.UserNotFound, "User not found")
+		case errors.Is(err, service.ErrRoleUnchanged):
+			apierror.Abort(c, apierror.RoleUnchanged, "User already has this role")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to propose role change")
+		default:
+			respond(c, http.StatusAccepted, gin.H{"message": "Role change awaiting approval", "approval": toPendingActionResponse(action)})
+		}
+	}
+}
+
+// ReverseTransactionRequest represents a reversal request
+type ReverseTransactionRequest struct {
+	Note string `json:"note" binding:"required,max=255"` // Why the transaction is reversed
+}
+
+// ReverseTransactionHandler reverses a transaction. Reversals up to the threshold are applied
+// immediately; larger ones are submitted for approval by a second admin.
+func ReverseTransactionHandler(txs service.TransactionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		txID, ok := parseIDParam(c, "id") // Parse transaction ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid transaction ID")
+			return
+		}
+		var req ReverseTransactionRequest // Bind JSON request to struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.AbortInvalid(c, err, "Invalid request")
+			return
+		}
+		result, action, err := txs.Reverse(c.Request.Context(), txID, req.Note, audit.FromRequest(c))
+		switch {
+		case errors.Is(err, service.ErrTransactionNotFound):
+			apierror.Abort(c, apierror.TransactionNotFound, "Transaction not found")
+		case errors.Is(err, ledger.ErrNotReversible):
+			apierror.Abort(c, apierror.TransactionNotReversible, "Transaction cannot be reversed")
+		case errors.Is(err, ledger.ErrInsufficientFunds):
+			// The receiver has already spent the money
+			apierror.Abort(c, apierror.InsufficientFundsToReverse, "Insufficient funds to reverse")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Reversal failed")
+		case action != nil:
+			respond(c, http.StatusAccepted, gin.H{"message": "Reversal awaiting approval", "approval": toPendingActionResponse(action)})
+		default:
+			respond(c, http.StatusOK, gin.H{"message": "Transaction reversed", "reversal": result})
+		}
 	}
 }
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"                         // Request-scoped cancellation
+	"encoding/json"                   // Raw payloads in responses
+	"errors"                          // Error inspection
+	"net/http"                        // HTTP status codes
+	"wallet_system/internal/apierror" // Error responses
+	"wallet_system/internal/approval" // Maker-checker workflow
+	"wallet_system/internal/audit"    // Audit log
+	"wallet_system/internal/domain"   // Importing domain models
+	"wallet_system/internal/service"  // Business logic
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// DecisionRequest represents an approve or reject request
+type DecisionRequest struct {
+	Note string `json:"note" binding:"max=255"` // Optional reason for the decision
+}
+
+// PendingActionResponse represents a pending action returned to admins
+type PendingActionResponse struct {
+	ID           uint            `json:"id"`            // Action ID
+	Type         string          `json:"type"`          // Action type
+	Payload      json.RawMessage `json:"payload"`       // Parameters
+	TargetType   string          `json:"target_type"`   // Kind of object acted upon
+	TargetID     string          `json:"target_id"`     // ID of the object acted upon
+	Status       string          `json:"status"`        // Current status
+	ProposedBy   uint            `json:"proposed_by"`   // Proposer
+	ProposalNote string          `json:"proposal_note"` // Reason for the proposal
+	DecidedBy    *uint           `json:"decided_by"`    // Decider
+	DecisionNote string          `json:"decision_note"` // Reason for the decision
+	Result       json.RawMessage `json:"result"`        // Execution result
+	CreatedAt    int64           `json:"created_at"`    // Creation time in milliseconds
+	ExpiresAt    int64           `json:"expires_at"`    // Expiry time in milliseconds
+	DecidedAt    *int64          `json:"decided_at"`    // Decision time in milliseconds
+}
+
+// toPendingActionResponse maps a pending action to its response format
+func toPendingActionResponse(a *domain.PendingAction) PendingActionResponse {
+	resp := PendingActionResponse{
+		ID:           a.ID,
+		Type:         a.Type,
+		Payload:      json.RawMessage(a.Payload),
+		TargetType:   a.TargetType,
+		TargetID:     a.TargetID,
+		Status:       a.Status,
+		ProposedBy:   a.ProposedBy,
+		ProposalNote: a.ProposalNote,
+		DecidedBy:    a.DecidedBy,
+		DecisionNote: a.DecisionNote,
+		CreatedAt:    a.CreatedAt,
+		ExpiresAt:    a.ExpiresAt,
+		DecidedAt:    a.DecidedAt,
+	}
+	// Only include a result once there is one
+	if a.Result != "" {
+		resp.Result = json.RawMessage(a.Result)
+	}
+	return resp
+}
+
+// approvalError maps workflow errors to HTTP responses
+func approvalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, approval.ErrNotFound):
+		apierror.Abort(c, apierror.ApprovalNotFound, "Pending action not found")
+	case errors.Is(err, approval.ErrNotPending):
+		apierror.Abort(c, apierror.ApprovalNotPending, "Action is no longer pending")
+	case errors.Is(err, approval.ErrExpired):
+		apierror.Abort(c, apierror.ApprovalExpired, "Action has expired")
+	case errors.Is(err, approval.ErrSelfApproval):
+		apierror.Abort(c, apierror.SelfApproval, "Proposer cannot decide their own action")
+	case errors.Is(err, approval.ErrForbidden):
+		apierror.Abort(c, apierror.PermissionDenied, "Missing permission for this action")
+	default:
+		apierror.Abort(c, apierror.Internal, "Failed to process action")
+	}
+}
+
+// ListApprovalsHandler lists pending actions the caller is allowed to decide, filtered by status
+func ListApprovalsHandler(approvals service.ApprovalService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role") // Role set by RequirePermission
+		actions, err := approvals.List(c.Request.Context(), role, c.DefaultQuery("status", domain.ApprovalPending))
+		if err != nil {
+			apierror.Abort(c, apierror.Internal, "Failed to fetch pending actions")
+			return
+		}
+		resp := []PendingActionResponse{} // Empty list rather than null
+		for i := range actions {
+			resp = append(resp, toPendingActionResponse(&actions[i]))
+		}
+		respond(c, http.StatusOK, gin.H{"approvals": resp}) // Return the list
+	}
+}
+
+// GetApprovalHandler returns a single pending action
+func GetApprovalHandler(approvals service.ApprovalService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseIDParam(c, "id") // Parse action ID
+		if !ok {
+			apierror.Abort(c, apierror.ApprovalNotFound, "Pending action not found")
+			return
+		}
+		action, err := approvals.Get(c.Request.Context(), c.GetString("role"), id)
+		if err != nil {
+			approvalError(c, err)
+			return
+		}
+		respond(c, http.StatusOK, gin.H{"approval": toPendingActionResponse(action)})
+	}
+}
+
+// decisionHandler builds the approve and reject handlers, which differ only in the workflow call
+func decisionHandler(decide func(context.Context, string, uint, string, audit.Entry) (*domain.PendingAction, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseIDParam(c, "id") // Parse action ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid action ID")
+			return
+		}
+		var req DecisionRequest // Body is optional
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				apierror.AbortInvalid(c, err, "Invalid request")
+				return
+			}
+		}
+		action, err := decide(c.Request.Context(), c.GetString("role"), id, req.Note, audit.FromRequest(c))
+		if errors.Is(err, approval.ErrExecutionFailed) {
+			// The decision was recorded but the operation could not be applied
+			apierror.AbortWith(c, &apierror.Error{
+				Code:    apierror.ApprovalExecutionFailed,
+				Message: err.Error(),
+				Meta:    gin.H{"approval": toPendingActionResponse(action)}, // Recorded decision
+			})
+			return
+		}
+		if err != nil {
+			approvalError(c, err)
+			return
+		}
+		respond(c, http.StatusOK, gin.H{"approval": toPendingActionResponse(action)})
+	}
+}
+
+// ApproveHandler approves and executes a pending action
+func ApproveHandler(approvals service.ApprovalService) gin.HandlerFunc {
+	return decisionHandler(approvals.Approve)
+}
+
+// RejectHandler rejects a pending action
+func RejectHandler(approvals service.ApprovalService) gin.HandlerFunc {
+	return decisionHandler(approvals.Reject)
+}
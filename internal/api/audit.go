@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/csv"                      // CSV export
+	"encoding/json"                     // JSON Lines export
+	"net/http"                          // HTTP status codes
+	"strconv"                           // String conversion
+	"wallet_system/internal/apierror"   // Error responses
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/logging"    // Request logger
+	"wallet_system/internal/repository" // Query filters
+	"wallet_system/internal/service"    // Business logic
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// AuditLogResponse represents an audit record returned to admins
+type AuditLogResponse struct {
+	ID         uint   `json:"id"`          // Record ID
+	ActorID    *uint  `json:"actor_id"`    // Acting user, null if anonymous
+	Action     string `json:"action"`      // Action name
+	TargetType string `json:"target_type"` // Kind of object acted upon
+	TargetID   string `json:"target_id"`   // ID of the object acted upon
+	Before     string `json:"before"`      // JSON state before
+	After      string `json:"after"`       // JSON state after
+	IP         string `json:"ip"`          // Client IP
+	UserAgent  string `json:"user_agent"`  // Client user agent
+	RequestID  string `json:"request_id"`  // Request ID
+	CreatedAt  int64  `json:"created_at"`  // Timestamp in milliseconds
+	PrevHash   string `json:"prev_hash"`   // Hash of the previous record
+	Hash       string `json:"hash"`        // Hash of this record
+}
+
+// toAuditLogResponse maps an audit record to its response format
+func toAuditLogResponse(a domain.AuditLog) AuditLogResponse {
+	return AuditLogResponse{
+		ID:         a.ID,
+		ActorID:    a.ActorID,
+		Action:     a.Action,
+		TargetType: a.TargetType,
+		TargetID:   a.TargetID,
+		Before:     a.Before,
+		After:      a.After,
+		IP:         a.IP,
+		UserAgent:  a.UserAgent,
+		RequestID:  a.RequestID,
+		CreatedAt:  a.CreatedAt,
+		PrevHash:   a.PrevHash,
+		Hash:       a.Hash,
+	}
+}
+
+// auditFilter reads the audit log filters from the request, writing an error response if any is invalid
+func auditFilter(c *gin.Context) (repository.AuditFilter, bool) {
+	var f repository.AuditFilter // Filters to apply
+	if v := c.Query("actor_id"); v != "" {
+		actorID, err := strconv.ParseUint(v, 10, 0)
+		if err != nil {
+			apierror.AbortField(c, "actor_id", "Invalid actor_id")
+			return f, false
+		}
+		id := uint(actorID)
+		f.ActorID = &id // Filter by actor
+	}
+	if action := c.Query("action"); action != "" {
+		f.Actions = []string{action} // Filter by action
+	}
+	f.TargetType = c.Query("target_type") // Filter by target kind
+	f.TargetID = c.Query("target_id")     // Filter by target ID
+	// Date filters are RFC 3339 or epoch milliseconds
+	for param, dst := range map[string]**int64{"from": &f.From, "to": &f.To} {
+		if v := c.Query(param); v != "" {
+			ms, ok := parseTimeParam(v)
+			if !ok {
+				// If invalid, return bad request
+				apierror.AbortField(c, param, "Invalid "+param+" timestamp")
+				return f, false
+			}
+			*dst = &ms // Filter by date
+		}
+	}
+	return f, true
+}
+
+// ListAuditLogsHandler returns audit records, newest first, with optional filters
+func ListAuditLogsHandler(audits service.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page := 1                                // Default page number
+		pageSize := service.DefaultAuditPageSize // Default page size
+		if p := c.Query("page"); p != "" {
+			if v, err := strconv.Atoi(p); err == nil && v > 0 {
+				page = v // Set page if valid
+			}
+		}
+		// Check and set page size within limits
+		if ps := c.Query("page_size"); ps != "" {
+			if v, err := strconv.Atoi(ps); err == nil && v > 0 && v <= service.MaxAuditPageSize {
+				pageSize = v // Set page size
+			}
+		}
+		filter, ok := auditFilter(c) // Apply filters
+		if !ok {
+			return // Response already written
+		}
+		list, err := audits.List(c.Request.Context(), filter, page, pageSize)
+		if err != nil {
+			apierror.Abort(c, apierror.Internal, "Failed to fetch audit records")
+			return
+		}
+		resp := make([]AuditLogResponse, len(list.Records)) // Map records to response format
+		for i, r := range list.Records {
+			resp[i] = toAuditLogResponse(r)
+		}
+		respond(c, http.StatusOK, gin.H{
+			"records":     resp,            // List of records
+			"page":        list.Page,       // Current page
+			"page_size":   list.PageSize,   // Page size
+			"total":       list.Total,      // Total number of records
+			"total_pages": list.TotalPages, // Total pages
+		})
+	}
+}
+
+// ExportAuditLogsHandler streams matching audit records in chain order as JSON Lines or CSV
+func ExportAuditLogsHandler(audits service.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		format := c.DefaultQuery("format", "jsonl") // Export format
+		if format != "jsonl" && format != "csv" {
+			apierror.Abort(c, apierror.UnsupportedFormat, "Unsupported format")
+			return
+		}
+		filter, ok := auditFilter(c) // Apply filters
+		if !ok {
+			return // Response already written
+		}
+		c.Header("Content-Disposition", "attachment; filename=audit."+format) // Download as a file
+		var write func(*domain.AuditLog) error                                // Encodes one record
+		var csvWriter *csv.Writer                                             // Only used for CSV
+		if format == "csv" {
+			c.Header("Content-Type", "text/csv")
+			csvWriter = csv.NewWriter(c.Writer)
+			_ = csvWriter.Write([]string{"id", "actor_id", "action", "target_type", "target_id", "before", "after", "ip", "user_agent", "request_id", "created_at", "prev_hash", "hash"})
+			write = func(r *domain.AuditLog) error {
+				actor := "" // Empty for anonymous actions
+				if r.ActorID != nil {
+					actor = strconv.FormatUint(uint64(*r.ActorID), 10)
+				}
+				_ = csvWriter.Write([]string{
+					strconv.FormatUint(uint64(r.ID), 10), actor, r.Action, r.TargetType, r.TargetID,
+					r.Before, r.After, r.IP, r.UserAgent, r.RequestID,
+					strconv.FormatInt(r.CreatedAt, 10), r.PrevHash, r.Hash,
+				})
+				csvWriter.Flush()
+				return csvWriter.Error()
+			}
+		} else {
+			c.Header("Content-Type", "application/x-ndjson")
+			enc := json.NewEncoder(c.Writer) // JSON Lines encoder
+			write = func(r *domain.AuditLog) error {
+				return enc.Encode(toAuditLogResponse(*r))
+			}
+		}
+		err := audits.Export(c.Request.Context(), filter, write)
+		if err != nil && !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")        // Errors are JSON
+			c.Writer.Header().Del("Content-Disposition") // Not a download
+			apierror.Abort(c, apierror.Internal, "Failed to fetch audit records")
+			return
+		} else if err != nil {
+			// Stop streaming; the client sees a truncated file
+			logging.Logger(c).WithField("error", err.Error()).Error("Export interrupted")
+			return
+		}
+		if csvWriter != nil {
+			csvWriter.Flush() // Header row of an empty export
+		}
+	}
+}
@@ -1,15 +1,15 @@
 package api
 
 import (
-	"net/http"                      // HTTP status codes
-	"regexp"                        // Regular expressions
-	"strings"                       // String manipulation
-	"wallet_system/internal/domain" // Importing domain models
-	"wallet_system/internal/utils"  // Utility functions
-
-	"github.com/gin-gonic/gin"   // Gin web framework
-	"golang.org/x/crypto/bcrypt" // Password hashing
-	"gorm.io/gorm"               // GORM ORM library
+	"errors"                          // Error inspection
+	"net/http"                        // HTTP status codes
+	"wallet_system/internal/apierror" // Error responses
+	"wallet_system/internal/audit"    // Audit log
+	"wallet_system/internal/domain"   // Importing domain models
+	"wallet_system/internal/service"  // Business logic
+	"wallet_system/internal/utils"    // Utility functions
+
+	"github.com/gin-gonic/gin" // Gin web framework
 )
 
 // Request and Response structs
@@ -24,91 +24,201 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"` // Password must be provided
 }
 
+// Request struct for refreshing a session
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"` // Refresh token must be provided
+}
+
+// Request struct for logout; the refresh token is optional
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // Refresh token to revoke alongside the access token
+}
+
+// Request struct for completing a password reset
+type PasswordResetRequest struct {
+	Token       string `json:"token" binding:"required"`        // Reset token issued by an admin
+	NewPassword string `json:"new_password" binding:"required"` // New password
+}
+
 // Response struct for authentication
 type AuthResponse struct {
-	Token string `json:"token"` // JWT token
+	Token        string `json:"token"`         // JWT access token
+	RefreshToken string `json:"refresh_token"` // JWT refresh token
+	TokenType    string `json:"token_type"`    // Always "Bearer"
+	ExpiresIn    int64  `json:"expires_in"`    // Access token lifetime in seconds
 }
 
-// isValidUsername checks if the username contains only alphabetic characters
-func isValidUsername(username string) bool {
-	matched, _ := regexp.MatchString(`^[A-Za-z]+$`, username) // Regex to match alphabetic characters only
-	return matched                                            // Return whether it matched
+// MeResponse represents the authenticated user's profile
+type MeResponse struct {
+	ID          uint                `json:"id"`          // User ID
+	Username    string              `json:"username"`    // Username
+	Role        string              `json:"role"`        // User role
+	Permissions []domain.Permission `json:"permissions"` // Permissions granted by the role
+	Wallet      *domain.Wallet      `json:"wallet"`      // Associated wallet, if created
 }
 
-// isValidPassword checks if the password length is between 8 and 15 characters
-func isValidPassword(password string) bool {
-	return len(password) >= 8 && len(password) <= 15 // Return true if length is valid
+// toAuthResponse maps a token pair to the response format
+func toAuthResponse(t *service.Tokens) AuthResponse {
+	return AuthResponse{
+		Token:        t.AccessToken,  // Access token
+		RefreshToken: t.RefreshToken, // Refresh token
+		TokenType:    "Bearer",       // Token type for the Authorization header
+		ExpiresIn:    t.ExpiresIn,    // Access token lifetime
+	}
 }
 
-// isValidPassword checks if the password length is between 8 and 15 characters
-func RegisterHandler(db *gorm.DB) gin.HandlerFunc {
+// RegisterHandler creates a new user account
+func RegisterHandler(auth service.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req RegisterRequest // Bind JSON request to struct
 		if err := c.ShouldBindJSON(&req); err != nil {
 			// If binding fails, return bad request
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			apierror.AbortInvalid(c, err, "Invalid request")
 			return
 		}
-		// Validate username and password
-		if !isValidUsername(req.Username) {
-			// If username is invalid, return bad request
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Username must be alphabetic only"})
+		err := auth.Register(c.Request.Context(), req.Username, req.Password)
+		switch {
+		case errors.Is(err, service.ErrInvalidUsername):
+			apierror.AbortField(c, "username", "Username must be alphabetic only")
+		case errors.Is(err, service.ErrInvalidPassword):
+			apierror.AbortField(c, "password", "Password must be 8-15 characters")
+		case errors.Is(err, service.ErrUsernameTaken):
+			apierror.Abort(c, apierror.UsernameTaken, "Username already exists")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to hash password")
+		default:
+			respond(c, http.StatusCreated, gin.H{"message": "User registered successfully"})
+		}
+	}
+}
+
+// LoginHandler authenticates a user and returns an access and refresh token
+func LoginHandler(auth service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req LoginRequest // Bind JSON request to struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			// If binding fails, return bad request
+			apierror.AbortInvalid(c, err, "Invalid request")
 			return
 		}
-		// Validate password length
-		if !isValidPassword(req.Password) {
-			// If password is invalid, return bad request
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be 8-15 characters"})
+		tokens, err := auth.Login(c.Request.Context(), req.Username, req.Password, audit.FromRequest(c))
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			apierror.Abort(c, apierror.InvalidCredentials, "Invalid credentials")
+		case errors.Is(err, service.ErrAccountClosed):
+			apierror.Abort(c, apierror.AccountClosed, "Account closed")
+		case errors.Is(err, service.ErrPasswordResetRequired):
+			apierror.Abort(c, apierror.PasswordResetRequired, "Password reset required")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to generate token")
+		default:
+			respond(c, http.StatusOK, toAuthResponse(tokens))
+		}
+	}
+}
+
+// RefreshHandler exchanges a valid refresh token for a new token pair.
+// Refresh tokens are single use: the presented token is revoked and a new one issued.
+func RefreshHandler(auth service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RefreshRequest // Bind JSON request to struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			// If binding fails, return bad request
+			apierror.AbortInvalid(c, err, "Invalid request")
 			return
 		}
-		// Hash the password and create the user
-		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-		if err != nil {
-			// If hashing fails, return internal server error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		tokens, err := auth.Refresh(c.Request.Context(), req.RefreshToken)
+		switch {
+		case errors.Is(err, service.ErrInvalidRefreshToken):
+			apierror.Abort(c, apierror.InvalidRefreshToken, "Invalid or expired refresh token")
+		case errors.Is(err, service.ErrSessionStore):
+			apierror.Abort(c, apierror.ServiceUnavailable, "Session store unavailable")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to generate token")
+		default:
+			respond(c, http.StatusOK, toAuthResponse(tokens))
+		}
+	}
+}
+
+// LogoutHandler revokes the current access token and, if given, the refresh token
+func LogoutHandler(auth service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := c.Get("claims") // Get token claims from context
+		// Check if claims exist in context
+		if !exists {
+			// If not, return unauthorized
+			apierror.Abort(c, apierror.Unauthenticated, "Unauthorized")
 			return
 		}
-		// Create user with lowercase username to ensure uniqueness
-		user := domain.User{Username: strings.ToLower(req.Username), Password: string(hash)}
-		// Attempt to create the user in the database
-		if err := db.Create(&user).Error; err != nil {
-			// If creation fails (e.g., duplicate username), return bad request
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Username already exists"})
+		var req LogoutRequest // Body is optional
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				// If binding fails, return bad request
+				apierror.AbortInvalid(c, err, "Invalid request")
+				return
+			}
+		}
+		if err := auth.Logout(c.Request.Context(), claims.(*utils.Claims), req.RefreshToken); err != nil {
+			// If the session store is unavailable, return service unavailable
+			apierror.Abort(c, apierror.ServiceUnavailable, "Session store unavailable")
 			return
 		}
 		// Return success response
-		c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully"})
+		respond(c, http.StatusOK, gin.H{"message": "Logged out"})
 	}
 }
 
-// LoginHandler authenticates a user and returns a JWT token
-func LoginHandler(db *gorm.DB, jwtSecret string) gin.HandlerFunc {
+// MeHandler returns the authenticated user's profile
+func MeHandler(auth service.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var req LoginRequest // Bind JSON request to struct
-		if err := c.ShouldBindJSON(&req); err != nil {
-			// If binding fails, return bad request
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		userID, exists := c.Get("userID") // Get userID from context
+		// Check if userID exists in context
+		if !exists {
+			// If not, return unauthorized
+			apierror.Abort(c, apierror.Unauthenticated, "Unauthorized")
 			return
 		}
-		var user domain.User // Fetch user from database
-		if err := db.Where("username = ?", strings.ToLower(req.Username)).First(&user).Error; err != nil {
-			// If user not found, return unauthorized
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		user, err := auth.Me(c.Request.Context(), userID.(uint))
+		if err != nil {
+			// If user not found, return not found
+			apierror.Abort(c, apierror.UserNotFound, "User not found")
 			return
 		}
-		// Compare provided password with stored hash
-		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-			return
+		resp := MeResponse{
+			ID:          user.ID,                           // User ID
+			Username:    user.Username,                     // Username
+			Role:        user.Role,                         // User role
+			Permissions: domain.RolePermissions[user.Role], // Permissions granted by the role
 		}
-		// Generate JWT token
-		token, err := utils.GenerateJWT(user.ID, jwtSecret)
-		if err != nil {
-			// If token generation fails, return internal server error
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		// Only include the wallet if the user has created one
+		if user.Wallet.ID != 0 {
+			resp.Wallet = &user.Wallet
+		}
+		respond(c, http.StatusOK, resp) // Return the profile
+	}
+}
+
+// PasswordResetHandler sets a new password using a reset token issued by an admin
+func PasswordResetHandler(auth service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req PasswordResetRequest // Bind JSON request to struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.AbortInvalid(c, err, "Invalid request")
 			return
 		}
-		// Return the token in the response
-		c.JSON(http.StatusOK, AuthResponse{Token: token})
+		err := auth.ResetPassword(c.Request.Context(), req.Token, req.NewPassword, audit.FromRequest(c))
+		switch {
+		case errors.Is(err, service.ErrInvalidPassword):
+			apierror.AbortField(c, "new_password", "Password must be 8-15 characters")
+		case errors.Is(err, service.ErrSessionStore):
+			apierror.Abort(c, apierror.ServiceUnavailable, "Session store unavailable")
+		case errors.Is(err, service.ErrInvalidResetToken):
+			apierror.Abort(c, apierror.InvalidResetToken, "Invalid or expired reset token")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to hash password")
+		default:
+			respond(c, http.StatusOK, gin.H{"message": "Password updated"})
+		}
 	}
 }
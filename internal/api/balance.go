@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"                          // Error inspection
+	"net/http"                        // HTTP status codes
+	"time"                            // Timestamps
+	"wallet_system/internal/apierror" // Error responses
+	"wallet_system/internal/service"  // Business logic
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// atParam reads the optional at parameter, writing an error response if it is invalid
+func atParam(c *gin.Context) (*int64, bool) {
+	v := c.Query("at")
+	if v == "" {
+		return nil, true // Current balance
+	}
+	ms, ok := parseTimeParam(v)
+	if !ok {
+		apierror.AbortField(c, "at", "Invalid at; use RFC 3339 or epoch milliseconds")
+		return nil, false
+	}
+	return &ms, true
+}
+
+// writeBalance responds with a balance lookup, mapping its errors
+func writeBalance(c *gin.Context, b *service.Balance, err error, currency string) {
+	if errors.Is(err, service.ErrWalletNotFound) {
+		apierror.Abort(c, apierror.WalletNotFound, "Wallet not found")
+		return
+	} else if err != nil {
+		apierror.Abort(c, apierror.Internal, "Failed to compute balance")
+		return
+	}
+	respond(c, http.StatusOK, gin.H{
+		"wallet_id": b.WalletID,                          // Wallet
+		"balance":   b.Balance,                           // Balance at that time
+		"currency":  currency,                            // Currency
+		"at":        b.At.UTC().Format(time.RFC3339Nano), // Point in time
+	})
+}
+
+// GetBalanceHandler returns the user's wallet balance, now or at a past time given as at, i.e.
+// after every posting made at or before it
+func GetBalanceHandler(reports service.ReportService, currency string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get userID from context
+		userID, exists := c.Get("userID")
+		// Check if userID exists in context
+		if !exists {
+			// If not, return unauthorized
+			apierror.Abort(c, apierror.Unauthenticated, "Unauthorized")
+			return
+		}
+		at, ok := atParam(c) // Point in time, if any
+		if !ok {
+			return
+		}
+		b, err := reports.UserBalance(c.Request.Context(), userID.(uint), at)
+		writeBalance(c, b, err, currency)
+	}
+}
+
+// AdminGetBalanceHandler returns the balance of any wallet, including system accounts, now or at a past time
+func AdminGetBalanceHandler(reports service.ReportService, currency string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		walletID, ok := parseIDParam(c, "id") // Parse wallet ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid wallet ID")
+			return
+		}
+		at, ok := atParam(c) // Point in time, if any
+		if !ok {
+			return
+		}
+		b, err := reports.WalletBalance(c.Request.Context(), walletID, at)
+		writeBalance(c, b, err, currency)
+	}
+}
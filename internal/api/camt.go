@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"                          // Error inspection
+	"net/http"                        // HTTP status codes
+	"time"                            // Periods
+	"wallet_system/internal/apierror" // Error responses
+	"wallet_system/internal/camt"     // ISO 20022 messages
+	"wallet_system/internal/service"  // Business logic
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// camtPeriod reads the from/to window, defaulting to the previous UTC day as for an end-of-day statement
+func camtPeriod(c *gin.Context) (camt.Period, bool) {
+	from, to, ok := dateRangeParams(c)
+	if !ok {
+		return camt.Period{}, false
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour) // Start of the current UTC day
+	period := camt.Period{From: today.AddDate(0, 0, -1), To: today}
+	if from != nil {
+		period.From = time.UnixMilli(*from)
+	}
+	if to != nil {
+		period.To = time.UnixMilli(*to)
+	}
+	if !period.From.Before(period.To) {
+		apierror.AbortField(c, "from", "from must be before to")
+		return camt.Period{}, false
+	}
+	return period, true
+}
+
+// writeCamt encodes a message and sends it as an XML download
+func writeCamt(c *gin.Context, doc any, filename string) {
+	body, err := camt.Marshal(doc)
+	if err != nil {
+		apierror.Abort(c, apierror.Internal, "Failed to encode message")
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename="+filename) // Download as a file
+	c.Data(http.StatusOK, "application/xml", body)
+}
+
+// CamtExportHandler returns the camt.053 statement (kind service.CamtStatement) or camt.054
+// notification (kind service.CamtNotification) of any wallet, including system accounts, for
+// reconciliation against bank messages
+func CamtExportHandler(reports service.ReportService, kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		walletID, ok := parseIDParam(c, "id") // Parse wallet ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid wallet ID")
+			return
+		}
+		period, ok := camtPeriod(c) // Reporting window
+		if !ok {
+			return
+		}
+		doc, err := reports.Camt(c.Request.Context(), walletID, kind, period)
+		switch {
+		case errors.Is(err, service.ErrWalletNotFound):
+			apierror.Abort(c, apierror.WalletNotFound, "Wallet not found")
+		case errors.Is(err, service.ErrExportTooLarge):
+			apierror.Abort(c, apierror.ExportTooLarge, "Too many entries; narrow from/to")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to fetch transactions")
+		default:
+			writeCamt(c, doc, "camt."+kind+"-"+period.From.UTC().Format("20060102")+".xml")
+		}
+	}
+}
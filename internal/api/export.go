@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"                          // Error inspection
+	"wallet_system/internal/apierror" // Error responses
+	"wallet_system/internal/export"   // Export formats
+	"wallet_system/internal/logging"  // Request logger
+	"wallet_system/internal/service"  // Business logic
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// dateRangeParams parses the from and to query parameters, writing an error response if they are invalid
+func dateRangeParams(c *gin.Context) (from, to *int64, ok bool) {
+	for param, dst := range map[string]**int64{"from": &from, "to": &to} {
+		if v := c.Query(param); v != "" {
+			ms, valid := parseTimeParam(v)
+			if !valid {
+				apierror.AbortField(c, param, "Invalid "+param+"; use RFC 3339 or epoch milliseconds")
+				return nil, nil, false
+			}
+			*dst = &ms
+		}
+	}
+	if from != nil && to != nil && *from > *to {
+		apierror.AbortField(c, "from", "from must not be after to")
+		return nil, nil, false
+	}
+	return from, to, true
+}
+
+// exportOpener returns an opener that writes the export to the response in format, setting the
+// download headers once the writer exists
+func exportOpener(c *gin.Context, format string) service.ExportOpener {
+	return func(meta export.Meta) (export.Writer, error) {
+		w, err := export.NewWriter(format, c.Writer, meta) // Writer for the requested format
+		if err != nil {
+			return nil, err
+		}
+		c.Header("Content-Type", export.ContentType(format))                         // Format MIME type
+		c.Header("Content-Disposition", "attachment; filename=transactions."+format) // Download as a file
+		return w, nil
+	}
+}
+
+// exportError reports a failed export. Once rows have been sent the status cannot change, so the
+// failure is only logged and the client sees a truncated file.
+func exportError(c *gin.Context, err error) {
+	if c.Writer.Written() {
+		logging.Logger(c).WithField("error", err.Error()).Error("Export interrupted")
+		return
+	}
+	c.Writer.Header().Del("Content-Type")        // Errors are JSON
+	c.Writer.Header().Del("Content-Disposition") // Not a download
+	switch {
+	case errors.Is(err, service.ErrWalletNotFound):
+		apierror.Abort(c, apierror.WalletNotFound, "Wallet not found")
+	case errors.Is(err, export.ErrAccountRequired):
+		apierror.AbortField(c, "wallet_id", "OFX export needs a single wallet")
+	case errors.Is(err, export.ErrUnsupportedFormat):
+		apierror.Abort(c, apierror.UnsupportedFormat, "Unsupported format; use csv, jsonl or ofx")
+	default:
+		apierror.Abort(c, apierror.Internal, "Failed to fetch transactions")
+	}
+}
+
+// ExportTransactionHistoryHandler streams the user's transactions as CSV, JSON Lines or OFX,
+// oldest first, with counterparties and a running balance
+func ExportTransactionHistoryHandler(reports service.ReportService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get userID from context
+		userID, exists := c.Get("userID")
+		// Check if userID exists in context
+		if !exists {
+			// If not, return unauthorized
+			apierror.Abort(c, apierror.Unauthenticated, "Unauthorized")
+			return
+		}
+		from, to, ok := dateRangeParams(c) // Period to export
+		if !ok {
+			return
+		}
+		open := exportOpener(c, c.DefaultQuery("format", export.FormatCSV))
+		if err := reports.ExportHistory(c.Request.Context(), userID.(uint), from, to, open); err != nil {
+			exportError(c, err)
+		}
+	}
+}
+
+// AdminExportTransactionsHandler streams transactions matching the admin listing filters as CSV,
+// JSON Lines or OFX, oldest first. When the filters select a single wallet amounts are signed from
+// its side, with the wallet's balance after each row.
+func AdminExportTransactionsHandler(reports service.ReportService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := transactionFilter(c) // Read filters
+		if !ok {
+			return
+		}
+		open := exportOpener(c, c.DefaultQuery("format", export.FormatCSV))
+		if err := reports.ExportTransactions(c.Request.Context(), filter, open); err != nil {
+			exportError(c, err)
+		}
+	}
+}
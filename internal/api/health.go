@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"                        // Check deadlines
+	"net/http"                       // HTTP status codes
+	"time"                           // Timeouts
+	"wallet_system/internal/migrate" // Schema version check
+
+	"github.com/gin-gonic/gin"     // Gin web framework
+	"github.com/redis/go-redis/v9" // Redis client
+	"gorm.io/gorm"                 // GORM ORM library
+)
+
+// readyTimeout bounds each readiness check, so a hung dependency fails the probe instead of hanging it
+const readyTimeout = 2 * time.Second
+
+// dependencyStatus is the result of one readiness check
+type dependencyStatus struct {
+	Status string `json:"status"`          // ok or error
+	Error  string `json:"error,omitempty"` // Why the check failed
+}
+
+// HealthzHandler reports that the process is alive. It checks no dependencies, so an outage
+// of the database or Redis does not get the process restarted.
+func HealthzHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// ReadyzHandler reports whether the process can serve traffic: the database and Redis answer
+// a ping and every migration this binary knows about has been applied. Each dependency is
+// reported separately; any failure makes the response 503.
+func ReadyzHandler(db *gorm.DB, rdb *redis.Client, migrator *migrate.Migrator) gin.HandlerFunc {
+	checks := []struct {
+		name  string                          // Key in the response
+		check func(ctx context.Context) error // Fails when the dependency is unusable
+	}{
+		{"database", func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		}},
+		{"redis", func(ctx context.Context) error {
+			return rdb.Ping(ctx).Err()
+		}},
+		{"migrations", migrator.Check},
+	}
+	return func(c *gin.Context) {
+		ready := true                                // All checks passed
+		results := make(map[string]dependencyStatus) // Result per dependency
+		for _, dep := range checks {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), readyTimeout)
+			err := dep.check(ctx)
+			cancel()
+			if err != nil {
+				ready = false
+				results[dep.name] = dependencyStatus{Status: "error", Error: err.Error()}
+				continue
+			}
+			results[dep.name] = dependencyStatus{Status: "ok"}
+		}
+		if !ready {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "checks": results})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": results})
+	}
+}
@@ -0,0 +1,16 @@
+package api
+
+import (
+	"strconv"                        // String conversion
+	"wallet_system/internal/service" // Page size limits
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// pageSizeParam reads page_size, falling back to the default when missing or out of range
+func pageSizeParam(c *gin.Context) int {
+	if v, err := strconv.Atoi(c.Query("page_size")); err == nil && v > 0 && v <= service.MaxPageSize {
+		return v // Valid page size
+	}
+	return service.DefaultPageSize
+}
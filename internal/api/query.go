@@ -0,0 +1,38 @@
+package api
+
+import (
+	"strconv" // String conversion
+	"strings" // String manipulation
+	"time"    // Time parsing
+)
+
+// parseTimeParam parses a date filter given either as RFC 3339 (or a bare YYYY-MM-DD date,
+// taken as midnight UTC) or as epoch milliseconds, and returns epoch milliseconds to match created_at
+func parseTimeParam(v string) (int64, bool) {
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return ms, ms >= 0 // Epoch milliseconds
+	}
+	if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+		return t.UnixMilli(), true // RFC 3339 timestamp
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t.UnixMilli(), true // Calendar date
+	}
+	return 0, false
+}
+
+// parseSetParam splits a comma-separated parameter and checks every value against valid
+func parseSetParam(v string, valid func(string) bool) ([]string, bool) {
+	var values []string // Parsed values
+	for _, s := range strings.Split(v, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue // Tolerate trailing commas
+		}
+		if !valid(s) {
+			return nil, false
+		}
+		values = append(values, s)
+	}
+	return values, len(values) > 0
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"strings"                         // Path prefixes
+	"wallet_system/internal/apierror" // Error responses
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// respond writes a successful response. Versioned routes wrap body in {"data": body};
+// unversioned routes get body as it is.
+func respond(c *gin.Context, status int, body any) {
+	if apierror.Enveloped(c) {
+		c.JSON(status, gin.H{"data": body})
+		return
+	}
+	c.JSON(status, body)
+}
+
+// NotFoundHandler answers requests that match no route. Paths under versionPrefix get the
+// error envelope of that version.
+func NotFoundHandler(versionPrefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, versionPrefix+"/") {
+			c.Set(apierror.EnvelopeKey, true)
+		}
+		apierror.Abort(c, apierror.RouteNotFound, "Route not found")
+	}
+}
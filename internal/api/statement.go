@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"                           // Error inspection
+	"net/http"                         // HTTP status codes
+	"strconv"                          // String conversion
+	"strings"                          // String matching
+	"wallet_system/internal/apierror"  // Error responses
+	"wallet_system/internal/camt"      // ISO 20022 messages
+	"wallet_system/internal/domain"    // Importing domain models
+	"wallet_system/internal/logging"   // Request logger
+	"wallet_system/internal/service"   // Business logic
+	"wallet_system/internal/statement" // Statement rendering
+
+	"github.com/gin-gonic/gin"   // Gin web framework
+	"github.com/sirupsen/logrus" // Logging library
+)
+
+// StatementResponse represents a statement returned to its owner
+type StatementResponse struct {
+	ID               uint                    `json:"id"`                // Statement ID
+	WalletID         uint                    `json:"wallet_id"`         // Wallet
+	PeriodStart      int64                   `json:"period_start"`      // Start of the period in ms, inclusive
+	PeriodEnd        int64                   `json:"period_end"`        // End of the period in ms, exclusive
+	Currency         string                  `json:"currency"`          // ISO 4217 currency code
+	OpeningBalance   float64                 `json:"opening_balance"`   // Balance at the start
+	TotalCredits     float64                 `json:"total_credits"`     // Money in
+	TotalDebits      float64                 `json:"total_debits"`      // Money out
+	ClosingBalance   float64                 `json:"closing_balance"`   // Balance at the end
+	TransactionCount int                     `json:"transaction_count"` // Number of movements
+	CreatedAt        int64                   `json:"created_at"`        // Generation time in ms
+	Lines            []StatementLineResponse `json:"lines,omitempty"`   // Movements, on the detail view only
+}
+
+// StatementLineResponse represents one statement line
+type StatementLineResponse struct {
+	TransactionID uint    `json:"transaction_id"`         // Transaction
+	PostedAt      int64   `json:"posted_at"`              // Posting time in ms
+	Type          string  `json:"type"`                   // Transaction type
+	Counterparty  string  `json:"counterparty,omitempty"` // Owner of the other wallet
+	Description   string  `json:"description"`            // Description
+	Amount        float64 `json:"amount"`                 // Signed amount
+	Balance       float64 `json:"balance"`                // Balance after the line
+}
+
+// toStatementResponse maps a statement and any loaded lines to the response format
+func toStatementResponse(s domain.Statement) StatementResponse {
+	resp := StatementResponse{
+		ID:               s.ID,               // Statement ID
+		WalletID:         s.WalletID,         // Wallet
+		PeriodStart:      s.PeriodStart,      // Start
+		PeriodEnd:        s.PeriodEnd,        // End
+		Currency:         s.Currency,         // Currency
+		OpeningBalance:   s.OpeningBalance,   // Opening balance
+		TotalCredits:     s.TotalCredits,     // Money in
+		TotalDebits:      s.TotalDebits,      // Money out
+		ClosingBalance:   s.ClosingBalance,   // Closing balance
+		TransactionCount: s.TransactionCount, // Movements
+		CreatedAt:        s.CreatedAt,        // Generation time
+	}
+	for _, l := range s.Lines {
+		resp.Lines = append(resp.Lines, StatementLineResponse{
+			TransactionID: l.TransactionID, // Transaction
+			PostedAt:      l.PostedAt,      // Posting time
+			Type:          l.Type,          // Type
+			Counterparty:  l.Counterparty,  // Counterparty
+			Description:   l.Description,   // Description
+			Amount:        l.Amount,        // Amount
+			Balance:       l.Balance,       // Balance
+		})
+	}
+	return resp
+}
+
+// ListStatementsHandler returns the user's statements, newest period first, without lines
+func ListStatementsHandler(reports service.ReportService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID") // Get userID from context
+		if !exists {
+			apierror.Abort(c, apierror.Unauthenticated, "Unauthorized")
+			return
+		}
+		statements, err := reports.Statements(c.Request.Context(), userID.(uint))
+		if errors.Is(err, service.ErrWalletNotFound) {
+			apierror.Abort(c, apierror.WalletNotFound, "Wallet not found")
+			return
+		} else if err != nil {
+			apierror.Abort(c, apierror.Internal, "Failed to fetch statements")
+			return
+		}
+		resp := make([]StatementResponse, len(statements))
+		for i, s := range statements {
+			resp[i] = toStatementResponse(s)
+		}
+		respond(c, http.StatusOK, gin.H{"statements": resp})
+	}
+}
+
+// GetStatementHandler returns one of the user's statements with its lines, as JSON, as a printable
+// page with format=html or an Accept header preferring HTML, or as camt.053 XML with format=camt053
+func GetStatementHandler(reports service.ReportService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("userID") // Get userID from context
+		if !exists {
+			apierror.Abort(c, apierror.Unauthenticated, "Unauthorized")
+			return
+		}
+		statementID, ok := parseIDParam(c, "id") // Parse statement ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid statement ID")
+			return
+		}
+		st, owner, err := reports.Statement(c.Request.Context(), userID.(uint), statementID)
+		switch {
+		case errors.Is(err, service.ErrWalletNotFound):
+			apierror.Abort(c, apierror.WalletNotFound, "Wallet not found")
+			return
+		case errors.Is(err, service.ErrStatementNotFound):
+			apierror.Abort(c, apierror.StatementNotFound, "Statement not found")
+			return
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to fetch statement")
+			return
+		}
+		format := c.Query("format") // json, html or camt053
+		if format == "" && strings.HasPrefix(c.GetHeader("Accept"), "text/html") {
+			format = "html" // Browsers ask for HTML first
+		}
+		switch format {
+		case "camt053":
+			writeCamt(c, camt.FromStatement(st, owner), "statement-"+strconv.Itoa(int(st.ID))+".camt053.xml")
+		case "html":
+			c.Header("Content-Type", "text/html; charset=utf-8")
+			c.Status(http.StatusOK)
+			if err := statement.RenderHTML(c.Writer, st, owner); err != nil {
+				logging.Logger(c).WithFields(logrus.Fields{
+					"statement_id": st.ID,       // Statement
+					"error":        err.Error(), // Error message
+				}).Error("Failed to render statement")
+			}
+		default:
+			respond(c, http.StatusOK, toStatementResponse(*st))
+		}
+	}
+}
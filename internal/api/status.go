@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"                          // Error values
+	"net/http"                        // HTTP status codes
+	"strconv"                         // String conversion
+	"wallet_system/internal/apierror" // Error responses
+	"wallet_system/internal/audit"    // Audit log
+	"wallet_system/internal/service"  // Business logic
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// StatusRequest represents a freeze or unfreeze request
+type StatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active frozen_debits frozen_all"` // New status; closing has its own endpoint
+	Reason string `json:"reason" binding:"required,max=255"`                               // Why the status is changed
+}
+
+// CloseAccountRequest represents an account closure request
+type CloseAccountRequest struct {
+	Reason      string `json:"reason" binding:"required,max=255"` // Why the account is closed
+	FinalPayout bool   `json:"final_payout"`                      // Pay out a remaining balance instead of refusing
+}
+
+// parseIDParam parses a positive numeric path parameter
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.Atoi(c.Param(name)) // Parse the parameter
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// SetUserStatusHandler freezes or unfreezes a user account
+func SetUserStatusHandler(users service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := parseIDParam(c, "id") // Parse target user ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid user ID")
+			return
+		}
+		var req StatusRequest // Bind JSON request to struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.AbortInvalid(c, err, "Invalid request")
+			return
+		}
+		err := users.SetStatus(c.Request.Context(), userID, req.Status, req.Reason, audit.FromRequest(c))
+		switch {
+		case errors.Is(err, service.ErrSelfAction):
+			apierror.Abort(c, apierror.SelfActionForbidden, "Cannot change your own status")
+		case errors.Is(err, service.ErrUserNotFound):
+			apierror.Abort(c, apierror.UserNotFound, "User not found")
+		case errors.Is(err, service.ErrAccountClosed):
+			apierror.Abort(c, apierror.AccountAlreadyClosed, "Account is closed")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to update status")
+		default:
+			respond(c, http.StatusOK, gin.H{"message": "Status updated", "user_id": userID, "status": req.Status})
+		}
+	}
+}
+
+// SetWalletStatusHandler freezes or unfreezes a single wallet
+func SetWalletStatusHandler(users service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		walletID, ok := parseIDParam(c, "id") // Parse target wallet ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid wallet ID")
+			return
+		}
+		var req StatusRequest // Bind JSON request to struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.AbortInvalid(c, err, "Invalid request")
+			return
+		}
+		err := users.SetWalletStatus(c.Request.Context(), walletID, req.Status, req.Reason, audit.FromRequest(c))
+		switch {
+		case errors.Is(err, service.ErrWalletNotFound):
+			apierror.Abort(c, apierror.WalletNotFound, "Wallet not found")
+		case errors.Is(err, service.ErrAlreadyClosed):
+			apierror.Abort(c, apierror.WalletAlreadyClosed, "Wallet is closed")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to update status")
+		default:
+			respond(c, http.StatusOK, gin.H{"message": "Status updated", "wallet_id": walletID, "status": req.Status})
+		}
+	}
+}
+
+// CloseUserHandler permanently closes a user account and its wallet. The wallet must be
+// empty unless a final payout is requested, in which case the remaining balance is
+// withdrawn before closing.
+func CloseUserHandler(users service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := parseIDParam(c, "id") // Parse target user ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid user ID")
+			return
+		}
+		var req CloseAccountRequest // Bind JSON request to struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.AbortInvalid(c, err, "Invalid request")
+			return
+		}
+		payout, err := users.Close(c.Request.Context(), userID, req.Reason, req.FinalPayout, audit.FromRequest(c))
+		switch {
+		case errors.Is(err, service.ErrSelfAction):
+			apierror.Abort(c, apierror.SelfActionForbidden, "Cannot close your own account")
+		case errors.Is(err, service.ErrUserNotFound):
+			apierror.Abort(c, apierror.UserNotFound, "User not found")
+		case errors.Is(err, service.ErrAlreadyClosed):
+			apierror.Abort(c, apierror.AccountAlreadyClosed, "Account is already closed")
+		case errors.Is(err, service.ErrBalanceNotZero):
+			apierror.Abort(c, apierror.WalletNotEmpty, "Wallet balance must be zero; set final_payout to pay it out")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to close account")
+		default:
+			respond(c, http.StatusOK, gin.H{"message": "Account closed", "user_id": userID, "payout": payout})
+		}
+	}
+}
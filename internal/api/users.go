@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"                          // Error values
+	"net/http"                        // HTTP status codes
+	"wallet_system/internal/apierror" // Error responses
+	"wallet_system/internal/audit"    // Audit log
+	"wallet_system/internal/domain"   // Importing domain models
+	"wallet_system/internal/service"  // Service types
+	"wallet_system/internal/utils"    // Session helpers
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// UserDetailResponse represents a single user with wallet and recent activity
+type UserDetailResponse struct {
+	service.UserSummary                      // User and wallet
+	Permissions         []domain.Permission  `json:"permissions"`         // Permissions granted by the role
+	RecentTransactions  []domain.Transaction `json:"recent_transactions"` // Latest transactions touching the wallet
+	RecentLogins        []AuditLogResponse   `json:"recent_logins"`       // Latest login attempts
+}
+
+// ForcePasswordResetRequest represents a forced password reset
+type ForcePasswordResetRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"` // Why the reset is forced
+}
+
+// RevokeSessionsRequest represents a session revocation
+type RevokeSessionsRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"` // Why the sessions are revoked
+}
+
+// GetUserHandler returns a single user with their wallet, latest transactions and login history
+func GetUserHandler(users service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := parseIDParam(c, "id") // Parse target user ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid user ID")
+			return
+		}
+		detail, err := users.Get(c.Request.Context(), userID)
+		if errors.Is(err, service.ErrUserNotFound) {
+			apierror.Abort(c, apierror.UserNotFound, "User not found")
+			return
+		} else if err != nil {
+			apierror.Abort(c, apierror.Internal, "Failed to fetch user")
+			return
+		}
+		resp := UserDetailResponse{
+			UserSummary:        service.Summarize(*detail.User),                       // User and wallet
+			Permissions:        domain.RolePermissions[detail.User.Role],              // Role permissions
+			RecentTransactions: detail.RecentTransactions,                             // Latest transactions
+			RecentLogins:       make([]AuditLogResponse, 0, len(detail.RecentLogins)), // Latest logins
+		}
+		for _, l := range detail.RecentLogins {
+			resp.RecentLogins = append(resp.RecentLogins, toAuditLogResponse(l))
+		}
+		respond(c, http.StatusOK, resp) // Return the user
+	}
+}
+
+// ForcePasswordResetHandler signs a user out everywhere and blocks login until they set a new
+// password. The single-use reset token is returned to the admin to hand over out of band.
+func ForcePasswordResetHandler(users service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ForcePasswordResetRequest // Bind JSON request to struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.AbortInvalid(c, err, "Invalid request")
+			return
+		}
+		userID, ok := parseIDParam(c, "id") // Parse target user ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid user ID")
+			return
+		}
+		token, err := users.ForcePasswordReset(c.Request.Context(), userID, req.Reason, audit.FromRequest(c))
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			apierror.Abort(c, apierror.UserNotFound, "User not found")
+		case errors.Is(err, service.ErrAccountClosed):
+			apierror.Abort(c, apierror.AccountAlreadyClosed, "Account is closed")
+		case errors.Is(err, service.ErrSessionStore):
+			apierror.Abort(c, apierror.ServiceUnavailable, "Session store unavailable")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to force password reset")
+		default:
+			respond(c, http.StatusOK, gin.H{
+				"message":     "Password reset required",          // Confirmation
+				"user_id":     userID,                             // Target user
+				"reset_token": token,                              // Token for POST /auth/password/reset
+				"expires_in":  int(utils.ResetTokenTTL.Seconds()), // Token lifetime in seconds
+			})
+		}
+	}
+}
+
+// RevokeSessionsHandler invalidates every access and refresh token issued to a user so far
+func RevokeSessionsHandler(users service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RevokeSessionsRequest // Bind JSON request to struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.AbortInvalid(c, err, "Invalid request")
+			return
+		}
+		userID, ok := parseIDParam(c, "id") // Parse target user ID
+		if !ok {
+			apierror.AbortField(c, "id", "Invalid user ID")
+			return
+		}
+		err := users.RevokeSessions(c.Request.Context(), userID, req.Reason, audit.FromRequest(c))
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			apierror.Abort(c, apierror.UserNotFound, "User not found")
+		case errors.Is(err, service.ErrSessionStore):
+			apierror.Abort(c, apierror.ServiceUnavailable, "Session store unavailable")
+		case errors.Is(err, service.ErrAuditUnavailable):
+			apierror.Abort(c, apierror.Internal, "Sessions revoked but audit log unavailable")
+		case err != nil:
+			apierror.Abort(c, apierror.Internal, "Failed to fetch user")
+		default:
+			respond(c, http.StatusOK, gin.H{"message": "Sessions revoked", "user_id": userID})
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package apierror
+
+import (
+	"encoding/json"                  // JSON decoding errors
+	"errors"                         // Error inspection
+	"fmt"                            // Validation messages
+	"io"                             // Empty bodies
+	"reflect"                        // JSON field names
+	"strings"                        // Tag parsing
+	"wallet_system/internal/logging" // Request IDs
+
+	"github.com/gin-gonic/gin"               // Gin web framework
+	"github.com/gin-gonic/gin/binding"       // Request validation
+	"github.com/go-playground/validator/v10" // Validation errors
+)
+
+// EnvelopeKey is set in the Gin context of versioned routes, whose responses are wrapped in
+// the data and error envelopes. Unversioned routes keep their original bodies.
+const EnvelopeKey = "apiEnvelope"
+
+// FieldError describes one invalid field or parameter of a request
+type FieldError struct {
+	Field   string `json:"field,omitempty"` // JSON name of the field or query parameter
+	Message string `json:"message"`         // What is wrong with it
+}
+
+// Error is an error response: a code from the catalogue, a human-readable message and
+// optional details
+type Error struct {
+	Code    *Code        // Stable identifier and HTTP status
+	Message string       // Human-readable explanation; may change between releases
+	Details []FieldError // Offending fields, for INVALID_REQUEST
+	Meta    gin.H        // Extra information, such as the accepted values
+}
+
+// Error returns the code and message
+func (e *Error) Error() string {
+	return e.Code.ID + ": " + e.Message
+}
+
+// body is the error envelope of versioned routes
+type body struct {
+	Code      string       `json:"code"`                 // Stable identifier
+	Message   string       `json:"message"`              // Human-readable explanation
+	Details   []FieldError `json:"details,omitempty"`    // Offending fields
+	Meta      gin.H        `json:"meta,omitempty"`       // Extra information
+	RequestID string       `json:"request_id,omitempty"` // For support requests
+}
+
+// New returns an error with code and message
+func New(code *Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Abort answers with code and message and stops the handler chain
+func Abort(c *gin.Context, code *Code, message string) {
+	AbortWith(c, New(code, message))
+}
+
+// AbortInvalid answers INVALID_REQUEST with a detail for every field that failed to bind or
+// validate in err, which may be nil when a handler check failed instead
+func AbortInvalid(c *gin.Context, err error, message string) {
+	AbortWith(c, &Error{Code: InvalidRequest, Message: message, Details: Details(err)})
+}
+
+// AbortField answers INVALID_REQUEST for a single invalid field or query parameter
+func AbortField(c *gin.Context, field, message string) {
+	AbortWith(c, &Error{Code: InvalidRequest, Message: message, Details: []FieldError{{Field: field, Message: message}}})
+}
+
+// AbortWith answers with err and stops the handler chain. Versioned routes get the error
+// envelope; unversioned routes get the original {"error": message} body.
+func AbortWith(c *gin.Context, err *Error) {
+	if !Enveloped(c) {
+		legacy := gin.H{"error": err.Message} // Original body
+		for k, v := range err.Meta {
+			legacy[k] = v
+		}
+		c.AbortWithStatusJSON(err.Code.Status, legacy)
+		return
+	}
+	c.AbortWithStatusJSON(err.Code.Status, gin.H{"error": body{
+		Code:      err.Code.ID,
+		Message:   err.Message,
+		Details:   err.Details,
+		Meta:      err.Meta,
+		RequestID: logging.RequestID(c.Request.Context()),
+	}})
+}
+
+// Enveloped reports whether the request came in on a versioned route
+func Enveloped(c *gin.Context) bool {
+	return c.GetBool(EnvelopeKey)
+}
+
+// Details turns a binding error into one detail per offending field
+func Details(err error) []FieldError {
+	var invalid validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &invalid):
+		details := make([]FieldError, 0, len(invalid))
+		for _, fe := range invalid {
+			details = append(details, FieldError{Field: fieldPath(fe), Message: validationMessage(fe)})
+		}
+		return details
+	case errors.As(err, &typeErr):
+		return []FieldError{{Field: typeErr.Field, Message: "must be " + jsonKind(typeErr.Type)}}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return []FieldError{{Message: "body must be a JSON object"}}
+	}
+	return []FieldError{{Message: err.Error()}}
+}
+
+// fieldPath is the JSON path of a failed field, without the name of the request struct
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
+}
+
+// validationMessage explains a failed validation tag
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	}
+	return fmt.Sprintf("failed the %s check", fe.Tag())
+}
+
+// jsonKind names the JSON type a Go type is decoded from
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
+
+// Validation errors name fields by their JSON name, which is what clients send
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
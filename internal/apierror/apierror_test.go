@@ -0,0 +1,39 @@
+package apierror
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestCatalogueIsCurrent(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCatalogue(&buf); err != nil {
+		t.Fatalf("write catalogue: %v", err)
+	}
+	published, err := os.ReadFile("../../docs/errors.md")
+	if err != nil {
+		t.Fatalf("read catalogue: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), published) {
+		t.Fatal("docs/errors.md is stale; run go generate ./internal/apierror")
+	}
+}
+
+func TestCodesAreUniqueAndWellFormed(t *testing.T) {
+	format := regexp.MustCompile(`^[A-Z]+(_[A-Z]+)*$`)
+	seen := map[string]bool{}
+	for _, c := range Catalogue() {
+		if !format.MatchString(c.ID) {
+			t.Errorf("%s is not UPPER_SNAKE_CASE", c.ID)
+		}
+		if seen[c.ID] {
+			t.Errorf("%s is defined twice", c.ID)
+		}
+		if c.Status < 400 || c.Description == "" {
+			t.Errorf("%s needs an error status and a description", c.ID)
+		}
+		seen[c.ID] = true
+	}
+}
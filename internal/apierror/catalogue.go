@@ -0,0 +1,45 @@
+package apierror
+
+import (
+	"fmt"      // Table rows
+	"io"       // Output
+	"net/http" // Status text
+)
+
+// catalogueHeader introduces the generated catalogue
+const catalogueHeader = `# Error codes
+
+<!-- Generated by go generate ./internal/apierror from internal/apierror/codes.go; do not edit. -->
+
+Errors on the ` + "`/v1`" + ` routes have this shape:
+
+` + "```json" + `
+{
+  "error": {
+    "code": "INVALID_REQUEST",
+    "message": "Invalid request",
+    "details": [{"field": "amount", "message": "must be greater than 0"}],
+    "request_id": "4b1f0c2e9d6a4e0f8a7b3c5d2e1f0a9b"
+  }
+}
+` + "```" + `
+
+Match on ` + "`code`" + `, which never changes meaning. ` + "`message`" + ` is for people and may be reworded.
+` + "`details`" + ` and ` + "`meta`" + ` are present only when they carry information.
+
+| Code | Status | Meaning |
+|------|--------|---------|
+`
+
+// WriteCatalogue writes the Markdown catalogue of every error code, published as docs/errors.md
+func WriteCatalogue(w io.Writer) error {
+	if _, err := io.WriteString(w, catalogueHeader); err != nil {
+		return err
+	}
+	for _, c := range catalogue {
+		if _, err := fmt.Fprintf(w, "| `%s` | %d %s | %s |\n", c.ID, c.Status, http.StatusText(c.Status), c.Description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
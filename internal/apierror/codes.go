@@ -0,0 +1,95 @@
+package apierror
+
+import "net/http" // HTTP status codes
+
+//go:generate go run ../../cmd/errorcodes -o ../../docs/errors.md
+
+// Code is a stable, machine-readable error identifier. Clients match on the ID rather than
+// the message, so a published ID never changes meaning; add a new code instead.
+type Code struct {
+	ID          string // Value of error.code, such as INSUFFICIENT_FUNDS
+	Status      int    // HTTP status the code is served with
+	Description string // Meaning, published in the catalogue
+}
+
+var catalogue []*Code // Every code, in definition order
+
+// define registers a code for the catalogue
+func define(id string, status int, description string) *Code {
+	c := &Code{ID: id, Status: status, Description: description}
+	catalogue = append(catalogue, c)
+	return c
+}
+
+// Catalogue returns every code in definition order
+func Catalogue() []*Code {
+	return append([]*Code(nil), catalogue...)
+}
+
+// Request problems
+var (
+	InvalidRequest    = define("INVALID_REQUEST", http.StatusBadRequest, "The body or a parameter is missing, malformed or out of range; `details` names each offending field.")
+	InvalidCursor     = define("INVALID_CURSOR", http.StatusBadRequest, "The pagination cursor was not issued for this listing or has been altered; start again from the first page.")
+	UnsupportedFormat = define("UNSUPPORTED_FORMAT", http.StatusBadRequest, "The requested export format is not supported.")
+	ExportTooLarge    = define("EXPORT_TOO_LARGE", http.StatusBadRequest, "The export would hold too many entries; narrow the period.")
+	RouteNotFound     = define("ROUTE_NOT_FOUND", http.StatusNotFound, "No endpoint matches the method and path.")
+	RateLimited       = define("RATE_LIMITED", http.StatusTooManyRequests, "Too many requests; retry after the number of seconds in `Retry-After`.")
+)
+
+// Authentication and authorization
+var (
+	Unauthenticated       = define("UNAUTHENTICATED", http.StatusUnauthorized, "The access token is missing, invalid, expired or revoked, or the account changed role or was closed since it was issued; log in again.")
+	InvalidCredentials    = define("INVALID_CREDENTIALS", http.StatusUnauthorized, "The username or password is wrong.")
+	InvalidRefreshToken   = define("INVALID_REFRESH_TOKEN", http.StatusUnauthorized, "The refresh token is invalid, expired or already used; log in again.")
+	InvalidResetToken     = define("INVALID_RESET_TOKEN", http.StatusUnauthorized, "The password reset token is invalid or expired.")
+	PermissionDenied      = define("PERMISSION_DENIED", http.StatusForbidden, "The role of the caller lacks a permission the endpoint needs.")
+	PasswordResetRequired = define("PASSWORD_RESET_REQUIRED", http.StatusForbidden, "An administrator requires a new password; use the reset token to set one.")
+	AccountClosed         = define("ACCOUNT_CLOSED", http.StatusForbidden, "The account has been closed and can no longer log in.")
+)
+
+// Users and wallets
+var (
+	UsernameTaken        = define("USERNAME_TAKEN", http.StatusBadRequest, "Another user already has the username.")
+	UserNotFound         = define("USER_NOT_FOUND", http.StatusNotFound, "No user has the ID.")
+	SelfActionForbidden  = define("SELF_ACTION_FORBIDDEN", http.StatusBadRequest, "Administrators cannot change the role or status of, or close, their own account.")
+	AccountFrozen        = define("ACCOUNT_FROZEN", http.StatusForbidden, "The account of the caller is frozen and cannot move money.")
+	AccountAlreadyClosed = define("ACCOUNT_ALREADY_CLOSED", http.StatusConflict, "The account is closed, so its status and credentials can no longer change.")
+	WalletExists         = define("WALLET_EXISTS", http.StatusBadRequest, "The user already has a wallet; each user has one.")
+	WalletNotFound       = define("WALLET_NOT_FOUND", http.StatusNotFound, "The user has no wallet, or no wallet has the ID.")
+	WalletFrozen         = define("WALLET_FROZEN", http.StatusForbidden, "The wallet is frozen and cannot send money.")
+	WalletClosed         = define("WALLET_CLOSED", http.StatusForbidden, "The wallet is closed and cannot move money.")
+	WalletAlreadyClosed  = define("WALLET_ALREADY_CLOSED", http.StatusConflict, "The wallet is closed, so its status can no longer change.")
+	WalletNotEmpty       = define("WALLET_NOT_EMPTY", http.StatusConflict, "The account cannot be closed while its wallet holds money; set `final_payout` to pay it out.")
+	SystemWallet         = define("SYSTEM_WALLET", http.StatusBadRequest, "System wallets cannot be adjusted by hand.")
+)
+
+// Money movements
+var (
+	InsufficientFunds          = define("INSUFFICIENT_FUNDS", http.StatusBadRequest, "The wallet balance is lower than the amount.")
+	SelfTransfer               = define("SELF_TRANSFER", http.StatusBadRequest, "The sender and the recipient are the same user.")
+	RecipientNotFound          = define("RECIPIENT_NOT_FOUND", http.StatusNotFound, "No customer has the recipient username.")
+	RecipientWalletNotFound    = define("RECIPIENT_WALLET_NOT_FOUND", http.StatusNotFound, "The recipient has no wallet.")
+	RecipientRestricted        = define("RECIPIENT_RESTRICTED", http.StatusForbidden, "The recipient account or wallet is frozen or closed and cannot receive money.")
+	TransactionNotFound        = define("TRANSACTION_NOT_FOUND", http.StatusNotFound, "No transaction has the ID.")
+	TransactionNotReversible   = define("TRANSACTION_NOT_REVERSIBLE", http.StatusConflict, "The transaction was already reversed or is of a type that cannot be reversed.")
+	InsufficientFundsToReverse = define("INSUFFICIENT_FUNDS_TO_REVERSE", http.StatusConflict, "The recipient of the transaction no longer holds enough money to reverse it.")
+	StatementNotFound          = define("STATEMENT_NOT_FOUND", http.StatusNotFound, "The wallet has no statement with the ID.")
+)
+
+// Roles, adjustments and four-eyes approval
+var (
+	UnknownRole             = define("UNKNOWN_ROLE", http.StatusBadRequest, "The role does not exist; `GET /v1/admin/roles` lists the roles.")
+	RoleUnchanged           = define("ROLE_UNCHANGED", http.StatusBadRequest, "The user already has the role.")
+	UnknownReasonCode       = define("UNKNOWN_REASON_CODE", http.StatusBadRequest, "The adjustment reason code is not known; `meta.reason_codes` lists the accepted codes.")
+	ApprovalNotFound        = define("APPROVAL_NOT_FOUND", http.StatusNotFound, "No pending action has the ID, or the caller may not see it.")
+	ApprovalNotPending      = define("APPROVAL_NOT_PENDING", http.StatusConflict, "The action was already approved or rejected.")
+	ApprovalExpired         = define("APPROVAL_EXPIRED", http.StatusGone, "The action was not decided before it expired; propose it again.")
+	SelfApproval            = define("SELF_APPROVAL", http.StatusForbidden, "The proposer of an action cannot approve or reject it.")
+	ApprovalExecutionFailed = define("APPROVAL_EXECUTION_FAILED", http.StatusUnprocessableEntity, "The approval was recorded but the action could not be applied; `meta.approval` holds the action.")
+)
+
+// Server problems
+var (
+	ServiceUnavailable = define("SERVICE_UNAVAILABLE", http.StatusServiceUnavailable, "A dependency such as the session store is unavailable; retry later.")
+	Internal           = define("INTERNAL_ERROR", http.StatusInternalServerError, "The server failed to handle the request; quote the `request_id` when reporting it.")
+)
@@ -0,0 +1,267 @@
+package approval
+
+import (
+	"context"                       // Context for the expiry loop
+	"encoding/json"                 // JSON payloads and results
+	"errors"                        // Error values
+	"fmt"                           // Error wrapping
+	"strconv"                       // String conversion
+	"time"                          // Timestamps
+	"wallet_system/internal/audit"  // Audit log
+	"wallet_system/internal/domain" // Importing domain models
+
+	"github.com/sirupsen/logrus" // Logging library
+	"gorm.io/gorm"               // GORM ORM library
+	"gorm.io/gorm/clause"        // Row locking
+)
+
+// Errors returned by the approval workflow
+var (
+	ErrUnknownAction   = errors.New("unknown action type")                     // Type was never registered
+	ErrNotFound        = errors.New("pending action not found")                // No such proposal
+	ErrNotPending      = errors.New("action is no longer pending")             // Already decided or expired
+	ErrExpired         = errors.New("action has expired")                      // Decided after ExpiresAt
+	ErrSelfApproval    = errors.New("proposer cannot decide their own action") // Four-eyes rule
+	ErrForbidden       = errors.New("missing permission for this action")      // Approver lacks the permission
+	ErrExecutionFailed = errors.New("action failed to execute")                // Approved but Execute returned an error
+)
+
+// Action defines how a registered action type is authorized and executed
+type Action struct {
+	Permission domain.Permission                                                              // Required to propose and to decide
+	Execute    func(tx *gorm.DB, action *domain.PendingAction, meta audit.Entry) (any, error) // Runs the operation inside the approval transaction; meta carries the approver and client details
+	OnCommit   func(result any)                                                               // Optional hook run after a successful commit, e.g. cache invalidation
+}
+
+// registry holds the action types known to the workflow; it is filled at startup
+var registry = map[string]Action{}
+
+// Register makes an action type available to the workflow
+func Register(actionType string, a Action) {
+	registry[actionType] = a
+}
+
+// Lookup returns the definition of a registered action type
+func Lookup(actionType string) (Action, bool) {
+	a, ok := registry[actionType]
+	return a, ok
+}
+
+// Proposal describes an operation to submit for approval
+type Proposal struct {
+	Type       string // Registered action type
+	Payload    any    // Parameters, marshaled to JSON
+	TargetType string // Kind of object acted upon
+	TargetID   string // ID of the object acted upon
+	Note       string // Why the action is proposed
+}
+
+// Propose records a pending action. meta carries the proposer and client details for the audit log.
+func Propose(db *gorm.DB, p Proposal, meta audit.Entry, ttl time.Duration) (*domain.PendingAction, error) {
+	if _, ok := registry[p.Type]; !ok {
+		return nil, ErrUnknownAction // Only registered actions can be proposed
+	}
+	if meta.ActorID == nil {
+		return nil, ErrForbidden // Anonymous proposals are not allowed
+	}
+	payload, err := json.Marshal(p.Payload) // Encode parameters
+	if err != nil {
+		return nil, err
+	}
+	action := &domain.PendingAction{
+		Type:         p.Type,                          // Action type
+		Payload:      string(payload),                 // Parameters
+		TargetType:   p.TargetType,                    // Target kind
+		TargetID:     p.TargetID,                      // Target ID
+		Status:       domain.ApprovalPending,          // Waiting for a decision
+		ProposedBy:   *meta.ActorID,                   // Proposer
+		ProposalNote: p.Note,                          // Reason
+		ExpiresAt:    time.Now().Add(ttl).UnixMilli(), // Expiry in milliseconds
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(action).Error; err != nil {
+			return err // Return error to rollback
+		}
+		entry := meta                                 // Actor and client details
+		entry.Action = audit.ActionApprovalPropose    // Action name
+		entry.TargetType = "pending_action"           // Target kind
+		entry.TargetID = strconv.Itoa(int(action.ID)) // Target ID
+		entry.After = action                          // Proposal as recorded
+		return audit.Record(tx, entry)                // Return error to rollback
+	})
+	if err != nil {
+		return nil, err
+	}
+	return action, nil
+}
+
+// decide locks a pending action and checks that the actor may decide it
+func decide(tx *gorm.DB, id uint, actorID uint, role string) (*domain.PendingAction, Action, error) {
+	var action domain.PendingAction // Locked so two admins cannot decide at once
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&action, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, Action{}, ErrNotFound
+		}
+		return nil, Action{}, err
+	}
+	def, ok := registry[action.Type] // Definition of the action type
+	if !ok {
+		return nil, Action{}, ErrUnknownAction
+	}
+	if action.Status != domain.ApprovalPending {
+		return nil, Action{}, ErrNotPending // Already decided
+	}
+	if action.ProposedBy == actorID {
+		return nil, Action{}, ErrSelfApproval // Four-eyes rule
+	}
+	if !domain.HasPermission(role, def.Permission) {
+		return nil, Action{}, ErrForbidden // Decider must hold the action's permission
+	}
+	return &action, def, nil
+}
+
+// finish stores the decision on an action and records it in the audit log
+func finish(tx *gorm.DB, action *domain.PendingAction, status string, actorID uint, note string, result any, meta audit.Entry, auditAction string) error {
+	now := time.Now().UnixMilli() // Decision time
+	encoded := ""                 // JSON result, if any
+	if result != nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		encoded = string(b)
+	}
+	before := *action // State before the decision
+	action.Status, action.DecidedBy, action.DecisionNote, action.Result, action.DecidedAt = status, &actorID, note, encoded, &now
+	if err := tx.Model(action).Updates(map[string]any{
+		"status":        status,  // New status
+		"decided_by":    actorID, // Decider
+		"decision_note": note,    // Reason
+		"result":        encoded, // Result or error
+		"decided_at":    now,     // Decision time
+	}).Error; err != nil {
+		return err
+	}
+	entry := meta                                 // Actor and client details
+	entry.Action = auditAction                    // Action name
+	entry.TargetType = "pending_action"           // Target kind
+	entry.TargetID = strconv.Itoa(int(action.ID)) // Target ID
+	entry.Before = before                         // State before
+	entry.After = action                          // State after
+	return audit.Record(tx, entry)
+}
+
+// expire marks an action as expired if its deadline has passed
+func expire(db *gorm.DB, action *domain.PendingAction) bool {
+	if time.Now().UnixMilli() <= action.ExpiresAt {
+		return false // Still open
+	}
+	_ = db.Model(action).Update("status", domain.ApprovalExpired).Error // Best effort; the sweeper retries
+	return true
+}
+
+// Approve executes a pending action on behalf of a second admin.
+// If execution fails the action is marked failed and ErrExecutionFailed is returned.
+func Approve(db *gorm.DB, id uint, role string, note string, meta audit.Entry) (*domain.PendingAction, error) {
+	if meta.ActorID == nil {
+		return nil, ErrForbidden // Anonymous decisions are not allowed
+	}
+	actorID := *meta.ActorID // Approver
+	var (
+		action  *domain.PendingAction // Action being approved
+		def     Action                // Its definition
+		result  any                   // Result of execution
+		execErr error                 // Error from execution
+		expired bool                  // Whether the action had expired
+	)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		action, def, err = decide(tx, id, actorID, role) // Lock and check
+		if err != nil {
+			return err
+		}
+		if expired = expire(tx, action); expired {
+			return nil // Commit the expiry
+		}
+		// Run the action in a savepoint so a failure can still be recorded
+		execErr = tx.Transaction(func(inner *gorm.DB) error {
+			var err error
+			result, err = def.Execute(inner, action, meta)
+			return err
+		})
+		if execErr != nil {
+			return finish(tx, action, domain.ApprovalFailed, actorID, note, map[string]string{"error": execErr.Error()}, meta, audit.ActionApprovalFailed)
+		}
+		return finish(tx, action, domain.ApprovalApproved, actorID, note, result, meta, audit.ActionApprovalApprove)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, ErrExpired
+	}
+	if execErr != nil {
+		return action, fmt.Errorf("%w: %v", ErrExecutionFailed, execErr)
+	}
+	// Run post-commit hooks
+	if def.OnCommit != nil {
+		def.OnCommit(result)
+	}
+	return action, nil
+}
+
+// Reject closes a pending action without executing it
+func Reject(db *gorm.DB, id uint, role string, note string, meta audit.Entry) (*domain.PendingAction, error) {
+	if meta.ActorID == nil {
+		return nil, ErrForbidden // Anonymous decisions are not allowed
+	}
+	actorID := *meta.ActorID // Rejecting admin
+	var (
+		action  *domain.PendingAction // Action being rejected
+		expired bool                  // Whether the action had expired
+	)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		action, _, err = decide(tx, id, actorID, role) // Lock and check
+		if err != nil {
+			return err
+		}
+		if expired = expire(tx, action); expired {
+			return nil // Commit the expiry
+		}
+		return finish(tx, action, domain.ApprovalRejected, actorID, note, nil, meta, audit.ActionApprovalReject)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, ErrExpired
+	}
+	return action, nil
+}
+
+// ExpireStale marks every pending action past its deadline as expired
+func ExpireStale(db *gorm.DB) (int64, error) {
+	res := db.Model(&domain.PendingAction{}).
+		Where("status = ? AND expires_at < ?", domain.ApprovalPending, time.Now().UnixMilli()).
+		Update("status", domain.ApprovalExpired)
+	return res.RowsAffected, res.Error
+}
+
+// RunExpiry sweeps expired actions every interval until ctx is cancelled
+func RunExpiry(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval) // Sweep interval
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return // Stop when the server shuts down
+		case <-ticker.C:
+			if n, err := ExpireStale(db); err != nil {
+				logrus.WithField("error", err.Error()).Error("Failed to expire pending actions")
+			} else if n > 0 {
+				logrus.WithField("count", n).Info("Expired pending actions")
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package audit
+
+import (
+	"crypto/sha256"                  // Hashing for the tamper-evident chain
+	"encoding/hex"                   // Hex encoding of hashes
+	"encoding/json"                  // JSON encoding of before/after state
+	"errors"                         // Error values
+	"strconv"                        // String conversion
+	"strings"                        // String building
+	"time"                           // Timestamps
+	"wallet_system/internal/domain"  // Importing domain models
+	"wallet_system/internal/logging" // Request ID of the current request
+
+	"github.com/gin-gonic/gin" // Gin web framework
+	"gorm.io/gorm"             // GORM ORM library
+)
+
+// Actions recorded in the audit log
+const (
+	ActionLogin             = "auth.login"                // Successful login
+	ActionLoginFailed       = "auth.login_failed"         // Failed login attempt
+	ActionRoleAssign        = "role.assign"               // Role granted or revoked
+	ActionAdminUsersList    = "admin.users.list"          // Admin listed users
+	ActionAdminTxList       = "admin.transactions.list"   // Admin listed transactions
+	ActionAdminAuditList    = "admin.audit.list"          // Admin listed audit records
+	ActionAdminAuditExport  = "admin.audit.export"        // Admin exported audit records
+	ActionApprovalPropose   = "approval.propose"          // Admin proposed a sensitive action
+	ActionApprovalApprove   = "approval.approve"          // Second admin approved and executed it
+	ActionApprovalReject    = "approval.reject"           // Second admin rejected it
+	ActionApprovalFailed    = "approval.failed"           // Approved action failed to execute
+	ActionTxReverse         = "transaction.reverse"       // Transaction reversed
+	ActionWalletAdjust      = "wallet.adjust"             // Manual balance adjustment posted
+	ActionUserStatus        = "user.status"               // User frozen or unfrozen
+	ActionWalletStatus      = "wallet.status"             // Wallet frozen or unfrozen
+	ActionUserClose         = "user.close"                // User account and wallet closed
+	ActionPasswordReset     = "user.password_reset"       // Admin forced a password reset
+	ActionPasswordResetDone = "auth.password_reset"       // User completed a password reset
+	ActionSessionsRevoke    = "user.sessions_revoke"      // Admin revoked all of a user's sessions
+	ActionAdminUserGet      = "admin.users.get"           // Admin viewed a single user
+	ActionAdminTxExport     = "admin.transactions.export" // Admin exported transactions
+	ActionAdminCamtExport   = "admin.camt.export"         // Admin exported ISO 20022 camt messages
+	ActionAdminBalanceGet   = "admin.wallets.balance"     // Admin looked up a wallet's balance at a point in time
+	ActionLedgerReconcile   = "ledger.reconcile"          // Correcting entry posted for a balance discrepancy
+)
+
+// Entry describes an action to record
+type Entry struct {
+	ActorID    *uint  // User who performed the action, nil if anonymous
+	Action     string // Action name
+	TargetType string // Kind of object acted upon
+	TargetID   string // ID of the object acted upon
+	Before     any    // State before the action, marshaled to JSON
+	After      any    // State after the action, marshaled to JSON
+	IP         string // Client IP address
+	UserAgent  string // Client user agent
+	RequestID  string // Request ID
+}
+
+// ErrChainLockMissing is returned when the audit_chain_lock row is gone, so appends cannot be serialized
+var ErrChainLockMissing = errors.New("audit chain lock row is missing")
+
+// FromRequest returns an Entry pre-filled with the request's actor and client details
+func FromRequest(c *gin.Context) Entry {
+	e := Entry{
+		IP:        c.ClientIP(),                           // Client IP address
+		UserAgent: c.Request.UserAgent(),                  // Client user agent
+		RequestID: logging.RequestID(c.Request.Context()), // Request ID assigned by the request ID middleware
+	}
+	// Attach the authenticated user, if any
+	if userID, exists := c.Get("userID"); exists {
+		id := userID.(uint)
+		e.ActorID = &id
+	}
+	return e
+}
+
+// encode marshals state to JSON, leaving nil state empty
+func encode(v any) (string, error) {
+	if v == nil {
+		return "", nil // No state recorded
+	}
+	b, err := json.Marshal(v) // Marshal state to JSON
+	if err != nil {
+		return "", err // Return error if marshaling fails
+	}
+	return string(b), nil
+}
+
+// truncate shortens s to at most max characters, the width of its column, so the stored
+// value and the hash computed over it agree
+func truncate(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max])
+	}
+	return s
+}
+
+// ComputeHash returns the chain hash of a record; the record's own Hash and ID are not included
+func ComputeHash(r *domain.AuditLog) string {
+	actor := "" // Empty for anonymous actions
+	if r.ActorID != nil {
+		actor = strconv.FormatUint(uint64(*r.ActorID), 10)
+	}
+	// Fields are joined with a separator that cannot appear unescaped in JSON or header values
+	fields := []string{
+		r.PrevHash,
+		strconv.FormatInt(r.CreatedAt, 10),
+		actor,
+		r.Action,
+		r.TargetType,
+		r.TargetID,
+		r.Before,
+		r.After,
+		r.IP,
+		r.UserAgent,
+		r.RequestID,
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f"))) // Hash the canonical form
+	return hex.EncodeToString(sum[:])
+}
+
+// Record appends an entry to the audit log. Pass a transaction to make the
+// record part of the same unit of work as the action it describes.
+func Record(db *gorm.DB, e Entry) error {
+	before, err := encode(e.Before) // Encode state before the action
+	if err != nil {
+		return err
+	}
+	after, err := encode(e.After) // Encode state after the action
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Take the chain lock before reading the head. Locking the head itself does nothing while the
+		// log is empty; the update holds the lock row on MySQL and PostgreSQL, and the database write
+		// lock on SQLite, until the transaction ends, so appends from every goroutine and instance
+		// queue here. Nothing else is held while waiting, so a caller's open transaction cannot
+		// deadlock against another append.
+		// The counter always changes, so MySQL reports the row as affected.
+		lock := tx.Exec("UPDATE audit_chain_lock SET version = version + 1 WHERE id = 1")
+		if lock.Error != nil {
+			return lock.Error
+		}
+		if lock.RowsAffected != 1 {
+			return ErrChainLockMissing
+		}
+		var last domain.AuditLog // Current head of the chain
+		if err := tx.Order("id desc").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+		record := domain.AuditLog{
+			ActorID:    e.ActorID,                  // Actor
+			Action:     e.Action,                   // Action name
+			TargetType: e.TargetType,               // Target kind
+			TargetID:   e.TargetID,                 // Target ID
+			Before:     before,                     // State before
+			After:      after,                      // State after
+			IP:         truncate(e.IP, 64),         // Client IP
+			UserAgent:  truncate(e.UserAgent, 255), // Client user agent, which the client controls
+			RequestID:  truncate(e.RequestID, 64),  // Request ID
+			CreatedAt:  time.Now().UnixMilli(),     // Timestamp in milliseconds
+			PrevHash:   last.Hash,                  // Link to the previous record
+		}
+		record.Hash = ComputeHash(&record) // Seal the record
+		return tx.Create(&record).Error    // Append the record
+	})
+}
+
+// VerifyResult summarizes a chain verification
+type VerifyResult struct {
+	Checked  int64  // Number of records checked
+	Valid    bool   // Whether the whole chain is intact
+	BrokenID uint   // ID of the first record that failed verification
+	Reason   string // Why verification failed
+}
+
+// Verify walks the whole audit log in order and checks every hash and link
+func Verify(db *gorm.DB) (*VerifyResult, error) {
+	result := &VerifyResult{Valid: true} // Assume valid until a record fails
+	prevHash := ""                       // The first record links to nothing
+	var batch []domain.AuditLog          // Records are checked in batches to bound memory
+	err := db.Order("id asc").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			r := &batch[i]
+			result.Checked++ // Count checked records
+			// Check the link to the previous record
+			if r.PrevHash != prevHash {
+				result.Valid, result.BrokenID, result.Reason = false, r.ID, "previous hash does not match"
+				return gorm.ErrInvalidData // Stop iterating
+			}
+			// Check the record's own hash
+			if ComputeHash(r) != r.Hash {
+				result.Valid, result.BrokenID, result.Reason = false, r.ID, "record hash does not match contents"
+				return gorm.ErrInvalidData // Stop iterating
+			}
+			prevHash = r.Hash // Advance the chain
+		}
+		return nil
+	}).Error
+	// A broken chain is reported through the result, not as an error
+	if err != nil && err != gorm.ErrInvalidData {
+		return nil, err
+	}
+	return result, nil
+}
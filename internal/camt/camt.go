@@ -0,0 +1,415 @@
+package camt
+
+import (
+	"encoding/xml"                  // XML encoding
+	"math"                          // Absolute values
+	"strconv"                       // Number formatting
+	"time"                          // Timestamps
+	"wallet_system/internal/domain" // Importing domain models
+)
+
+// Message namespaces
+const (
+	Namespace053 = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08" // Bank to customer statement
+	Namespace054 = "urn:iso:std:iso:20022:tech:xsd:camt.054.001.08" // Bank to customer debit/credit notification
+)
+
+// Issuer is the proprietary code issuer used for bank transaction codes and account IDs
+const Issuer = "WALLET"
+
+// Entry is one booked movement on an account, signed from the account's side
+type Entry struct {
+	TransactionID uint      // Transaction ID, used as entry and end-to-end reference
+	BookedAt      time.Time // Posting time
+	Amount        float64   // Positive for credits, negative for debits
+	Type          string    // Transaction type
+	Reversal      bool      // Whether the entry undoes an earlier one
+	Counterparty  string    // Owner of the other wallet, if any
+	Info          string    // Unstructured remittance information
+}
+
+// EntryFromTransaction maps a transaction to an entry on the given wallet
+func EntryFromTransaction(tx domain.Transaction, walletID uint, counterparty string) Entry {
+	amount := tx.Amount // Credits are positive
+	if tx.ToWalletID == nil || *tx.ToWalletID != walletID {
+		amount = -tx.Amount // Debits are negative
+	}
+	return Entry{
+		TransactionID: tx.ID,                            // Reference
+		BookedAt:      time.UnixMilli(tx.CreatedAt),     // Posting time
+		Amount:        amount,                           // Signed amount
+		Type:          tx.Type,                          // Type
+		Reversal:      tx.Type == domain.TxTypeReversal, // Reversal indicator
+		Counterparty:  counterparty,                     // Other side
+		Info:          tx.Note,                          // Note
+	}
+}
+
+// Account identifies the wallet a message is about
+type Account struct {
+	WalletID uint   // Wallet ID, sent as a proprietary account identification
+	Currency string // ISO 4217 currency code
+	Owner    string // Owner's name
+}
+
+// Amount is an amount with its currency
+type Amount struct {
+	Currency string `xml:"Ccy,attr"`  // ISO 4217 currency code
+	Value    string `xml:",chardata"` // Decimal amount
+}
+
+// amount returns the absolute value of v with two decimals
+func amount(v float64, currency string) Amount {
+	return Amount{Currency: currency, Value: strconv.FormatFloat(math.Abs(v), 'f', 2, 64)}
+}
+
+// creditDebit returns the credit/debit indicator for a signed amount
+func creditDebit(v float64) string {
+	if v < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+// dateTime formats a time as an ISO date-time in UTC
+func dateTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// date formats a time as an ISO date in UTC
+func date(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// GroupHeader identifies a message
+type GroupHeader struct {
+	MessageID string `xml:"MsgId"`   // Unique message ID, at most 35 characters
+	CreatedAt string `xml:"CreDtTm"` // Creation time
+}
+
+// AccountXML is the account block of a statement or notification
+type AccountXML struct {
+	ID struct {
+		Other struct {
+			ID     string `xml:"Id"` // Wallet ID
+			Scheme struct {
+				Proprietary string `xml:"Prtry"` // Scheme name
+			} `xml:"SchmeNm"`
+			Issuer string `xml:"Issr"` // Issuer of the ID
+		} `xml:"Othr"`
+	} `xml:"Id"`
+	Currency string `xml:"Ccy"`            // Account currency
+	Owner    *owner `xml:"Ownr,omitempty"` // Account owner
+}
+
+// owner names the account owner
+type owner struct {
+	Name string `xml:"Nm"` // Owner's name
+}
+
+// accountXML builds the account block
+func accountXML(a Account) AccountXML {
+	var x AccountXML
+	x.ID.Other.ID = strconv.FormatUint(uint64(a.WalletID), 10)
+	x.ID.Other.Scheme.Proprietary = "WALLETID"
+	x.ID.Other.Issuer = Issuer
+	x.Currency = a.Currency
+	if a.Owner != "" {
+		x.Owner = &owner{Name: truncate(a.Owner, 140)}
+	}
+	return x
+}
+
+// EntryXML is a booked entry
+type EntryXML struct {
+	Reference string `xml:"NtryRef"`           // Entry reference
+	Amount    Amount `xml:"Amt"`               // Absolute amount
+	CdtDbt    string `xml:"CdtDbtInd"`         // CRDT or DBIT
+	Reversal  bool   `xml:"RvslInd,omitempty"` // Set for reversals
+	Status    struct {
+		Code string `xml:"Cd"` // BOOK
+	} `xml:"Sts"`
+	BookingDate struct {
+		DateTime string `xml:"DtTm"` // Posting time
+	} `xml:"BookgDt"`
+	ValueDate struct {
+		Date string `xml:"Dt"` // Value date
+	} `xml:"ValDt"`
+	ServicerRef string `xml:"AcctSvcrRef"` // Transaction ID
+	BankTxCode  struct {
+		Proprietary struct {
+			Code   string `xml:"Cd"`   // Transaction type
+			Issuer string `xml:"Issr"` // Issuer of the code
+		} `xml:"Prtry"`
+	} `xml:"BkTxCd"`
+	Details struct {
+		Tx struct {
+			Refs struct {
+				EndToEndID string `xml:"EndToEndId"` // Transaction ID
+			} `xml:"Refs"`
+			Amount         Amount          `xml:"Amt"`                 // Absolute amount
+			CdtDbt         string          `xml:"CdtDbtInd"`           // CRDT or DBIT
+			RelatedParties *relatedParties `xml:"RltdPties,omitempty"` // Counterparty
+			Remittance     *remittance     `xml:"RmtInf,omitempty"`    // Note
+		} `xml:"TxDtls"`
+	} `xml:"NtryDtls"`
+}
+
+// relatedParties names the other side of a movement
+type relatedParties struct {
+	Debtor   *party `xml:"Dbtr,omitempty"` // Payer, on credits
+	Creditor *party `xml:"Cdtr,omitempty"` // Payee, on debits
+}
+
+// remittance carries free-text information about a movement
+type remittance struct {
+	Unstructured string `xml:"Ustrd"` // Free text, at most 140 characters
+}
+
+// party is a named counterparty
+type party struct {
+	Party struct {
+		Name string `xml:"Nm"` // Name
+	} `xml:"Pty"`
+}
+
+// entryXML builds an entry block
+func entryXML(e Entry, currency string) EntryXML {
+	var x EntryXML
+	ref := strconv.FormatUint(uint64(e.TransactionID), 10) // Transaction ID as text
+	x.Reference = ref
+	x.Amount = amount(e.Amount, currency)
+	x.CdtDbt = creditDebit(e.Amount)
+	x.Reversal = e.Reversal
+	x.Status.Code = "BOOK"
+	x.BookingDate.DateTime = dateTime(e.BookedAt)
+	x.ValueDate.Date = date(e.BookedAt)
+	x.ServicerRef = ref
+	x.BankTxCode.Proprietary.Code = e.Type
+	x.BankTxCode.Proprietary.Issuer = Issuer
+	x.Details.Tx.Refs.EndToEndID = ref
+	x.Details.Tx.Amount = x.Amount
+	x.Details.Tx.CdtDbt = x.CdtDbt
+	if e.Counterparty != "" {
+		p := &party{}
+		p.Party.Name = truncate(e.Counterparty, 140)
+		x.Details.Tx.RelatedParties = &relatedParties{}
+		if e.Amount < 0 {
+			x.Details.Tx.RelatedParties.Creditor = p // We paid them
+		} else {
+			x.Details.Tx.RelatedParties.Debtor = p // They paid us
+		}
+	}
+	if e.Info != "" {
+		x.Details.Tx.Remittance = &remittance{Unstructured: truncate(e.Info, 140)}
+	}
+	return x
+}
+
+// truncate shortens s to at most max characters
+func truncate(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max])
+	}
+	return s
+}
+
+// BalanceXML is an opening or closing balance
+type BalanceXML struct {
+	Type struct {
+		CodeOrProprietary struct {
+			Code string `xml:"Cd"` // OPBD or CLBD
+		} `xml:"CdOrPrtry"`
+	} `xml:"Tp"`
+	Amount Amount `xml:"Amt"`       // Absolute amount
+	CdtDbt string `xml:"CdtDbtInd"` // CRDT for positive balances, DBIT for negative
+	Date   struct {
+		Date string `xml:"Dt"` // Balance date
+	} `xml:"Dt"`
+}
+
+// balanceXML builds a balance block
+func balanceXML(code string, v float64, currency string, at time.Time) BalanceXML {
+	var x BalanceXML
+	x.Type.CodeOrProprietary.Code = code
+	x.Amount = amount(v, currency)
+	x.CdtDbt = creditDebit(v)
+	x.Date.Date = date(at)
+	return x
+}
+
+// summaryXML totals the entries of a report
+type summaryXML struct {
+	Total struct {
+		Count string `xml:"NbOfNtries"` // Number of entries
+		Sum   string `xml:"Sum"`        // Sum of absolute amounts
+	} `xml:"TtlNtries"`
+	Credits totalXML `xml:"TtlCdtNtries"` // Credit entries
+	Debits  totalXML `xml:"TtlDbtNtries"` // Debit entries
+}
+
+// totalXML is a count and sum of entries
+type totalXML struct {
+	Count string `xml:"NbOfNtries"` // Number of entries
+	Sum   string `xml:"Sum"`        // Sum of absolute amounts
+}
+
+// summary totals entries
+func summary(entries []Entry) summaryXML {
+	var s summaryXML
+	var credits, debits float64 // Sums
+	var nCredits, nDebits int   // Counts
+	for _, e := range entries {
+		if e.Amount < 0 {
+			debits -= e.Amount
+			nDebits++
+		} else {
+			credits += e.Amount
+			nCredits++
+		}
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	s.Total.Count = strconv.Itoa(len(entries))
+	s.Total.Sum = format(credits + debits)
+	s.Credits = totalXML{Count: strconv.Itoa(nCredits), Sum: format(credits)}
+	s.Debits = totalXML{Count: strconv.Itoa(nDebits), Sum: format(debits)}
+	return s
+}
+
+// Period is the reporting window of a message
+type Period struct {
+	From time.Time // Start, inclusive
+	To   time.Time // End, exclusive
+}
+
+// periodXML is the from/to block of a report
+type periodXML struct {
+	From string `xml:"FrDtTm"` // Start
+	To   string `xml:"ToDtTm"` // End
+}
+
+// periodOf builds the from/to block; the end is reported as the last instant of the period
+func periodOf(p Period) *periodXML {
+	return &periodXML{From: dateTime(p.From), To: dateTime(p.To.Add(-time.Millisecond))}
+}
+
+// StatementXML is the Stmt block of a camt.053 message
+type StatementXML struct {
+	ID        string       `xml:"Id"`        // Statement ID
+	CreatedAt string       `xml:"CreDtTm"`   // Creation time
+	Period    *periodXML   `xml:"FrToDt"`    // Reporting window
+	Account   AccountXML   `xml:"Acct"`      // Account
+	Balances  []BalanceXML `xml:"Bal"`       // Opening and closing balances
+	Summary   summaryXML   `xml:"TxsSummry"` // Entry totals
+	Entries   []EntryXML   `xml:"Ntry"`      // Booked entries
+}
+
+// Document053 is a camt.053 bank to customer statement
+type Document053 struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"` // Message namespace
+	Message   struct {
+		Header     GroupHeader    `xml:"GrpHdr"` // Message header
+		Statements []StatementXML `xml:"Stmt"`   // One statement per account
+	} `xml:"BkToCstmrStmt"`
+}
+
+// NotificationXML is the Ntfctn block of a camt.054 message
+type NotificationXML struct {
+	ID        string     `xml:"Id"`        // Notification ID
+	CreatedAt string     `xml:"CreDtTm"`   // Creation time
+	Period    *periodXML `xml:"FrToDt"`    // Reporting window
+	Account   AccountXML `xml:"Acct"`      // Account
+	Summary   summaryXML `xml:"TxsSummry"` // Entry totals
+	Entries   []EntryXML `xml:"Ntry"`      // Booked entries
+}
+
+// Document054 is a camt.054 bank to customer debit/credit notification
+type Document054 struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"` // Message namespace
+	Message   struct {
+		Header        GroupHeader       `xml:"GrpHdr"` // Message header
+		Notifications []NotificationXML `xml:"Ntfctn"` // One notification per account
+	} `xml:"BkToCstmrDbtCdtNtfctn"`
+}
+
+// messageID builds a message or report ID of at most 35 characters
+func messageID(kind string, walletID uint, now time.Time) string {
+	return truncate(kind+"-"+strconv.FormatUint(uint64(walletID), 10)+"-"+strconv.FormatInt(now.UnixMilli(), 10), 35)
+}
+
+// Statement builds a camt.053 statement of an account over a period. opening is the balance
+// at the start of the period; the closing balance is opening plus the entries.
+func Statement(acct Account, period Period, opening float64, entries []Entry) *Document053 {
+	now := time.Now() // Creation time
+	closing := opening
+	for _, e := range entries {
+		closing += e.Amount
+	}
+	st := StatementXML{
+		ID:        messageID("STMT", acct.WalletID, now), // Statement ID
+		CreatedAt: dateTime(now),                         // Creation time
+		Period:    periodOf(period),                      // Window
+		Account:   accountXML(acct),                      // Account
+		Balances: []BalanceXML{
+			balanceXML("OPBD", opening, acct.Currency, period.From),                      // Opening booked
+			balanceXML("CLBD", closing, acct.Currency, period.To.Add(-time.Millisecond)), // Closing booked
+		},
+		Summary: summary(entries), // Totals
+	}
+	for _, e := range entries {
+		st.Entries = append(st.Entries, entryXML(e, acct.Currency))
+	}
+	doc := &Document053{Namespace: Namespace053}
+	doc.Message.Header = GroupHeader{MessageID: messageID("CAMT053", acct.WalletID, now), CreatedAt: dateTime(now)}
+	doc.Message.Statements = []StatementXML{st}
+	return doc
+}
+
+// Notification builds a camt.054 debit/credit notification of the entries on an account
+func Notification(acct Account, period Period, entries []Entry) *Document054 {
+	now := time.Now() // Creation time
+	n := NotificationXML{
+		ID:        messageID("NTFC", acct.WalletID, now), // Notification ID
+		CreatedAt: dateTime(now),                         // Creation time
+		Period:    periodOf(period),                      // Window
+		Account:   accountXML(acct),                      // Account
+		Summary:   summary(entries),                      // Totals
+	}
+	for _, e := range entries {
+		n.Entries = append(n.Entries, entryXML(e, acct.Currency))
+	}
+	doc := &Document054{Namespace: Namespace054}
+	doc.Message.Header = GroupHeader{MessageID: messageID("CAMT054", acct.WalletID, now), CreatedAt: dateTime(now)}
+	doc.Message.Notifications = []NotificationXML{n}
+	return doc
+}
+
+// Marshal encodes a message with an XML declaration
+func Marshal(doc any) ([]byte, error) {
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// FromStatement builds a camt.053 message from a stored statement and its lines
+func FromStatement(st *domain.Statement, owner string) *Document053 {
+	entries := make([]Entry, 0, len(st.Lines)) // One entry per line
+	for _, l := range st.Lines {
+		entries = append(entries, Entry{
+			TransactionID: l.TransactionID,                 // Reference
+			BookedAt:      time.UnixMilli(l.PostedAt),      // Posting time
+			Amount:        l.Amount,                        // Signed amount
+			Type:          l.Type,                          // Type
+			Reversal:      l.Type == domain.TxTypeReversal, // Reversal indicator
+			Counterparty:  l.Counterparty,                  // Other side
+			Info:          l.Description,                   // Description
+		})
+	}
+	acct := Account{WalletID: st.WalletID, Currency: st.Currency, Owner: owner} // Statement account
+	period := Period{From: time.UnixMilli(st.PeriodStart), To: time.UnixMilli(st.PeriodEnd)}
+	return Statement(acct, period, st.OpeningBalance, entries)
+}
@@ -0,0 +1,406 @@
+package camt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"wallet_system/internal/domain"
+)
+
+// node is a parsed XML element
+type node struct {
+	Space    string
+	Name     string
+	Attrs    map[string]string
+	Text     string
+	Children []*node
+}
+
+// parse reads an XML document into a tree
+func parse(t *testing.T, b []byte) *node {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	var stack []*node
+	var root *node
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch tk := tok.(type) {
+		case xml.StartElement:
+			n := &node{Space: tk.Name.Space, Name: tk.Name.Local, Attrs: map[string]string{}}
+			for _, a := range tk.Attr {
+				n.Attrs[a.Name.Local] = a.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, n)
+			} else {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(bytes.TrimSpace(tk))
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if root == nil {
+		t.Fatal("document has no root element")
+	}
+	return root
+}
+
+// child returns the first child with the given name
+func (n *node) child(name string) *node {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// path follows child names from n
+func (n *node) path(names ...string) *node {
+	cur := n
+	for _, name := range names {
+		if cur = cur.child(name); cur == nil {
+			return nil
+		}
+	}
+	return cur
+}
+
+// all returns every descendant with the given name
+func (n *node) all(name string) []*node {
+	var out []*node
+	for _, c := range n.Children {
+		if c.Name == name {
+			out = append(out, c)
+		}
+		out = append(out, c.all(name)...)
+	}
+	return out
+}
+
+// Sequences from the camt.053.001.08 and camt.054.001.08 schemas. Only the elements this
+// package can emit are listed; children must appear in this order and required ones must be present.
+var sequences = map[string]struct {
+	order    []string
+	required []string
+}{
+	"GrpHdr":    {[]string{"MsgId", "CreDtTm"}, []string{"MsgId", "CreDtTm"}},
+	"Stmt":      {[]string{"Id", "CreDtTm", "FrToDt", "Acct", "Bal", "TxsSummry", "Ntry"}, []string{"Id", "Acct", "Bal"}},
+	"Ntfctn":    {[]string{"Id", "CreDtTm", "FrToDt", "Acct", "TxsSummry", "Ntry"}, []string{"Id", "Acct"}},
+	"Acct":      {[]string{"Id", "Ccy", "Ownr"}, []string{"Id"}},
+	"Othr":      {[]string{"Id", "SchmeNm", "Issr"}, []string{"Id"}},
+	"Bal":       {[]string{"Tp", "Amt", "CdtDbtInd", "Dt"}, []string{"Tp", "Amt", "CdtDbtInd", "Dt"}},
+	"Ntry":      {[]string{"NtryRef", "Amt", "CdtDbtInd", "RvslInd", "Sts", "BookgDt", "ValDt", "AcctSvcrRef", "BkTxCd", "NtryDtls"}, []string{"Amt", "CdtDbtInd", "Sts", "BkTxCd"}},
+	"TxDtls":    {[]string{"Refs", "Amt", "CdtDbtInd", "RltdPties", "RmtInf"}, nil},
+	"RltdPties": {[]string{"Dbtr", "Cdtr"}, nil},
+	"Prtry":     {[]string{"Cd", "Issr"}, []string{"Cd"}},
+}
+
+// Simple type patterns from the schemas
+var (
+	amountPattern   = regexp.MustCompile(`^\d{1,13}(\.\d{1,5})?$`) // ActiveOrHistoricCurrencyAndAmount, 18 digits total
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)             // ActiveOrHistoricCurrencyCode
+	countPattern    = regexp.MustCompile(`^[0-9]{1,15}$`)          // Max15NumericText
+	decimalPattern  = regexp.MustCompile(`^-?\d+(\.\d+)?$`)        // DecimalNumber
+)
+
+// validate checks a message against the schema constraints above. It runs everywhere;
+// TestMessagesValidateAgainstSchemas checks the full schemas with xmllint.
+func validate(t *testing.T, root *node, namespace, message string) {
+	t.Helper()
+	if root.Name != "Document" || root.Space != namespace {
+		t.Fatalf("root is {%s}%s, want {%s}Document", root.Space, root.Name, namespace)
+	}
+	if root.child(message) == nil {
+		t.Fatalf("missing %s", message)
+	}
+	var walk func(n *node)
+	walk = func(n *node) {
+		// Some names are both leaves and aggregates, e.g. SchmeNm/Prtry and BkTxCd/Prtry
+		if seq, ok := sequences[n.Name]; ok && len(n.Children) > 0 {
+			pos := map[string]int{}
+			for i, name := range seq.order {
+				pos[name] = i
+			}
+			last := -1
+			for _, c := range n.Children {
+				p, known := pos[c.Name]
+				if !known {
+					t.Errorf("%s: unexpected child %s", n.Name, c.Name)
+					continue
+				}
+				if p < last {
+					t.Errorf("%s: %s out of schema order", n.Name, c.Name)
+				}
+				last = p
+			}
+			for _, name := range seq.required {
+				if n.child(name) == nil {
+					t.Errorf("%s: missing required %s", n.Name, name)
+				}
+			}
+		}
+		switch n.Name {
+		case "Amt":
+			if !amountPattern.MatchString(n.Text) {
+				t.Errorf("Amt %q does not match the amount type", n.Text)
+			}
+			if !currencyPattern.MatchString(n.Attrs["Ccy"]) {
+				t.Errorf("Amt currency %q is not an ISO 4217 code", n.Attrs["Ccy"])
+			}
+		case "Ccy":
+			if !currencyPattern.MatchString(n.Text) {
+				t.Errorf("Ccy %q is not an ISO 4217 code", n.Text)
+			}
+		case "CdtDbtInd":
+			if n.Text != "CRDT" && n.Text != "DBIT" {
+				t.Errorf("CdtDbtInd %q is not CRDT or DBIT", n.Text)
+			}
+		case "NbOfNtries":
+			if !countPattern.MatchString(n.Text) {
+				t.Errorf("NbOfNtries %q is not Max15NumericText", n.Text)
+			}
+		case "Sum":
+			if !decimalPattern.MatchString(n.Text) {
+				t.Errorf("Sum %q is not a decimal", n.Text)
+			}
+		case "CreDtTm", "DtTm", "FrDtTm", "ToDtTm":
+			if _, err := time.Parse(time.RFC3339, n.Text); err != nil {
+				t.Errorf("%s %q is not an ISO date-time", n.Name, n.Text)
+			}
+		case "Dt":
+			if n.Text != "" {
+				if _, err := time.Parse("2006-01-02", n.Text); err != nil {
+					t.Errorf("Dt %q is not an ISO date", n.Text)
+				}
+			}
+		case "MsgId", "NtryRef", "AcctSvcrRef", "EndToEndId", "Cd", "Issr":
+			if n.Text == "" || len(n.Text) > 35 {
+				t.Errorf("%s %q is not Max35Text", n.Name, n.Text)
+			}
+		case "Nm", "Ustrd":
+			if n.Text == "" || len([]rune(n.Text)) > 140 {
+				t.Errorf("%s %q is not Max140Text", n.Name, n.Text)
+			}
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(root)
+}
+
+// schemaFor returns the vendored schema for a message namespace
+func schemaFor(namespace string) string {
+	return filepath.Join("testdata", strings.TrimPrefix(namespace, "urn:iso:std:iso:20022:tech:xsd:")+".xsd")
+}
+
+// uintPtr returns a pointer to v
+func uintPtr(v uint) *uint { return &v }
+
+// testEntries returns a credit, a debit and a reversal on wallet 7
+func testEntries() []Entry {
+	at := time.Date(2026, 9, 14, 10, 30, 0, 0, time.UTC)
+	txs := []struct {
+		tx           domain.Transaction
+		counterparty string
+	}{
+		{domain.Transaction{ID: 1, ToWalletID: uintPtr(7), Amount: 100, Type: domain.TxTypeDeposit, CreatedAt: at.UnixMilli()}, ""},
+		{domain.Transaction{ID: 2, FromWalletID: uintPtr(7), ToWalletID: uintPtr(9), Amount: 30.5, Type: domain.TxTypeTransfer, CreatedAt: at.Add(time.Hour).UnixMilli()}, "bob"},
+		{domain.Transaction{ID: 3, FromWalletID: uintPtr(9), ToWalletID: uintPtr(7), Amount: 30.5, Type: domain.TxTypeReversal, ReferenceID: uintPtr(2), Note: "sent in error <&>", CreatedAt: at.Add(2 * time.Hour).UnixMilli()}, "bob"},
+	}
+	var entries []Entry
+	for _, x := range txs {
+		entries = append(entries, EntryFromTransaction(x.tx, 7, x.counterparty))
+	}
+	return entries
+}
+
+// testPeriod is the day the test entries were booked
+var testPeriod = Period{From: time.Date(2026, 9, 14, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)}
+
+func TestStatementMatchesSchema(t *testing.T) {
+	acct := Account{WalletID: 7, Currency: "USD", Owner: "alice"}
+	b, err := Marshal(Statement(acct, testPeriod, 20, testEntries()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := parse(t, b)
+	validate(t, root, Namespace053, "BkToCstmrStmt")
+
+	stmt := root.path("BkToCstmrStmt", "Stmt")
+	bals := stmt.all("Bal")
+	if len(bals) != 2 {
+		t.Fatalf("got %d balances, want opening and closing", len(bals))
+	}
+	want := map[string]string{"OPBD": "20.00", "CLBD": "120.00"} // 20 + 100 - 30.5 + 30.5
+	for _, bal := range bals {
+		code := bal.path("Tp", "CdOrPrtry", "Cd").Text
+		if got := bal.child("Amt").Text; got != want[code] {
+			t.Errorf("%s balance is %s, want %s", code, got, want[code])
+		}
+	}
+	if n := len(stmt.all("Ntry")); n != 3 {
+		t.Errorf("got %d entries, want 3", n)
+	}
+	if got := stmt.path("TxsSummry", "TtlNtries", "Sum").Text; got != "161.00" {
+		t.Errorf("total sum is %s, want 161.00", got)
+	}
+	if got := stmt.path("Acct", "Id", "Othr", "Id").Text; got != "7" {
+		t.Errorf("account id is %s, want 7", got)
+	}
+}
+
+func TestStatementEntryDirections(t *testing.T) {
+	b, err := Marshal(Statement(Account{WalletID: 7, Currency: "USD"}, testPeriod, 0, testEntries()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := parse(t, b).all("Ntry")
+	cases := []struct {
+		ind, party, partyRole string
+		reversal              bool
+	}{
+		{"CRDT", "", "", false},
+		{"DBIT", "bob", "Cdtr", false},
+		{"CRDT", "bob", "Dbtr", true},
+	}
+	for i, tc := range cases {
+		e := entries[i]
+		if got := e.child("CdtDbtInd").Text; got != tc.ind {
+			t.Errorf("entry %d: CdtDbtInd %s, want %s", i, got, tc.ind)
+		}
+		if got := e.child("RvslInd") != nil; got != tc.reversal {
+			t.Errorf("entry %d: reversal %v, want %v", i, got, tc.reversal)
+		}
+		if tc.party != "" {
+			p := e.path("NtryDtls", "TxDtls", "RltdPties", tc.partyRole, "Pty", "Nm")
+			if p == nil || p.Text != tc.party {
+				t.Errorf("entry %d: missing %s %s", i, tc.partyRole, tc.party)
+			}
+		}
+	}
+	if got := entries[2].path("NtryDtls", "TxDtls", "RmtInf", "Ustrd").Text; got != "sent in error <&>" {
+		t.Errorf("remittance info is %q", got)
+	}
+}
+
+func TestNotificationMatchesSchema(t *testing.T) {
+	b, err := Marshal(Notification(Account{WalletID: 7, Currency: "EUR"}, testPeriod, testEntries()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := parse(t, b)
+	validate(t, root, Namespace054, "BkToCstmrDbtCdtNtfctn")
+	n := root.path("BkToCstmrDbtCdtNtfctn", "Ntfctn")
+	if len(n.all("Bal")) != 0 {
+		t.Error("notifications carry no balances")
+	}
+	if got := n.path("TxsSummry", "TtlDbtNtries", "NbOfNtries").Text; got != "1" {
+		t.Errorf("debit count is %s, want 1", got)
+	}
+}
+
+func TestFromStatement(t *testing.T) {
+	st := &domain.Statement{
+		WalletID: 7, Currency: "USD", OpeningBalance: -5,
+		PeriodStart: testPeriod.From.UnixMilli(), PeriodEnd: testPeriod.To.UnixMilli(),
+		Lines: []domain.StatementLine{
+			{TransactionID: 4, PostedAt: testPeriod.From.Add(time.Hour).UnixMilli(), Type: domain.TxTypeDeposit, Description: "Deposit", Amount: 12.25, Balance: 7.25},
+		},
+	}
+	b, err := Marshal(FromStatement(st, "alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := parse(t, b)
+	validate(t, root, Namespace053, "BkToCstmrStmt")
+	for _, bal := range root.all("Bal") {
+		code := bal.path("Tp", "CdOrPrtry", "Cd").Text
+		amt, ind := bal.child("Amt").Text, bal.child("CdtDbtInd").Text
+		switch code {
+		case "OPBD":
+			if amt != "5.00" || ind != "DBIT" {
+				t.Errorf("opening balance %s %s, want 5.00 DBIT", amt, ind)
+			}
+		case "CLBD":
+			if amt != "7.25" || ind != "CRDT" {
+				t.Errorf("closing balance %s %s, want 7.25 CRDT", amt, ind)
+			}
+		}
+	}
+	// The closing balance date is the last day of the period, not the exclusive end
+	if got := root.all("Bal")[1].path("Dt", "Dt").Text; got != "2026-09-14" {
+		t.Errorf("closing balance date %s, want 2026-09-14", got)
+	}
+}
+
+func TestMessagesValidateAgainstSchemas(t *testing.T) {
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		t.Skip("xmllint not installed")
+	}
+	st := &domain.Statement{
+		WalletID: 7, Currency: "USD", OpeningBalance: -5,
+		PeriodStart: testPeriod.From.UnixMilli(), PeriodEnd: testPeriod.To.UnixMilli(),
+		Lines: []domain.StatementLine{
+			{TransactionID: 4, PostedAt: testPeriod.From.Add(time.Hour).UnixMilli(), Type: domain.TxTypeDeposit, Description: "Deposit", Amount: 12.25, Balance: 7.25},
+		},
+	}
+	acct := Account{WalletID: 7, Currency: "USD", Owner: "alice"}
+	cases := []struct {
+		name      string
+		namespace string
+		doc       any
+	}{
+		{"statement", Namespace053, Statement(acct, testPeriod, 20, testEntries())},
+		{"empty statement", Namespace053, Statement(acct, testPeriod, 0, nil)},
+		{"stored statement", Namespace053, FromStatement(st, "alice")},
+		{"notification", Namespace054, Notification(acct, testPeriod, testEntries())},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			schema := schemaFor(tc.namespace)
+			if _, err := os.Stat(schema); err != nil {
+				t.Fatalf("%s is not vendored, see testdata/README.md", schema)
+			}
+			b, err := Marshal(tc.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc := filepath.Join(t.TempDir(), "message.xml")
+			if err := os.WriteFile(doc, b, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if out, err := exec.Command(xmllint, "--noout", "--schema", schema, doc).CombinedOutput(); err != nil {
+				t.Errorf("does not validate against %s: %v\n%s", schema, err, out)
+			}
+		})
+	}
+}
+
+func TestMessageIDLength(t *testing.T) {
+	id := messageID("CAMT053", ^uint(0), time.Now())
+	if len(id) > 35 {
+		t.Errorf("message ID %q exceeds 35 characters", id)
+	}
+	if _, err := strconv.Atoi(id[len(id)-1:]); err != nil {
+		t.Errorf("message ID %q lost its timestamp", id)
+	}
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"fmt"     // For error messages
+	"io"      // For printing the configuration
 	"os"      // For environment variables
+	"regexp"  // For currency codes
 	"strconv" // For string to int conversion
+	"strings" // For key formatting
+	"time"    // For durations
 
 	"github.com/joho/godotenv" // For loading .env files
 )
@@ -10,33 +15,193 @@ import (
 // Config holds the application configuration
 type Config struct {
 	AppPort    string // Application port
+	DBDriver   string // Database driver: mysql, postgres or sqlite
 	DBUser     string // Database user
 	DBPassword string // Database password
 	DBHost     string // Database host
 	DBPort     string // Database port
-	DBName     string // Database name
+	DBName     string // Database name; the database file path for sqlite
+	DBSSLMode  string // PostgreSQL sslmode
 	JWTSecret  string // JWT secret key
 	RedisAddr  string // Redis server address
 	RedisPass  string // Redis password
 	RedisDB    int    // Redis database number
 	IsProd     bool   // Is production environment
+	LogLevel   string // Lowest level logged: debug, info, warn or error
+	LogFormat  string // Log line format: json or text
+
+	ApprovalTTL               time.Duration // How long a pending approval stays open
+	ReversalApprovalThreshold float64       // Reversals above this amount need a second admin
+	Currency                  string        // ISO 4217 code of the currency wallets hold, used in exports
+	ReconcileInterval         time.Duration // How often the server checks wallet balances against the ledger
+
+	HTTPReadTimeout   time.Duration // Limit on reading a request
+	HTTPWriteTimeout  time.Duration // Limit on writing a response
+	HTTPExportTimeout time.Duration // Limit on writing a streamed export
+	HTTPIdleTimeout   time.Duration // How long idle keep-alive connections stay open
+	ShutdownTimeout   time.Duration // How long in-flight requests get to finish on shutdown
+
+	ServiceName       string  // Service name reported in traces
+	TracesExporter    string  // Where spans go: none, stdout or otlp
+	TracesSampleRatio float64 // Share of new traces recorded, from 0 to 1
+
+	DBMaxOpenConns    int           // Most open database connections
+	DBMaxIdleConns    int           // Most idle database connections kept open
+	DBConnMaxLifetime time.Duration // How long a database connection is reused
+	RedisPoolSize     int           // Most Redis connections; zero uses the client default
+	AccessTokenTTL    time.Duration // Lifetime of access tokens
+	RefreshTokenTTL   time.Duration // Lifetime of refresh tokens
+	CacheTTL          time.Duration // How long cached reads are served
+
+	RateLimitAuth   RateLimit // Limit on the registration, login and password routes
+	RateLimitWallet RateLimit // Limit on the wallet routes
+	RateLimitAdmin  RateLimit // Limit on the admin routes
+
+	settings []setting // Effective values, for PrintTo
+}
+
+// RateLimit allows Requests requests per sliding Window to each identity. A zero
+// RateLimit disables limiting.
+type RateLimit struct {
+	Requests int           // Requests allowed per window
+	Window   time.Duration // Length of the sliding window
+	By       string        // Identity the limit applies to: ip or user
+}
+
+// Supported values of the enumerated settings
+var (
+	dbDrivers      = []string{"mysql", "postgres", "sqlite"}
+	sslModes       = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	logLevels      = []string{"debug", "info", "warn", "error"}
+	logFormats     = []string{"json", "text"}
+	traceExporters = []string{"none", "stdout", "otlp"}
+	rateLimitBy    = []string{"ip", "user", "api_key"}
+)
+
+// minSecretLength is the shortest JWT secret accepted; HS256 keys should be at least 256 bits
+const minSecretLength = 32
+
+// LoadConfig loads the configuration, reading the config file named by CONFIG_FILE if set
+func LoadConfig() (*Config, error) {
+	return Load("")
 }
 
-// LoadConfig loads configuration from environment variables
-func LoadConfig() *Config {
-	_ = godotenv.Load() // Load .env file if present
-	redisDB, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
-	return &Config{
-		AppPort:    os.Getenv("APP_PORT"),          // Application port
-		DBUser:     os.Getenv("DB_USER"),           // Database user
-		DBPassword: os.Getenv("DB_PASSWORD"),       // Database password
-		DBHost:     os.Getenv("DB_HOST"),           // Database host
-		DBPort:     os.Getenv("DB_PORT"),           // Database port
-		DBName:     os.Getenv("DB_NAME"),           // Database name
-		JWTSecret:  os.Getenv("JWT_SECRET"),        // JWT secret key
-		RedisAddr:  os.Getenv("REDIS_ADDR"),        // Redis server address
-		RedisPass:  os.Getenv("REDIS_PASS"),        // Redis password
-		RedisDB:    redisDB,                        // Redis database number
-		IsProd:     os.Getenv("IS_PROD") == "true", // Is production environment
+// Load loads the configuration from, in order of precedence, environment variables, the .env
+// file and the YAML or TOML config file at path, or at CONFIG_FILE if path is empty. Every
+// setting is validated; a *ValidationError lists all problems at once.
+func Load(path string) (*Config, error) {
+	if err := godotenv.Load(); err != nil && !isNotExist(err) {
+		return nil, fmt.Errorf("load .env: %w", err) // Present but unreadable
+	}
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE") // May be set in .env
+	}
+	l, err := newLoader(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{
+		AppPort:           strconv.Itoa(l.integer("APP_PORT", 8080, 1, 65535)), // Application port
+		DBDriver:          l.oneOf("DB_DRIVER", "mysql", dbDrivers...),         // Database driver
+		DBUser:            l.str("DB_USER", ""),                                // Database user
+		DBPassword:        l.secret("DB_PASSWORD"),                             // Database password
+		DBHost:            l.str("DB_HOST", "localhost"),                       // Database host
+		DBName:            l.str("DB_NAME", ""),                                // Database name
+		DBSSLMode:         l.oneOf("DB_SSLMODE", "disable", sslModes...),       // PostgreSQL sslmode
+		DBMaxOpenConns:    l.integer("DB_MAX_OPEN_CONNS", 25, 1, 10000),        // Connection pool size
+		DBMaxIdleConns:    l.integer("DB_MAX_IDLE_CONNS", 10, 0, 10000),        // Connections kept open when idle
+		DBConnMaxLifetime: l.duration("DB_CONN_MAX_LIFETIME", 30*time.Minute),  // Recycle connections behind load balancers
+		JWTSecret:         l.secret("JWT_SECRET"),                              // JWT secret key
+		AccessTokenTTL:    l.duration("ACCESS_TOKEN_TTL", time.Hour),           // Access tokens expire after an hour
+		RefreshTokenTTL:   l.duration("REFRESH_TOKEN_TTL", 7*24*time.Hour),     // Refresh tokens expire after a week
+		RedisAddr:         l.str("REDIS_ADDR", "localhost:6379"),               // Redis server address
+		RedisPass:         l.secret("REDIS_PASS"),                              // Redis password
+		RedisDB:           l.integer("REDIS_DB", 0, 0, 1<<16),                  // Redis database number
+		RedisPoolSize:     l.integer("REDIS_POOL_SIZE", 0, 0, 10000),           // Zero uses the client default
+		CacheTTL:          l.duration("CACHE_TTL", 60*time.Second),             // Cached reads are served for a minute
+		IsProd:            l.boolean("IS_PROD", false),                         // Is production environment
+		LogLevel:          l.oneOf("LOG_LEVEL", "info", logLevels...),          // Log level
+	}
+	// The database port and the log format default differently per driver and environment
+	dbPort := 3306
+	if cfg.DBDriver == "postgres" {
+		dbPort = 5432
+	}
+	cfg.DBPort = strconv.Itoa(l.integer("DB_PORT", dbPort, 1, 65535)) // Database port
+	logFormat := "text"                                               // Readable in a terminal during development
+	if cfg.IsProd {
+		logFormat = "json" // Parsed by log collectors in production
+	}
+	cfg.LogFormat = l.oneOf("LOG_FORMAT", logFormat, logFormats...) // Log format
+
+	cfg.ApprovalTTL = l.duration("APPROVAL_TTL", 72*time.Hour)                             // Pending approvals expire after 3 days
+	cfg.ReversalApprovalThreshold = l.number("REVERSAL_APPROVAL_THRESHOLD", 1000, 0, 1e15) // Reversals above 1000 need approval
+	cfg.Currency = l.str("CURRENCY", "USD")                                                // Wallets hold US dollars unless configured
+	cfg.ReconcileInterval = l.duration("RECONCILE_INTERVAL", 24*time.Hour)                 // Check the ledger daily
+
+	cfg.HTTPReadTimeout = l.duration("HTTP_READ_TIMEOUT", 15*time.Second)     // Requests are small JSON bodies
+	cfg.HTTPWriteTimeout = l.duration("HTTP_WRITE_TIMEOUT", 60*time.Second)   // Responses are small JSON bodies
+	cfg.HTTPExportTimeout = l.duration("HTTP_EXPORT_TIMEOUT", 30*time.Minute) // Exports stream the whole ledger
+	cfg.HTTPIdleTimeout = l.duration("HTTP_IDLE_TIMEOUT", 120*time.Second)    // Keep-alive connections
+	cfg.ShutdownTimeout = l.duration("SHUTDOWN_TIMEOUT", 30*time.Second)      // Drain in-flight requests
+
+	cfg.ServiceName = l.str("OTEL_SERVICE_NAME", "wallet_system")                   // Service name in traces
+	cfg.TracesExporter = l.oneOf("OTEL_TRACES_EXPORTER", "none", traceExporters...) // Tracing off unless configured
+	cfg.TracesSampleRatio = l.number("OTEL_TRACES_SAMPLER_ARG", 1, 0, 1)            // Record every trace by default
+
+	cfg.RateLimitAuth = l.rate("RATE_LIMIT_AUTH", "10/1m", "ip")        // Slow down credential guessing per client
+	cfg.RateLimitWallet = l.rate("RATE_LIMIT_WALLET", "120/1m", "user") // Per customer
+	cfg.RateLimitAdmin = l.rate("RATE_LIMIT_ADMIN", "300/1m", "user")   // Per administrator
+
+	validate(cfg, l)
+	l.unknownKeys()
+	cfg.settings = l.settings
+	return cfg, l.err()
+}
+
+// validate checks rules that span settings or go beyond a type and range
+func validate(cfg *Config, l *loader) {
+	switch {
+	case cfg.JWTSecret == "":
+		l.problem("JWT_SECRET: required")
+	case len(cfg.JWTSecret) < minSecretLength:
+		l.problem("JWT_SECRET: must be at least %d characters", minSecretLength)
+	}
+	if cfg.DBDriver != "sqlite" {
+		if cfg.DBUser == "" {
+			l.problem("DB_USER: required for %s", cfg.DBDriver)
+		}
+		if cfg.DBName == "" {
+			l.problem("DB_NAME: required for %s", cfg.DBDriver)
+		}
+	}
+	if cfg.DBMaxIdleConns > cfg.DBMaxOpenConns {
+		l.problem("DB_MAX_IDLE_CONNS: %d exceeds DB_MAX_OPEN_CONNS (%d)", cfg.DBMaxIdleConns, cfg.DBMaxOpenConns)
+	}
+	if cfg.RefreshTokenTTL <= cfg.AccessTokenTTL {
+		l.problem("REFRESH_TOKEN_TTL: must be longer than ACCESS_TOKEN_TTL")
+	}
+	if cfg.HTTPExportTimeout < cfg.HTTPWriteTimeout {
+		l.problem("HTTP_EXPORT_TIMEOUT: must not be shorter than HTTP_WRITE_TIMEOUT")
+	}
+	if !currencyCode.MatchString(cfg.Currency) {
+		l.problem("CURRENCY: %q is not an ISO 4217 code such as USD", cfg.Currency)
+	}
+}
+
+// currencyCode matches ISO 4217 alphabetic codes
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// PrintTo writes the effective configuration as YAML that Load accepts, with secrets redacted
+func (c *Config) PrintTo(w io.Writer) error {
+	for _, s := range c.settings {
+		value := s.value
+		if s.secret && value != "" {
+			value = redacted
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", strings.ToLower(s.key), strconv.Quote(value)); err != nil {
+			return err
+		}
 	}
+	return nil
 }
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSecret is long enough to pass validation
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// isolate runs the test in an empty directory, so no .env is read, with every setting unset
+func isolate(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	cfg, _ := Load("")
+	for _, s := range cfg.settings {
+		t.Setenv(s.key, "")
+		t.Setenv(s.key+"_FILE", "")
+	}
+	return dir
+}
+
+// writeFile writes content to name in dir and returns the path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+// problems returns the validation problems of err, failing unless it is a *ValidationError
+func problems(t *testing.T, err error) []string {
+	t.Helper()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error = %v, want a *ValidationError", err)
+	}
+	return verr.Problems
+}
+
+func TestLoadDefaults(t *testing.T) {
+	isolate(t)
+	t.Setenv("JWT_SECRET", testSecret)
+	t.Setenv("DB_USER", "wallet")
+	t.Setenv("DB_NAME", "wallet_db")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.AppPort != "8080" || cfg.DBDriver != "mysql" || cfg.DBPort != "3306" || cfg.CacheTTL != time.Minute || cfg.AccessTokenTTL != time.Hour || cfg.LogFormat != "text" {
+		t.Fatalf("defaults = %+v", cfg)
+	}
+	if cfg.RateLimitAuth != (RateLimit{Requests: 10, Window: time.Minute, By: "ip"}) {
+		t.Fatalf("RateLimitAuth = %+v", cfg.RateLimitAuth)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := isolate(t)
+	secretPath := writeFile(t, dir, "jwt_secret", testSecret+"\n")
+	path := writeFile(t, dir, "config.yaml", `
+app_port: 9000
+cache_ttl: 5m
+is_prod: true
+jwt_secret_file: `+secretPath+`
+db:
+  driver: postgres
+  user: wallet
+  name: from_file
+`)
+	t.Setenv("APP_PORT", "9100") // The environment wins over the file
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.AppPort != "9100" {
+		t.Fatalf("AppPort = %s, want the environment value", cfg.AppPort)
+	}
+	if cfg.DBDriver != "postgres" || cfg.DBName != "from_file" || cfg.DBPort != "5432" || cfg.CacheTTL != 5*time.Minute {
+		t.Fatalf("file values = %+v", cfg)
+	}
+	if cfg.JWTSecret != testSecret {
+		t.Fatalf("JWTSecret = %q, want the trimmed file content", cfg.JWTSecret)
+	}
+	if cfg.LogFormat != "json" {
+		t.Fatalf("LogFormat = %s, want json in production", cfg.LogFormat)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	dir := isolate(t)
+	path := writeFile(t, dir, "config.toml", `
+jwt_secret = "`+testSecret+`"
+redis_db = 3
+
+[rate_limit]
+wallet = "off"
+admin = "50/10s"
+admin_by = "api_key"
+
+[db]
+driver = "sqlite"
+name = "wallet.db"
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.DBDriver != "sqlite" || cfg.DBName != "wallet.db" || cfg.RedisDB != 3 {
+		t.Fatalf("config = %+v", cfg)
+	}
+	if cfg.RateLimitWallet.Requests != 0 || cfg.RateLimitAdmin != (RateLimit{Requests: 50, Window: 10 * time.Second, By: "api_key"}) {
+		t.Fatalf("rate limits = %+v, %+v", cfg.RateLimitWallet, cfg.RateLimitAdmin)
+	}
+}
+
+func TestLoadListsEveryProblem(t *testing.T) {
+	dir := isolate(t)
+	path := writeFile(t, dir, "config.yaml", "db_drvier: postgres\n")
+	t.Setenv("APP_PORT", "0")
+	t.Setenv("REDIS_DB", "two")
+	t.Setenv("CACHE_TTL", "soon")
+	t.Setenv("CURRENCY", "usd")
+	t.Setenv("LOG_LEVEL", "loud")
+	t.Setenv("RATE_LIMIT_AUTH", "lots")
+	t.Setenv("RATE_LIMIT_ADMIN_BY", "token")
+	_, err := Load(path)
+	got := strings.Join(problems(t, err), "\n")
+	for _, want := range []string{"APP_PORT", "REDIS_DB", "CACHE_TTL", "CURRENCY", "LOG_LEVEL", "RATE_LIMIT_AUTH", "RATE_LIMIT_ADMIN_BY", "JWT_SECRET: required", "DB_USER: required", "DB_NAME: required", "unknown setting db_drvier"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("problems lack %s:\n%s", want, got)
+		}
+	}
+}
+
+func TestLoadSecretFileConflict(t *testing.T) {
+	dir := isolate(t)
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("JWT_SECRET", testSecret)
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, dir, "jwt_secret", testSecret))
+	_, err := Load("")
+	if got := strings.Join(problems(t, err), "\n"); !strings.Contains(got, "JWT_SECRET_FILE") {
+		t.Fatalf("problems = %s", got)
+	}
+}
+
+func TestPrintToRedactsSecrets(t *testing.T) {
+	isolate(t)
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("JWT_SECRET", testSecret)
+	t.Setenv("DB_PASSWORD", "hunter2hunter2")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	var out bytes.Buffer
+	if err := cfg.PrintTo(&out); err != nil {
+		t.Fatalf("print: %v", err)
+	}
+	printed := out.String()
+	if strings.Contains(printed, testSecret) || strings.Contains(printed, "hunter2") {
+		t.Fatalf("secrets printed:\n%s", printed)
+	}
+	for _, want := range []string{`jwt_secret: "[REDACTED]"`, `db_driver: "sqlite"`, `cache_ttl: "1m0s"`, `redis_pass: ""`} {
+		if !strings.Contains(printed, want) {
+			t.Errorf("output lacks %s:\n%s", want, printed)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"errors"        // Missing files
+	"fmt"           // Error messages
+	"io/fs"         // File errors
+	"os"            // Environment and files
+	"path/filepath" // Config file extension
+	"sort"          // Stable error and key order
+	"strconv"       // Number parsing
+	"strings"       // Key normalization
+	"time"          // Durations
+
+	"github.com/pelletier/go-toml/v2" // TOML config files
+	"gopkg.in/yaml.v3"                // YAML config files
+)
+
+// redacted replaces secrets in PrintTo output
+const redacted = "[REDACTED]"
+
+// ValidationError lists every problem found while loading the configuration
+type ValidationError struct {
+	Problems []string // One entry per invalid or missing setting
+}
+
+// Error lists the problems, one per line
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// setting is one loaded value, kept for PrintTo
+type setting struct {
+	key    string // Environment variable name
+	value  string // Effective value, formatted
+	secret bool   // Redacted when printed
+}
+
+// loader reads settings from the layers in order of precedence: the environment (including
+// .env), then the config file, then the defaults. In each layer KEY_FILE may name a file
+// holding the value instead, for secrets mounted by an orchestrator.
+type loader struct {
+	file     map[string]string // Values from the config file, by upper-case key
+	used     map[string]bool   // Keys read, to reject unknown keys in the file
+	settings []setting         // Effective values in read order
+	problems []string          // Validation problems
+}
+
+// newLoader reads the optional config file at path
+func newLoader(path string) (*loader, error) {
+	l := &loader{file: map[string]string{}, used: map[string]bool{}}
+	if path == "" {
+		return l, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	var tree map[string]any // Parsed document
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &tree)
+	case ".toml":
+		err = toml.Unmarshal(raw, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s: use a .yaml, .yml or .toml file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	flatten("", tree, l.file)
+	return l, nil
+}
+
+// flatten turns nested sections into KEY_SUBKEY entries, so `db: {driver: mysql}` and
+// `db_driver: mysql` both set DB_DRIVER
+func flatten(prefix string, tree map[string]any, out map[string]string) {
+	for k, v := range tree {
+		key := strings.ToUpper(k)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		if nested, ok := v.(map[string]any); ok {
+			flatten(key, nested, out)
+			continue
+		}
+		out[key] = fmt.Sprint(v)
+	}
+}
+
+// problem records a validation problem
+func (l *loader) problem(format string, args ...any) {
+	l.problems = append(l.problems, fmt.Sprintf(format, args...))
+}
+
+// lookup returns the raw value of key from the highest layer that sets it, directly or via KEY_FILE
+func (l *loader) lookup(key string) (string, bool) {
+	l.used[key], l.used[key+"_FILE"] = true, true
+	layers := []struct {
+		name string                      // Layer name for errors
+		get  func(string) (string, bool) // Reads a key
+	}{
+		{"environment", os.LookupEnv},
+		{"config file", func(k string) (string, bool) { v, ok := l.file[k]; return v, ok }},
+	}
+	for _, layer := range layers {
+		v, hasValue := layer.get(key)
+		hasValue = hasValue && v != ""
+		path, hasFile := layer.get(key + "_FILE")
+		hasFile = hasFile && path != ""
+		switch {
+		case hasValue && hasFile:
+			l.problem("%s: set either %s or %s_FILE in the %s, not both", key, key, key, layer.name)
+			return "", false
+		case hasValue:
+			return v, true
+		case hasFile:
+			b, err := os.ReadFile(path)
+			if err != nil {
+				l.problem("%s_FILE: %v", key, err)
+				return "", false
+			}
+			return strings.TrimRight(string(b), "\r\n"), true // Files usually end with a newline
+		}
+	}
+	return "", false
+}
+
+// record keeps the effective value of key for PrintTo
+func (l *loader) record(key, value string, secret bool) {
+	l.settings = append(l.settings, setting{key: key, value: value, secret: secret})
+}
+
+// str reads a string, falling back to def
+func (l *loader) str(key, def string) string {
+	v, ok := l.lookup(key)
+	if !ok {
+		v = def
+	}
+	l.record(key, v, false)
+	return v
+}
+
+// secret reads a string that must never be printed
+func (l *loader) secret(key string) string {
+	v, _ := l.lookup(key)
+	l.record(key, v, true)
+	return v
+}
+
+// oneOf reads a string that must be one of allowed, falling back to def
+func (l *loader) oneOf(key, def string, allowed ...string) string {
+	v := l.str(key, def)
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	l.problem("%s: %q is not one of %s", key, v, strings.Join(allowed, ", "))
+	return v
+}
+
+// integer reads a whole number in [min, max], falling back to def
+func (l *loader) integer(key string, def, min, max int) int {
+	raw, ok := l.lookup(key)
+	n := def
+	if ok {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			l.problem("%s: %q is not a whole number", key, raw)
+		} else {
+			n = parsed
+		}
+	}
+	if n < min || n > max {
+		l.problem("%s: %d is outside %d-%d", key, n, min, max)
+	}
+	l.record(key, strconv.Itoa(n), false)
+	return n
+}
+
+// number reads a number in [min, max], falling back to def
+func (l *loader) number(key string, def, min, max float64) float64 {
+	raw, ok := l.lookup(key)
+	f := def
+	if ok {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			l.problem("%s: %q is not a number", key, raw)
+		} else {
+			f = parsed
+		}
+	}
+	if f < min || f > max {
+		l.problem("%s: %v is outside %v-%v", key, f, min, max)
+	}
+	l.record(key, strconv.FormatFloat(f, 'f', -1, 64), false)
+	return f
+}
+
+// duration reads a positive duration such as "72h", falling back to def
+func (l *loader) duration(key string, def time.Duration) time.Duration {
+	raw, ok := l.lookup(key)
+	d := def
+	if ok {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			l.problem("%s: %q is not a duration such as 30s or 72h", key, raw)
+		} else if parsed <= 0 {
+			l.problem("%s: must be positive", key)
+		} else {
+			d = parsed
+		}
+	}
+	l.record(key, d.String(), false)
+	return d
+}
+
+// rate reads a rate limit such as "10/1m" from key, or "off" to disable it, and the identity
+// it applies to from key_BY
+func (l *loader) rate(key, def, by string) RateLimit {
+	raw := l.str(key, def)
+	limit := RateLimit{By: l.oneOf(key+"_BY", by, rateLimitBy...)}
+	if raw == "off" {
+		return RateLimit{}
+	}
+	count, window, ok := strings.Cut(raw, "/")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n < 1 {
+		l.problem("%s: %q is not a rate such as 10/1m or off", key, raw)
+		return RateLimit{}
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d < time.Second {
+		l.problem("%s: window %q is not a duration of at least 1s", key, window)
+		return RateLimit{}
+	}
+	limit.Requests, limit.Window = n, d
+	return limit
+}
+
+// boolean reads true or false, falling back to def
+func (l *loader) boolean(key string, def bool) bool {
+	raw, ok := l.lookup(key)
+	b := def
+	if ok {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			l.problem("%s: %q is not true or false", key, raw)
+		} else {
+			b = parsed
+		}
+	}
+	l.record(key, strconv.FormatBool(b), false)
+	return b
+}
+
+// unknownKeys reports config file keys that no setting reads, which are usually typos
+func (l *loader) unknownKeys() {
+	var unknown []string // Unread keys
+	for k := range l.file {
+		if !l.used[k] {
+			unknown = append(unknown, strings.ToLower(k))
+		}
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		l.problem("config file: unknown setting %s", k)
+	}
+}
+
+// err returns the collected problems, or nil
+func (l *loader) err() error {
+	if len(l.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: l.problems}
+}
+
+// isNotExist reports whether err means a file is missing
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
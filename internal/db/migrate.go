@@ -1,24 +1,17 @@
 package db
 
 import (
-	"wallet_system/internal/domain" // Importing domain models
+	"wallet_system/internal/db/migrations" // Versioned migrations
+	"wallet_system/internal/migrate"       // Migration framework
 
-	"github.com/sirupsen/logrus"
-
-	"gorm.io/driver/mysql" // MySQL driver for GORM
-	"gorm.io/gorm"         // GORM ORM library
+	"gorm.io/gorm" // GORM ORM library
 )
 
-// Migrate performs automatic migration for the database schema
-func Migrate(dsn string) {
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{}) // Open a connection to the database
-	if err != nil {
-		logrus.Fatalf("failed to connect database: %v", err) // Log fatal error if connection fails
-	}
-	// AutoMigrate will create tables, missing foreign keys, constraints, columns and indexes
-	err = db.AutoMigrate(&domain.User{}, &domain.Wallet{}, &domain.Transaction{})
+// Migrator returns a migrator holding every migration for the database's dialect
+func Migrator(db *gorm.DB) (*migrate.Migrator, error) {
+	all, err := migrations.All(db.Dialector.Name()) // SQL and Go migrations, ordered
 	if err != nil {
-		logrus.Fatalf("migration failed: %v", err) // Log fatal error if migration fails
+		return nil, err
 	}
-	logrus.Info("Migration completed.") // Log successful migration
+	return migrate.New(db, all), nil
 }
@@ -0,0 +1,88 @@
+package migrations
+
+import (
+	"wallet_system/internal/migrate" // Migration framework
+
+	"gorm.io/gorm" // GORM ORM library
+)
+
+// Columns added to the tables of the first release after it shipped, as the initial schema
+// defines them. Databases created by that release's AutoMigrate lack them, and the initial
+// schema's CREATE TABLE IF NOT EXISTS leaves existing tables alone.
+type (
+	adoptedUser struct {
+		Status            string  `gorm:"size:16;not null;default:active"`
+		StatusReason      *string `gorm:"size:255"`
+		MustResetPassword bool    `gorm:"not null;default:false"`
+	}
+	adoptedWallet struct {
+		IsSystem     bool    `gorm:"not null;default:false"`
+		Status       string  `gorm:"size:16;not null;default:active"`
+		StatusReason *string `gorm:"size:255"`
+	}
+	adoptedTransaction struct {
+		Status           string `gorm:"size:16;not null;default:completed"`
+		ReferenceID      *uint
+		ReasonCode       *string `gorm:"size:32"`
+		Note             *string `gorm:"size:255"`
+		FromBalanceAfter *float64
+		ToBalanceAfter   *float64
+	}
+)
+
+func (adoptedUser) TableName() string        { return "users" }
+func (adoptedWallet) TableName() string      { return "wallets" }
+func (adoptedTransaction) TableName() string { return "transactions" }
+
+// adoptedIndexes are the transaction indexes of the initial schema. MySQL declares them inside
+// CREATE TABLE, so they are skipped along with it.
+var adoptedIndexes = []struct{ name, columns string }{
+	{"idx_tx_created_id", "created_at, id"},
+	{"idx_tx_from_created", "from_wallet_id, created_at, id"},
+	{"idx_tx_to_created", "to_wallet_id, created_at, id"},
+	{"idx_transactions_reference_id", "reference_id"},
+}
+
+// Bring databases created by AutoMigrate up to the initial schema before it is applied
+func init() {
+	register(migrate.Migration{
+		Version: 20261018000000,
+		Name:    "adopt_automigrate_schema",
+		Up: func(tx *gorm.DB) error {
+			m := tx.Migrator()
+			if !m.HasTable("transactions") {
+				return nil // New database; the initial schema creates everything
+			}
+			for _, t := range []struct {
+				model  any
+				fields []string
+			}{
+				{&adoptedUser{}, []string{"Status", "StatusReason", "MustResetPassword"}},
+				{&adoptedWallet{}, []string{"IsSystem", "Status", "StatusReason"}},
+				{&adoptedTransaction{}, []string{"Status", "ReferenceID", "ReasonCode", "Note", "FromBalanceAfter", "ToBalanceAfter"}},
+			} {
+				for _, field := range t.fields {
+					if m.HasColumn(t.model, field) {
+						continue
+					}
+					if err := m.AddColumn(t.model, field); err != nil {
+						return err
+					}
+				}
+			}
+			for _, idx := range adoptedIndexes {
+				if m.HasIndex("transactions", idx.name) {
+					continue
+				}
+				if err := tx.Exec("CREATE INDEX " + idx.name + " ON transactions (" + idx.columns + ")").Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		// The columns belong to the initial schema, whose down migration drops them
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
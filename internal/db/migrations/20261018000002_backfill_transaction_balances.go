@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"wallet_system/internal/ledger"  // Balance replay
+	"wallet_system/internal/migrate" // Migration framework
+
+	"github.com/sirupsen/logrus" // Logging library
+	"gorm.io/gorm"               // GORM ORM library
+)
+
+// Record balances on transactions posted before they were tracked
+func init() {
+	register(migrate.Migration{
+		Version: 20261018000002,
+		Name:    "backfill_transaction_balances",
+		Up: func(tx *gorm.DB) error {
+			n, err := ledger.BackfillBalances(tx)
+			if n > 0 {
+				logrus.WithField("count", n).Info("Backfilled transaction balances")
+			}
+			return err
+		},
+		// Recorded balances are correct either way, so there is nothing to undo
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
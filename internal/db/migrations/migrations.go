@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"embed"                          // Migration files compiled into the binary
+	"wallet_system/internal/migrate" // Migration framework
+)
+
+// sqlFiles holds the SQL migrations, one directory per database dialect
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var sqlFiles embed.FS
+
+// Dialects lists the dialects with SQL migrations. Every SQL migration exists once per dialect,
+// under the same version and name.
+var Dialects = []string{"mysql", "postgres", "sqlite"}
+
+// goMigrations holds the migrations written in Go, registered by the files of this package
+var goMigrations []migrate.Migration
+
+// register adds a Go migration; call it from an init function
+func register(m migrate.Migration) {
+	goMigrations = append(goMigrations, m)
+}
+
+// All returns every migration for the given dialect, ordered by version
+func All(dialect string) ([]migrate.Migration, error) {
+	return migrate.Load(sqlFiles, dialect, goMigrations)
+}
@@ -0,0 +1,125 @@
+-- Schema as created by AutoMigrate before versioned migrations. IF NOT EXISTS lets
+-- databases created that way adopt this migration once adopt_automigrate_schema has
+-- added the columns and indexes they lack.
+
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `username` varchar(191) NOT NULL,
+  `password` longtext NOT NULL,
+  `role` varchar(191) DEFAULT 'user',
+  `status` varchar(16) NOT NULL DEFAULT 'active',
+  `status_reason` varchar(255),
+  `must_reset_password` boolean NOT NULL DEFAULT false,
+  PRIMARY KEY (`id`),
+  CONSTRAINT `uni_users_username` UNIQUE (`username`)
+);
+
+CREATE TABLE IF NOT EXISTS `wallets` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `user_id` bigint unsigned,
+  `balance` double NOT NULL DEFAULT 0,
+  `is_system` boolean NOT NULL DEFAULT false,
+  `status` varchar(16) NOT NULL DEFAULT 'active',
+  `status_reason` varchar(255),
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_wallets_user_id` (`user_id`),
+  CONSTRAINT `fk_users_wallet` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `transactions` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `from_wallet_id` bigint unsigned,
+  `to_wallet_id` bigint unsigned,
+  `amount` double,
+  `type` longtext,
+  `status` varchar(16) NOT NULL DEFAULT 'completed',
+  `reference_id` bigint unsigned,
+  `reason_code` varchar(32),
+  `note` varchar(255),
+  `from_balance_after` double,
+  `to_balance_after` double,
+  `created_at` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_tx_created_id` (`created_at`, `id`),
+  INDEX `idx_tx_from_created` (`from_wallet_id`, `created_at`, `id`),
+  INDEX `idx_tx_to_created` (`to_wallet_id`, `created_at`, `id`),
+  INDEX `idx_transactions_reference_id` (`reference_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `audit_logs` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `actor_id` bigint unsigned,
+  `action` varchar(64),
+  `target_type` varchar(32),
+  `target_id` varchar(64),
+  `before` text,
+  `after` text,
+  `ip` varchar(64),
+  `user_agent` varchar(255),
+  `request_id` varchar(64),
+  `created_at` bigint,
+  `prev_hash` varchar(64),
+  `hash` varchar(64),
+  PRIMARY KEY (`id`),
+  INDEX `idx_audit_logs_actor_id` (`actor_id`),
+  INDEX `idx_audit_logs_action` (`action`),
+  INDEX `idx_audit_logs_target_type` (`target_type`),
+  INDEX `idx_audit_logs_target_id` (`target_id`),
+  INDEX `idx_audit_logs_created_at` (`created_at`),
+  UNIQUE INDEX `idx_audit_logs_hash` (`hash`)
+);
+
+CREATE TABLE IF NOT EXISTS `pending_actions` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `type` varchar(64),
+  `payload` text,
+  `target_type` varchar(32),
+  `target_id` varchar(64),
+  `status` varchar(16) DEFAULT 'pending',
+  `proposed_by` bigint unsigned,
+  `proposal_note` varchar(255),
+  `decided_by` bigint unsigned,
+  `decision_note` varchar(255),
+  `result` text,
+  `created_at` bigint,
+  `expires_at` bigint,
+  `decided_at` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_pending_actions_type` (`type`),
+  INDEX `idx_pending_actions_status` (`status`),
+  INDEX `idx_pending_actions_proposed_by` (`proposed_by`),
+  INDEX `idx_pending_actions_expires_at` (`expires_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `statements` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `wallet_id` bigint unsigned NOT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `period_start` bigint NOT NULL,
+  `period_end` bigint NOT NULL,
+  `currency` varchar(3),
+  `opening_balance` double,
+  `total_credits` double,
+  `total_debits` double,
+  `closing_balance` double,
+  `transaction_count` bigint,
+  `created_at` bigint,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_statement_period` (`wallet_id`, `period_start`),
+  INDEX `idx_statements_user_id` (`user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `statement_lines` (
+  `id` bigint unsigned AUTO_INCREMENT,
+  `statement_id` bigint unsigned,
+  `transaction_id` bigint unsigned,
+  `posted_at` bigint,
+  `type` varchar(16),
+  `counterparty` varchar(255),
+  `description` varchar(255),
+  `amount` double,
+  `balance` double,
+  PRIMARY KEY (`id`),
+  INDEX `idx_statement_lines_statement_id` (`statement_id`),
+  CONSTRAINT `fk_statements_lines` FOREIGN KEY (`statement_id`) REFERENCES `statements`(`id`) ON DELETE CASCADE ON UPDATE CASCADE
+);
@@ -0,0 +1,3 @@
+-- Revert audit_chain_lock
+
+DROP TABLE IF EXISTS `audit_chain_lock`;
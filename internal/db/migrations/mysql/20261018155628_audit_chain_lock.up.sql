@@ -0,0 +1,10 @@
+-- A single row that audit appends lock before reading the head of the hash chain, so appends
+-- from every instance are serialized, even while the audit log is still empty.
+
+CREATE TABLE `audit_chain_lock` (
+  `id` int NOT NULL,
+  `version` bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`)
+);
+
+INSERT INTO `audit_chain_lock` (`id`) VALUES (1);
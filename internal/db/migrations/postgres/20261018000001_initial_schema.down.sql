@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS statement_lines;
+DROP TABLE IF EXISTS statements;
+DROP TABLE IF EXISTS pending_actions;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS wallets;
+DROP TABLE IF EXISTS users;
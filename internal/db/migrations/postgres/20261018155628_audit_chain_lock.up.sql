@@ -0,0 +1,8 @@
+-- Audit chain lock row, matching the MySQL migration of the same version.
+
+CREATE TABLE audit_chain_lock (
+  id integer PRIMARY KEY,
+  version bigint NOT NULL DEFAULT 0
+);
+
+INSERT INTO audit_chain_lock (id) VALUES (1);
@@ -0,0 +1,116 @@
+-- Initial schema, matching the MySQL migration of the same version.
+
+CREATE TABLE IF NOT EXISTS users (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  username varchar(191) NOT NULL,
+  password text NOT NULL,
+  role varchar(191) DEFAULT 'user',
+  status varchar(16) NOT NULL DEFAULT 'active',
+  status_reason varchar(255),
+  must_reset_password numeric NOT NULL DEFAULT false,
+  CONSTRAINT uni_users_username UNIQUE (username)
+);
+
+CREATE TABLE IF NOT EXISTS wallets (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  user_id integer,
+  balance real NOT NULL DEFAULT 0,
+  is_system numeric NOT NULL DEFAULT false,
+  status varchar(16) NOT NULL DEFAULT 'active',
+  status_reason varchar(255),
+  CONSTRAINT fk_users_wallet FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallets_user_id ON wallets (user_id);
+
+CREATE TABLE IF NOT EXISTS transactions (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  from_wallet_id integer,
+  to_wallet_id integer,
+  amount real,
+  type text,
+  status varchar(16) NOT NULL DEFAULT 'completed',
+  reference_id integer,
+  reason_code varchar(32),
+  note varchar(255),
+  from_balance_after real,
+  to_balance_after real,
+  created_at integer
+);
+CREATE INDEX IF NOT EXISTS idx_tx_created_id ON transactions (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_tx_from_created ON transactions (from_wallet_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_tx_to_created ON transactions (to_wallet_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_reference_id ON transactions (reference_id);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  actor_id integer,
+  action varchar(64),
+  target_type varchar(32),
+  target_id varchar(64),
+  before text,
+  after text,
+  ip varchar(64),
+  user_agent varchar(255),
+  request_id varchar(64),
+  created_at integer,
+  prev_hash varchar(64),
+  hash varchar(64)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target_type ON audit_logs (target_type);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target_id ON audit_logs (target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_hash ON audit_logs (hash);
+
+CREATE TABLE IF NOT EXISTS pending_actions (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  type varchar(64),
+  payload text,
+  target_type varchar(32),
+  target_id varchar(64),
+  status varchar(16) DEFAULT 'pending',
+  proposed_by integer,
+  proposal_note varchar(255),
+  decided_by integer,
+  decision_note varchar(255),
+  result text,
+  created_at integer,
+  expires_at integer,
+  decided_at integer
+);
+CREATE INDEX IF NOT EXISTS idx_pending_actions_type ON pending_actions (type);
+CREATE INDEX IF NOT EXISTS idx_pending_actions_status ON pending_actions (status);
+CREATE INDEX IF NOT EXISTS idx_pending_actions_proposed_by ON pending_actions (proposed_by);
+CREATE INDEX IF NOT EXISTS idx_pending_actions_expires_at ON pending_actions (expires_at);
+
+CREATE TABLE IF NOT EXISTS statements (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  wallet_id integer NOT NULL,
+  user_id integer NOT NULL,
+  period_start integer NOT NULL,
+  period_end integer NOT NULL,
+  currency varchar(3),
+  opening_balance real,
+  total_credits real,
+  total_debits real,
+  closing_balance real,
+  transaction_count integer,
+  created_at integer
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_statement_period ON statements (wallet_id, period_start);
+CREATE INDEX IF NOT EXISTS idx_statements_user_id ON statements (user_id);
+
+CREATE TABLE IF NOT EXISTS statement_lines (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  statement_id integer,
+  transaction_id integer,
+  posted_at integer,
+  type varchar(16),
+  counterparty varchar(255),
+  description varchar(255),
+  amount real,
+  balance real,
+  CONSTRAINT fk_statements_lines FOREIGN KEY (statement_id) REFERENCES statements(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_statement_lines_statement_id ON statement_lines (statement_id);
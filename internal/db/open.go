@@ -0,0 +1,92 @@
+package db
+
+import (
+	"fmt"                           // Error messages
+	"net"                           // Host and port joining
+	"net/url"                       // PostgreSQL connection URLs
+	"wallet_system/internal/config" // Database settings
+
+	"github.com/glebarez/sqlite"                 // SQLite driver for GORM, pure Go
+	mysqldriver "github.com/go-sql-driver/mysql" // MySQL DSN formatting
+	"gorm.io/driver/mysql"                       // MySQL driver for GORM
+	"gorm.io/driver/postgres"                    // PostgreSQL driver for GORM
+	"gorm.io/gorm"                               // GORM ORM library
+)
+
+// Supported values of DB_DRIVER. They match the GORM dialect names, which select the migrations.
+const (
+	DriverMySQL    = "mysql"    // MySQL 8 or MariaDB
+	DriverPostgres = "postgres" // PostgreSQL 13 or later
+	DriverSQLite   = "sqlite"   // A local database file, for development and tests
+)
+
+// defaultSQLitePath is the database file used when DB_NAME is empty
+const defaultSQLitePath = "wallet.db"
+
+// DSN builds the data source name for the configured driver. Credentials are escaped, so
+// passwords may contain any character.
+func DSN(cfg *config.Config) (string, error) {
+	switch cfg.DBDriver {
+	case DriverMySQL:
+		c := mysqldriver.NewConfig()
+		c.User = cfg.DBUser                               // Database user
+		c.Passwd = cfg.DBPassword                         // Database password
+		c.Net = "tcp"                                     // Connect over TCP
+		c.Addr = net.JoinHostPort(cfg.DBHost, cfg.DBPort) // Server address
+		c.DBName = cfg.DBName                             // Database name
+		c.ParseTime = true                                // Scan DATETIME into time.Time
+		return c.FormatDSN(), nil
+	case DriverPostgres:
+		u := url.URL{
+			Scheme:   "postgres",                                   // PostgreSQL URL
+			User:     url.UserPassword(cfg.DBUser, cfg.DBPassword), // Credentials
+			Host:     net.JoinHostPort(cfg.DBHost, cfg.DBPort),     // Server address
+			Path:     "/" + cfg.DBName,                             // Database name
+			RawQuery: url.Values{"sslmode": {cfg.DBSSLMode}}.Encode(),
+		}
+		return u.String(), nil
+	case DriverSQLite:
+		path := cfg.DBName // Database file
+		if path == "" {
+			path = defaultSQLitePath
+		}
+		// Enforce foreign keys, wait for locks instead of failing, and let other processes read while one writes
+		return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", nil
+	}
+	return "", fmt.Errorf("unsupported DB_DRIVER %q; use mysql, postgres or sqlite", cfg.DBDriver)
+}
+
+// Open connects to the configured database
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	dsn, err := DSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var dialector gorm.Dialector // Driver for the configured database
+	switch cfg.DBDriver {
+	case DriverMySQL:
+		dialector = mysql.Open(dsn)
+	case DriverPostgres:
+		dialector = postgres.Open(dsn)
+	case DriverSQLite:
+		dialector = sqlite.Open(dsn)
+	}
+	conn, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DBDriver == DriverSQLite {
+		// SQLite has one writer and no row locks; a single connection serializes transactions
+		// the way SELECT ... FOR UPDATE does on the server databases
+		sqlDB.SetMaxOpenConns(1)
+		return conn, nil
+	}
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)       // Bound the load on the server
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)       // Keep some connections warm
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime) // Recycle before proxies drop them
+	return conn, nil
+}
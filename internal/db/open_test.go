@@ -0,0 +1,203 @@
+package db
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"wallet_system/internal/config"
+	"wallet_system/internal/domain"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/gorm/logger"
+)
+
+// awkwardPassword contains every character that needs escaping in a DSN
+const awkwardPassword = `p@ss:w/rd?#&=% "'`
+
+func TestDSNMySQL(t *testing.T) {
+	cfg := &config.Config{DBDriver: DriverMySQL, DBUser: "wallet", DBPassword: awkwardPassword, DBHost: "db", DBPort: "3306", DBName: "wallet_db"}
+	dsn, err := DSN(cfg)
+	if err != nil {
+		t.Fatalf("dsn: %v", err)
+	}
+	parsed, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("parse %q: %v", dsn, err)
+	}
+	if parsed.User != "wallet" || parsed.Passwd != awkwardPassword || parsed.Addr != "db:3306" || parsed.DBName != "wallet_db" || !parsed.ParseTime {
+		t.Fatalf("parsed = %+v", parsed)
+	}
+}
+
+func TestDSNPostgres(t *testing.T) {
+	cfg := &config.Config{DBDriver: DriverPostgres, DBUser: "wallet", DBPassword: awkwardPassword, DBHost: "db", DBPort: "5432", DBName: "wallet_db", DBSSLMode: "require"}
+	dsn, err := DSN(cfg)
+	if err != nil {
+		t.Fatalf("dsn: %v", err)
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("parse %q: %v", dsn, err)
+	}
+	password, _ := u.User.Password()
+	if u.User.Username() != "wallet" || password != awkwardPassword || u.Host != "db:5432" || u.Path != "/wallet_db" {
+		t.Fatalf("parsed = %s", u.Redacted())
+	}
+	if got := u.Query().Get("sslmode"); got != "require" {
+		t.Fatalf("sslmode = %q, want require", got)
+	}
+}
+
+func TestDSNSQLite(t *testing.T) {
+	dsn, err := DSN(&config.Config{DBDriver: DriverSQLite})
+	if err != nil {
+		t.Fatalf("dsn: %v", err)
+	}
+	if !strings.HasPrefix(dsn, "file:"+defaultSQLitePath+"?") {
+		t.Fatalf("dsn = %q, want the default file", dsn)
+	}
+	if !strings.Contains(dsn, "foreign_keys(1)") {
+		t.Fatalf("dsn = %q, want foreign keys enforced", dsn)
+	}
+}
+
+func TestDSNUnknownDriver(t *testing.T) {
+	if _, err := DSN(&config.Config{DBDriver: "oracle"}); err == nil {
+		t.Fatal("expected an error for an unknown driver")
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	cfg := &config.Config{DBDriver: DriverSQLite, DBName: filepath.Join(t.TempDir(), "wallet.db")}
+	conn, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	conn.Logger = logger.Discard
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	defer sqlDB.Close()
+
+	m, err := Migrator(conn)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	// Every model field must have a column, or GORM queries fail at runtime
+	models := []any{&domain.User{}, &domain.Wallet{}, &domain.Transaction{}, &domain.AuditLog{}, &domain.PendingAction{}, &domain.Statement{}, &domain.StatementLine{}}
+	for _, model := range models {
+		stmt := conn.Model(model).Statement
+		if err := stmt.Parse(model); err != nil {
+			t.Fatalf("parse %T: %v", model, err)
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !conn.Migrator().HasColumn(model, field.DBName) {
+				t.Errorf("%s.%s is missing", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	for _, s := range status {
+		if !s.Applied || s.Dirty {
+			t.Fatalf("migration %d: applied=%v dirty=%v", s.Version, s.Applied, s.Dirty)
+		}
+	}
+	// The down migrations must undo everything so the schema can be rebuilt
+	if _, err := m.Down(len(status)); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if conn.Migrator().HasTable(&domain.User{}) {
+		t.Fatal("users table survived down")
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+}
+
+// Tables as the AutoMigrate command of the first release created them
+type (
+	legacyUser struct {
+		ID       uint   `gorm:"primaryKey"`
+		Username string `gorm:"unique;not null"`
+		Password string `gorm:"not null"`
+		Role     string `gorm:"default:user"`
+	}
+	legacyWallet struct {
+		ID      uint    `gorm:"primaryKey"`
+		UserID  uint    `gorm:"uniqueIndex"`
+		Balance float64 `gorm:"not null;default:0"`
+	}
+	legacyTransaction struct {
+		ID           uint `gorm:"primaryKey"`
+		FromWalletID *uint
+		ToWalletID   *uint
+		Amount       float64
+		Type         string
+		CreatedAt    int64 `gorm:"autoCreateTime:milli"`
+	}
+)
+
+func (legacyUser) TableName() string        { return "users" }
+func (legacyWallet) TableName() string      { return "wallets" }
+func (legacyTransaction) TableName() string { return "transactions" }
+
+func TestSQLiteAdoptsAutoMigrateSchema(t *testing.T) {
+	cfg := &config.Config{DBDriver: DriverSQLite, DBName: filepath.Join(t.TempDir(), "wallet.db")}
+	conn, err := Open(cfg)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	conn.Logger = logger.Discard
+	sqlDB, err := conn.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	defer sqlDB.Close()
+
+	if err := conn.AutoMigrate(&legacyUser{}, &legacyWallet{}, &legacyTransaction{}); err != nil {
+		t.Fatalf("automigrate: %v", err)
+	}
+	alice, bob := legacyWallet{UserID: 1}, legacyWallet{UserID: 2}
+	conn.Create(&legacyUser{ID: 1, Username: "alice", Password: "x"})
+	conn.Create(&legacyUser{ID: 2, Username: "bob", Password: "x"})
+	conn.Create(&alice)
+	conn.Create(&bob)
+	conn.Create(&legacyTransaction{ToWalletID: &alice.ID, Amount: 100, Type: domain.TxTypeDeposit, CreatedAt: 1})
+	conn.Create(&legacyTransaction{FromWalletID: &alice.ID, ToWalletID: &bob.ID, Amount: 40, Type: domain.TxTypeTransfer, CreatedAt: 2})
+
+	m, err := Migrator(conn)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	for _, column := range []string{"status", "reason_code", "note", "from_balance_after", "to_balance_after"} {
+		if !conn.Migrator().HasColumn(&domain.Transaction{}, column) {
+			t.Errorf("transactions.%s is missing", column)
+		}
+	}
+	var transfer domain.Transaction
+	if err := conn.Where("type = ?", domain.TxTypeTransfer).First(&transfer).Error; err != nil {
+		t.Fatalf("load transfer: %v", err)
+	}
+	if transfer.Status != domain.TxStatusCompleted || transfer.FromBalanceAfter == nil || *transfer.FromBalanceAfter != 60 || transfer.ToBalanceAfter == nil || *transfer.ToBalanceAfter != 40 {
+		t.Fatalf("transfer = %+v, want completed with balances 60 and 40", transfer)
+	}
+	var user domain.User
+	if err := conn.First(&user, 1).Error; err != nil || user.Status != domain.StatusActive {
+		t.Fatalf("user = %+v, %v, want an active user", user, err)
+	}
+}
@@ -0,0 +1,28 @@
+package domain
+
+// Approval statuses
+const (
+	ApprovalPending  = "pending"  // Waiting for a second admin
+	ApprovalApproved = "approved" // Approved and executed
+	ApprovalRejected = "rejected" // Rejected by a second admin
+	ApprovalExpired  = "expired"  // Not decided before ExpiresAt
+	ApprovalFailed   = "failed"   // Approved but execution failed
+)
+
+// PendingAction Model. A sensitive operation proposed by one admin that only runs once a different admin approves it.
+type PendingAction struct {
+	ID           uint   `gorm:"primaryKey"`                    // Primary key
+	Type         string `gorm:"size:64;index"`                 // Registered action type, e.g. role.assign
+	Payload      string `gorm:"type:text"`                     // JSON parameters of the action
+	TargetType   string `gorm:"size:32"`                       // Kind of object acted upon
+	TargetID     string `gorm:"size:64"`                       // ID of the object acted upon
+	Status       string `gorm:"size:16;index;default:pending"` // pending, approved, rejected, expired, failed
+	ProposedBy   uint   `gorm:"index"`                         // Admin who proposed the action
+	ProposalNote string `gorm:"size:255"`                      // Why the action was proposed
+	DecidedBy    *uint  // Admin who approved or rejected the action
+	DecisionNote string `gorm:"size:255"`             // Why the action was approved or rejected
+	Result       string `gorm:"type:text"`            // JSON result, or the error if execution failed
+	CreatedAt    int64  `gorm:"autoCreateTime:milli"` // Timestamp of creation in milliseconds
+	ExpiresAt    int64  `gorm:"index"`                // Timestamp after which the proposal expires, in milliseconds
+	DecidedAt    *int64 // Timestamp of the decision in milliseconds
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"errors" // Error values
+
+	"gorm.io/gorm" // GORM ORM library
+)
+
+// ErrAuditImmutable is returned when code tries to modify or delete an audit record
+var ErrAuditImmutable = errors.New("audit log records are immutable")
+
+// AuditLog Model. Records are append-only and each one is hash-chained to the previous one.
+type AuditLog struct {
+	ID         uint   `gorm:"primaryKey"`          // Primary key, defines chain order
+	ActorID    *uint  `gorm:"index"`               // User who performed the action, nil if anonymous
+	Action     string `gorm:"size:64;index"`       // Action name, e.g. auth.login or role.assign
+	TargetType string `gorm:"size:32;index"`       // Kind of object acted upon, e.g. user
+	TargetID   string `gorm:"size:64;index"`       // ID of the object acted upon
+	Before     string `gorm:"type:text"`           // JSON state before the action
+	After      string `gorm:"type:text"`           // JSON state after the action
+	IP         string `gorm:"size:64"`             // Client IP address
+	UserAgent  string `gorm:"size:255"`            // Client user agent
+	RequestID  string `gorm:"size:64"`             // Request ID for correlation with logs
+	CreatedAt  int64  `gorm:"index"`               // Timestamp of creation in milliseconds
+	PrevHash   string `gorm:"size:64"`             // Hash of the previous record, empty for the first
+	Hash       string `gorm:"size:64;uniqueIndex"` // SHA-256 over this record's fields and PrevHash
+}
+
+// BeforeUpdate prevents audit records from being changed through GORM
+func (a *AuditLog) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditImmutable
+}
+
+// BeforeDelete prevents audit records from being deleted through GORM
+func (a *AuditLog) BeforeDelete(*gorm.DB) error {
+	return ErrAuditImmutable
+}
@@ -0,0 +1,55 @@
+package domain
+
+// Permission is a single action an authenticated user may be allowed to perform
+type Permission string
+
+// Permissions checked by the admin endpoints
+const (
+	PermTxRead         Permission = "tx:read"         // View any user's transactions
+	PermTxReverse      Permission = "tx:reverse"      // Reverse a completed transaction
+	PermUserRead       Permission = "user:read"       // View any user's profile and wallet
+	PermUserFreeze     Permission = "user:freeze"     // Freeze or unfreeze a user or wallet
+	PermRoleAssign     Permission = "role:assign"     // Grant or revoke roles
+	PermAuditRead      Permission = "audit:read"      // Query and export the audit log
+	PermTxAdjust       Permission = "tx:adjust"       // Manually credit or debit a wallet
+	PermUserManage     Permission = "user:manage"     // Force password resets and revoke sessions
+	PermApprovalDecide Permission = "approval:decide" // View and decide pending actions; each decision also needs the action's own permission
+)
+
+// Roles a user can hold
+const (
+	RoleUser       = "user"       // Regular customer, no admin permissions
+	RoleSupport    = "support"    // Customer support, read-only access
+	RoleFinance    = "finance"    // Finance team, money movement corrections
+	RoleCompliance = "compliance" // Compliance team, account restrictions
+	RoleAdmin      = "admin"      // Operations administrator
+	RoleSuperAdmin = "superadmin" // Full access including role management
+	RoleSystem     = "system"     // Internal system accounts; cannot log in or be assigned
+)
+
+// RolePermissions maps each role to the permissions it grants
+var RolePermissions = map[string][]Permission{
+	RoleUser:       {},
+	RoleSupport:    {PermUserRead, PermTxRead, PermUserManage},
+	RoleFinance:    {PermTxRead, PermTxReverse, PermTxAdjust, PermApprovalDecide},
+	RoleCompliance: {PermUserRead, PermTxRead, PermUserFreeze, PermAuditRead},
+	RoleAdmin:      {PermUserRead, PermTxRead, PermTxReverse, PermUserFreeze, PermTxAdjust, PermUserManage, PermApprovalDecide},
+	RoleSuperAdmin: {PermUserRead, PermTxRead, PermTxReverse, PermUserFreeze, PermRoleAssign, PermAuditRead, PermTxAdjust, PermUserManage, PermApprovalDecide},
+}
+
+// IsValidRole reports whether role is a known role
+func IsValidRole(role string) bool {
+	_, ok := RolePermissions[role] // Known roles are the keys of RolePermissions
+	return ok
+}
+
+// HasPermission reports whether role grants the permission
+func HasPermission(role string, perm Permission) bool {
+	// Check every permission granted by the role
+	for _, p := range RolePermissions[role] {
+		if p == perm {
+			return true // Permission granted
+		}
+	}
+	return false // Unknown role or permission not granted
+}
@@ -0,0 +1,32 @@
+package domain
+
+// Statement Model. A wallet's balances and movements over a period, generated once and kept
+// unchanged so it can be reproduced for regulators exactly as it was issued.
+type Statement struct {
+	ID               uint            `gorm:"primaryKey"`                                // Primary key
+	WalletID         uint            `gorm:"not null;uniqueIndex:idx_statement_period"` // Wallet the statement is for
+	UserID           uint            `gorm:"not null;index"`                            // Owner of the wallet when generated
+	PeriodStart      int64           `gorm:"not null;uniqueIndex:idx_statement_period"` // Start of the period in milliseconds, inclusive
+	PeriodEnd        int64           `gorm:"not null"`                                  // End of the period in milliseconds, exclusive
+	Currency         string          `gorm:"size:3"`                                    // ISO 4217 currency code
+	OpeningBalance   float64         // Balance at the start of the period
+	TotalCredits     float64         // Sum of money in during the period
+	TotalDebits      float64         // Sum of money out during the period
+	ClosingBalance   float64         // Balance at the end of the period
+	TransactionCount int             // Number of movements in the period
+	CreatedAt        int64           `gorm:"autoCreateTime:milli"`                          // Timestamp of generation in milliseconds
+	Lines            []StatementLine `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // Movements in posting order
+}
+
+// StatementLine Model. One movement on a statement, as seen from the statement's wallet.
+type StatementLine struct {
+	ID            uint    `gorm:"primaryKey"` // Primary key
+	StatementID   uint    `gorm:"index"`      // Statement the line belongs to
+	TransactionID uint    // Transaction the line shows
+	PostedAt      int64   // Posting time in milliseconds
+	Type          string  `gorm:"size:16"`  // Transaction type
+	Counterparty  string  `gorm:"size:255"` // Owner of the other wallet, if any
+	Description   string  `gorm:"size:255"` // Human-readable description
+	Amount        float64 // Signed amount: positive for credits, negative for debits
+	Balance       float64 // Balance after this line
+}
@@ -0,0 +1,29 @@
+package domain
+
+// Account statuses, shared by users and wallets
+const (
+	StatusActive       = "active"        // No restrictions
+	StatusFrozenDebits = "frozen_debits" // Can receive money but not send it
+	StatusFrozenAll    = "frozen_all"    // Can neither send nor receive money
+	StatusClosed       = "closed"        // Permanently closed; cannot log in or move money
+)
+
+// IsValidStatus reports whether status is a known account status
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusActive, StatusFrozenDebits, StatusFrozenAll, StatusClosed:
+		return true
+	}
+	return false
+}
+
+// CanDebit reports whether an account with this status may send money.
+// An empty status predates account states and counts as active.
+func CanDebit(status string) bool {
+	return status == StatusActive || status == ""
+}
+
+// CanCredit reports whether an account with this status may receive money
+func CanCredit(status string) bool {
+	return CanDebit(status) || status == StatusFrozenDebits
+}
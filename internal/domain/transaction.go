@@ -1,11 +1,86 @@
 package domain
 
-// Transaction Model
+// Transaction types
+const (
+	TxTypeDeposit    = "deposit"    // Money entering a wallet from outside
+	TxTypeTransfer   = "transfer"   // Money moving between two wallets
+	TxTypeReversal   = "reversal"   // Undoes an earlier transaction
+	TxTypeAdjustment = "adjustment" // Manual credit or debit by finance, posted against a system account
+	TxTypeWithdrawal = "withdrawal" // Money leaving the system, e.g. the final payout on closure
+)
+
+// TransactionTypes lists every transaction type
+var TransactionTypes = []string{TxTypeDeposit, TxTypeTransfer, TxTypeReversal, TxTypeAdjustment, TxTypeWithdrawal}
+
+// IsValidTransactionType reports whether t is a known transaction type
+func IsValidTransactionType(t string) bool {
+	for _, v := range TransactionTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Reason codes for manual adjustments
+const (
+	ReasonGoodwill       = "goodwill"       // Goodwill credit to a customer
+	ReasonCorrection     = "correction"     // Correction of an earlier error
+	ReasonChargeback     = "chargeback"     // Card or bank chargeback
+	ReasonFeeRefund      = "fee_refund"     // Refund of a fee
+	ReasonReconciliation = "reconciliation" // Fix for a ledger discrepancy
+)
+
+// AdjustmentReasons lists the reason codes accepted for manual adjustments
+var AdjustmentReasons = []string{ReasonGoodwill, ReasonCorrection, ReasonChargeback, ReasonFeeRefund, ReasonReconciliation}
+
+// IsValidAdjustmentReason reports whether code is an accepted adjustment reason code
+func IsValidAdjustmentReason(code string) bool {
+	for _, r := range AdjustmentReasons {
+		if r == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Transaction statuses
+const (
+	TxStatusCompleted = "completed" // Posted and in effect
+	TxStatusReversed  = "reversed"  // Posted, then undone by a reversal
+)
+
+// IsValidTransactionStatus reports whether s is a known transaction status
+func IsValidTransactionStatus(s string) bool {
+	return s == TxStatusCompleted || s == TxStatusReversed
+}
+
+// Transaction Model. The composite indexes serve keyset pagination on (created_at, id),
+// overall and per side of a wallet, and finding a wallet's last posting before a point in time.
+// The balances after the posting are kept out of JSON so a customer never sees the other side's balance.
 type Transaction struct {
-	ID           uint    `gorm:"primaryKey"` // Primary key
-	FromWalletID *uint   // Foreign key to Wallet of the sender
-	ToWalletID   *uint   // Foreign key to Wallet of the receiver
-	Amount       float64 // Amount of the transaction
-	Type         string  // Transaction type: deposit, transfer
-	CreatedAt    int64   `gorm:"autoCreateTime:milli"` // Timestamp of creation in milliseconds
+	ID               uint     `gorm:"primaryKey;index:idx_tx_created_id,priority:2;index:idx_tx_from_created,priority:3;index:idx_tx_to_created,priority:3"` // Primary key
+	FromWalletID     *uint    `gorm:"index:idx_tx_from_created,priority:1"`                                                                                  // Foreign key to Wallet of the sender
+	ToWalletID       *uint    `gorm:"index:idx_tx_to_created,priority:1"`                                                                                    // Foreign key to Wallet of the receiver
+	Amount           float64  // Amount of the transaction
+	Type             string   // Transaction type: deposit, transfer, reversal, adjustment, withdrawal
+	Status           string   `gorm:"size:16;not null;default:completed"`                                                                                              // Transaction status: completed, reversed
+	ReferenceID      *uint    `gorm:"index"`                                                                                                                           // Transaction this one reverses, if any
+	ReasonCode       string   `gorm:"size:32"`                                                                                                                         // Reason code for adjustments
+	Note             string   `gorm:"size:255"`                                                                                                                        // Free-text note for adjustments
+	FromBalanceAfter *float64 `json:"-"`                                                                                                                               // Sender's balance right after this posting
+	ToBalanceAfter   *float64 `json:"-"`                                                                                                                               // Receiver's balance right after this posting
+	CreatedAt        int64    `gorm:"autoCreateTime:milli;index:idx_tx_created_id,priority:1;index:idx_tx_from_created,priority:2;index:idx_tx_to_created,priority:2"` // Timestamp of creation in milliseconds
+}
+
+// BalanceAfter returns the balance of the given wallet right after this transaction, or nil if it
+// was not recorded or the wallet is not a side of the transaction
+func (t *Transaction) BalanceAfter(walletID uint) *float64 {
+	if t.ToWalletID != nil && *t.ToWalletID == walletID {
+		return t.ToBalanceAfter
+	}
+	if t.FromWalletID != nil && *t.FromWalletID == walletID {
+		return t.FromBalanceAfter
+	}
+	return nil
 }
@@ -2,9 +2,12 @@ package domain
 
 // User Model
 type User struct {
-	ID       uint   `gorm:"primaryKey"`                                     // Primary key
-	Username string `gorm:"unique;not null"`                                // Unique username
-	Password string `gorm:"not null"`                                       // Hashed password
-	Role     string `gorm:"default:user"`                                   // Role: user or admin
-	Wallet   Wallet `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // One-to-one relationship with Wallet
+	ID                uint   `gorm:"primaryKey"`                                     // Primary key
+	Username          string `gorm:"unique;not null"`                                // Unique username
+	Password          string `gorm:"not null"`                                       // Hashed password
+	Role              string `gorm:"default:user"`                                   // Role: one of the roles in RolePermissions
+	Status            string `gorm:"size:16;not null;default:active"`                // Account status: active, frozen_debits, frozen_all, closed
+	StatusReason      string `gorm:"size:255"`                                       // Why the status was last changed
+	MustResetPassword bool   `gorm:"not null;default:false"`                         // Set by an admin; login is refused until the password is reset
+	Wallet            Wallet `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"` // One-to-one relationship with Wallet
 }
@@ -2,7 +2,16 @@ package domain
 
 // Wallet Model
 type Wallet struct {
-	ID      uint    `gorm:"primaryKey"`         // Primary key
-	UserID  uint    `gorm:"uniqueIndex"`        // Foreign key to User
-	Balance float64 `gorm:"not null;default:0"` // Wallet balance
+	ID           uint    `gorm:"primaryKey"`                      // Primary key
+	UserID       uint    `gorm:"uniqueIndex"`                     // Foreign key to User
+	Balance      float64 `gorm:"not null;default:0"`              // Wallet balance
+	IsSystem     bool    `gorm:"not null;default:false"`          // System accounts may go negative
+	Status       string  `gorm:"size:16;not null;default:active"` // Wallet status: active, frozen_debits, frozen_all, closed
+	StatusReason string  `gorm:"size:255"`                        // Why the status was last changed
 }
+
+// System account usernames. System users own the internal wallets that manual
+// postings are booked against, so that every movement has two sides.
+const (
+	SystemAccountAdjustments = "sys.adjustments" // Counterparty for manual adjustments
+)
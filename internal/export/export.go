@@ -0,0 +1,147 @@
+package export
+
+import (
+	"encoding/csv"  // CSV output
+	"encoding/json" // JSON Lines output
+	"errors"        // Error values
+	"io"            // Output stream
+	"strconv"       // Number formatting
+	"time"          // Timestamps
+)
+
+// Supported export formats
+const (
+	FormatCSV   = "csv"   // Comma-separated values with a header row
+	FormatJSONL = "jsonl" // One JSON object per line
+	FormatOFX   = "ofx"   // Open Financial Exchange 2.2 bank statement
+)
+
+// ErrUnsupportedFormat is returned for unknown formats
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// ErrAccountRequired is returned when a format needs a single account and none was given
+var ErrAccountRequired = errors.New("format requires a single wallet")
+
+// Row is one exported transaction. When the export is for a single wallet, Amount is signed
+// from that wallet's point of view and Direction, Counterparty and RunningBalance are set.
+type Row struct {
+	ID             uint      `json:"id"`                        // Transaction ID
+	CreatedAt      time.Time `json:"created_at"`                // Posting time
+	Type           string    `json:"type"`                      // Transaction type
+	Status         string    `json:"status"`                    // Transaction status
+	Direction      string    `json:"direction,omitempty"`       // credit or debit, for single-wallet exports
+	Amount         float64   `json:"amount"`                    // Amount, negative for debits in single-wallet exports
+	FromWalletID   *uint     `json:"from_wallet_id"`            // Sending wallet
+	FromUsername   string    `json:"from_username,omitempty"`   // Owner of the sending wallet
+	ToWalletID     *uint     `json:"to_wallet_id"`              // Receiving wallet
+	ToUsername     string    `json:"to_username,omitempty"`     // Owner of the receiving wallet
+	Counterparty   string    `json:"counterparty,omitempty"`    // Owner of the other wallet, for single-wallet exports
+	RunningBalance *float64  `json:"running_balance,omitempty"` // Balance after this row, when it can be computed
+	ReferenceID    *uint     `json:"reference_id,omitempty"`    // Transaction this one reverses
+	ReasonCode     string    `json:"reason_code,omitempty"`     // Adjustment reason code
+	Note           string    `json:"note,omitempty"`            // Free-text note
+}
+
+// Meta describes the export as a whole
+type Meta struct {
+	WalletID       *uint     // Wallet the export is for, nil for multi-wallet exports
+	Currency       string    // ISO 4217 currency code
+	From           time.Time // Start of the period, zero if open
+	To             time.Time // End of the period, zero if open
+	OpeningBalance float64   // Wallet balance at the start of the period
+}
+
+// Writer streams rows in one format
+type Writer interface {
+	Write(r Row) error // Write one row
+	Close() error      // Write any trailer and flush
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatOFX:
+		return "application/x-ofx"
+	}
+	return "application/x-ndjson"
+}
+
+// NewWriter returns a Writer for format that writes to w
+func NewWriter(format string, w io.Writer, meta Meta) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	case FormatOFX:
+		if meta.WalletID == nil {
+			return nil, ErrAccountRequired // OFX statements describe one account
+		}
+		return &ofxWriter{w: w, meta: meta}, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// money formats an amount with two decimals
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// optionalID formats a nullable ID, empty when nil
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+// csvWriter writes rows as CSV with a header row
+type csvWriter struct {
+	w *csv.Writer // Underlying CSV writer
+}
+
+// newCSVWriter writes the header and returns the writer
+func newCSVWriter(w io.Writer) *csvWriter {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	_ = cw.w.Write([]string{"id", "created_at", "type", "status", "direction", "amount", "from_wallet_id", "from_username",
+		"to_wallet_id", "to_username", "counterparty", "running_balance", "reference_id", "reason_code", "note"})
+	return cw
+}
+
+// Write writes one row and flushes it to the client
+func (cw *csvWriter) Write(r Row) error {
+	balance := "" // Empty when the running balance is unknown
+	if r.RunningBalance != nil {
+		balance = money(*r.RunningBalance)
+	}
+	_ = cw.w.Write([]string{
+		strconv.FormatUint(uint64(r.ID), 10), r.CreatedAt.UTC().Format(time.RFC3339Nano), r.Type, r.Status, r.Direction,
+		money(r.Amount), optionalID(r.FromWalletID), r.FromUsername, optionalID(r.ToWalletID), r.ToUsername,
+		r.Counterparty, balance, optionalID(r.ReferenceID), r.ReasonCode, r.Note,
+	})
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// Close flushes any buffered output
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// jsonlWriter writes rows as JSON Lines
+type jsonlWriter struct {
+	enc *json.Encoder // Encoder writing one object per line
+}
+
+// Write writes one row
+func (jw *jsonlWriter) Write(r Row) error {
+	return jw.enc.Encode(r)
+}
+
+// Close has nothing to flush
+func (jw *jsonlWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,107 @@
+package export
+
+import (
+	"bytes"        // Buffer for escaping
+	"encoding/xml" // XML escaping
+	"fmt"          // Formatted output
+	"io"           // Output stream
+	"strconv"      // Number formatting
+	"time"         // Timestamps
+)
+
+// ofxTime formats a time as an OFX date in UTC
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:UTC]"
+}
+
+// ofxText escapes s for XML and truncates it to max characters
+func ofxText(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		s = string(r[:max])
+	}
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// ofxWriter writes an OFX 2.2 bank statement for a single wallet. The header is written with
+// the first row, so an open period can start at the first transaction.
+type ofxWriter struct {
+	w       io.Writer // Output stream
+	meta    Meta      // Account and period
+	started bool      // Whether the header has been written
+	balance float64   // Balance after the last row written
+	err     error     // First write error
+}
+
+// printf writes formatted output, remembering the first error
+func (ow *ofxWriter) printf(format string, args ...any) {
+	if ow.err == nil {
+		_, ow.err = fmt.Fprintf(ow.w, format, args...)
+	}
+}
+
+// begin writes the OFX header and opens the transaction list
+func (ow *ofxWriter) begin(start time.Time) {
+	ow.started = true
+	ow.balance = ow.meta.OpeningBalance
+	end := ow.meta.To // End of the period
+	if end.IsZero() {
+		end = time.Now()
+	}
+	ow.printf("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	ow.printf("<?OFX OFXHEADER=\"200\" VERSION=\"220\" SECURITY=\"NONE\" OLDFILEUID=\"NONE\" NEWFILEUID=\"NONE\"?>\n")
+	ow.printf("<OFX>\n<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>")
+	ow.printf("<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>\n", ofxTime(time.Now()))
+	ow.printf("<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	ow.printf("<STMTRS><CURDEF>%s</CURDEF>\n", ofxText(ow.meta.Currency, 3))
+	ow.printf("<BANKACCTFROM><BANKID>WALLET</BANKID><ACCTID>%d</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n", *ow.meta.WalletID)
+	ow.printf("<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", ofxTime(start), ofxTime(end))
+}
+
+// Write writes one row as a STMTTRN
+func (ow *ofxWriter) Write(r Row) error {
+	if !ow.started {
+		start := ow.meta.From // Start of the period
+		if start.IsZero() {
+			start = r.CreatedAt // Open periods start at the first transaction
+		}
+		ow.begin(start)
+	}
+	trnType := "CREDIT" // Money in
+	if r.Amount < 0 {
+		trnType = "DEBIT" // Money out
+	}
+	memo := r.Type // Describe the movement
+	if r.Note != "" {
+		memo += ": " + r.Note
+	}
+	ow.printf("<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID>",
+		trnType, ofxTime(r.CreatedAt), money(r.Amount), strconv.FormatUint(uint64(r.ID), 10))
+	if r.Counterparty != "" {
+		ow.printf("<NAME>%s</NAME>", ofxText(r.Counterparty, 32))
+	}
+	ow.printf("<MEMO>%s</MEMO></STMTTRN>\n", ofxText(memo, 255))
+	if r.RunningBalance != nil {
+		ow.balance = *r.RunningBalance // Track the closing balance
+	}
+	return ow.err
+}
+
+// Close closes the transaction list and writes the closing balance
+func (ow *ofxWriter) Close() error {
+	if !ow.started {
+		start := ow.meta.From // Empty statement over the requested period
+		if start.IsZero() {
+			start = time.Now()
+		}
+		ow.begin(start)
+	}
+	asOf := ow.meta.To // Balance date
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	ow.printf("</BANKTRANLIST>\n<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n", money(ow.balance), ofxTime(asOf))
+	ow.printf("</STMTRS></STMTTRNRS></BANKMSGSRSV1>\n</OFX>\n")
+	return ow.err
+}
@@ -0,0 +1,314 @@
+package ledger
+
+import (
+	"errors"                        // Error values
+	"sort"                          // Sorting wallet IDs for lock ordering
+	"wallet_system/internal/domain" // Importing domain models
+
+	"gorm.io/gorm"        // GORM ORM library
+	"gorm.io/gorm/clause" // Row locking
+)
+
+// Errors returned by Post
+var (
+	ErrInvalidAmount     = errors.New("amount must be positive")     // Zero or negative amount
+	ErrWalletNotFound    = errors.New("wallet not found")            // A referenced wallet does not exist
+	ErrInsufficientFunds = errors.New("insufficient funds")          // Debited wallet balance too low
+	ErrNoWallets         = errors.New("posting touches no wallet")   // Neither side given
+	ErrDebitsFrozen      = errors.New("wallet cannot send funds")    // Debited wallet is frozen or closed
+	ErrCreditsFrozen     = errors.New("wallet cannot receive funds") // Credited wallet is frozen or closed
+	ErrWalletClosed      = errors.New("wallet is closed")            // Closed wallets never move money
+)
+
+// Posting describes a single money movement. A nil FromWalletID means money
+// enters the system (deposit); a nil ToWalletID means money leaves it.
+type Posting struct {
+	FromWalletID *uint   // Wallet to debit, nil for money entering the system
+	ToWalletID   *uint   // Wallet to credit, nil for money leaving the system
+	Amount       float64 // Amount to move, must be positive
+	Type         string  // Transaction type
+	ReferenceID  *uint   // Related transaction, e.g. the one being reversed
+	ReasonCode   string  // Reason code, for adjustments
+	Note         string  // Free-text note, for adjustments
+	// Administrative postings (reversals, adjustments, payouts) ignore freezes,
+	// so compliance can still correct a frozen account. Closed wallets are never touched.
+	Administrative bool
+}
+
+// Result is the outcome of a posting
+type Result struct {
+	Transaction domain.Transaction      // Recorded transaction
+	Wallets     map[uint]*domain.Wallet // Locked wallets, with balances before the posting
+}
+
+// LockWallets loads the given wallets with row locks. Locks are taken in ascending ID order
+// so that two postings touching the same pair of wallets can never deadlock.
+func LockWallets(tx *gorm.DB, ids ...uint) (map[uint]*domain.Wallet, error) {
+	sorted := append([]uint(nil), ids...)                                    // Copy before sorting
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] }) // Ascending lock order
+	wallets := make(map[uint]*domain.Wallet, len(sorted))                    // Locked wallets by ID
+	for _, id := range sorted {
+		if _, done := wallets[id]; done {
+			continue // Already locked
+		}
+		var w domain.Wallet // Wallet to lock
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&w, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrWalletNotFound // Wallet does not exist
+			}
+			return nil, err // Database error
+		}
+		wallets[id] = &w
+	}
+	return wallets, nil
+}
+
+// Post locks the wallets involved, checks funds, moves the money and records the transaction.
+// It must be called inside a database transaction.
+func Post(tx *gorm.DB, p Posting) (*Result, error) {
+	if p.Amount <= 0 {
+		return nil, ErrInvalidAmount // Reject non-positive amounts
+	}
+	var ids []uint // Wallets to lock
+	if p.FromWalletID != nil {
+		ids = append(ids, *p.FromWalletID)
+	}
+	if p.ToWalletID != nil {
+		ids = append(ids, *p.ToWalletID)
+	}
+	if len(ids) == 0 {
+		return nil, ErrNoWallets // Nothing to post against
+	}
+	wallets, err := LockWallets(tx, ids...) // Lock all wallets involved
+	if err != nil {
+		return nil, err
+	}
+	// Check wallet statuses under the lock
+	for _, w := range wallets {
+		if w.Status == domain.StatusClosed {
+			return nil, ErrWalletClosed
+		}
+	}
+	if !p.Administrative {
+		if p.FromWalletID != nil && !domain.CanDebit(wallets[*p.FromWalletID].Status) {
+			return nil, ErrDebitsFrozen
+		}
+		if p.ToWalletID != nil && !domain.CanCredit(wallets[*p.ToWalletID].Status) {
+			return nil, ErrCreditsFrozen
+		}
+	}
+	// Debit the sender, checking funds under the lock
+	if p.FromWalletID != nil {
+		from := wallets[*p.FromWalletID]
+		// System accounts carry the other side of manual postings and may go negative
+		if !from.IsSystem && from.Balance < p.Amount {
+			return nil, ErrInsufficientFunds // Not enough money
+		}
+		if err := tx.Model(from).Update("balance", gorm.Expr("balance - ?", p.Amount)).Error; err != nil {
+			return nil, err // Return error to rollback
+		}
+	}
+	// Credit the receiver
+	if p.ToWalletID != nil {
+		if err := tx.Model(wallets[*p.ToWalletID]).Update("balance", gorm.Expr("balance + ?", p.Amount)).Error; err != nil {
+			return nil, err // Return error to rollback
+		}
+	}
+	// Create transaction record
+	t := domain.Transaction{
+		FromWalletID: p.FromWalletID,           // Pointer to handle nullability
+		ToWalletID:   p.ToWalletID,             // Pointer to handle nullability
+		Amount:       p.Amount,                 // Amount moved
+		Type:         p.Type,                   // Transaction type
+		Status:       domain.TxStatusCompleted, // Posted transactions are completed
+		ReferenceID:  p.ReferenceID,            // Related transaction
+		ReasonCode:   p.ReasonCode,             // Reason code
+		Note:         p.Note,                   // Note
+	}
+	// Record the balances after the posting, computed under the locks, so historical
+	// balances can be read from a single row
+	after := make(map[uint]float64, len(wallets)) // Balances after the posting
+	for id, w := range wallets {
+		after[id] = w.Balance
+	}
+	if p.FromWalletID != nil {
+		after[*p.FromWalletID] -= p.Amount
+	}
+	if p.ToWalletID != nil {
+		after[*p.ToWalletID] += p.Amount
+	}
+	if p.FromWalletID != nil {
+		b := after[*p.FromWalletID]
+		t.FromBalanceAfter = &b
+	}
+	if p.ToWalletID != nil {
+		b := after[*p.ToWalletID]
+		t.ToBalanceAfter = &b
+	}
+	if err := tx.Create(&t).Error; err != nil {
+		return nil, err // Return error to rollback
+	}
+	return &Result{Transaction: t, Wallets: wallets}, nil
+}
+
+// SystemWallet returns the wallet of a system account, creating the account on first use
+func SystemWallet(tx *gorm.DB, username string) (*domain.Wallet, error) {
+	var user domain.User // System user owning the wallet
+	err := tx.Where("username = ? AND role = ?", username, domain.RoleSystem).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The password is not a bcrypt hash, so the account can never log in
+		user = domain.User{Username: username, Password: "!", Role: domain.RoleSystem}
+		if err := tx.Create(&user).Error; err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	var wallet domain.Wallet // System wallet
+	err = tx.Where("user_id = ?", user.ID).First(&wallet).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		wallet = domain.Wallet{UserID: user.ID, IsSystem: true}
+		if err := tx.Create(&wallet).Error; err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// ErrNotReversible is returned when a transaction cannot be reversed
+var ErrNotReversible = errors.New("transaction cannot be reversed")
+
+// Reversible reports whether t can be reversed. Only completed deposits and transfers can;
+// adjustments are corrected with an opposite adjustment, and reversals and withdrawals are final.
+func Reversible(t *domain.Transaction) bool {
+	if t.Status != domain.TxStatusCompleted {
+		return false
+	}
+	return t.Type == domain.TxTypeDeposit || t.Type == domain.TxTypeTransfer
+}
+
+// Reverse posts the opposite of a completed transaction and marks the original as reversed.
+// It must be called inside a database transaction.
+func Reverse(tx *gorm.DB, txID uint) (*Result, error) {
+	var original domain.Transaction // Transaction to reverse, locked against concurrent reversal
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&original, txID).Error; err != nil {
+		return nil, err
+	}
+	if !Reversible(&original) {
+		return nil, ErrNotReversible
+	}
+	// Swap the sides to undo the movement
+	result, err := Post(tx, Posting{
+		FromWalletID:   original.ToWalletID,   // Take the money back from the receiver
+		ToWalletID:     original.FromWalletID, // Return it to the sender, or out of the system
+		Amount:         original.Amount,       // Same amount
+		Type:           domain.TxTypeReversal, // Reversal type
+		ReferenceID:    &original.ID,          // Link to the original
+		Administrative: true,                  // Corrections apply to frozen wallets too
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Mark the original as reversed
+	if err := tx.Model(&original).Update("status", domain.TxStatusReversed).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// BalanceBefore returns a wallet's balance as of the given time in milliseconds, i.e. after every
+// transaction posted to it strictly before then. It reads the balance recorded on the wallet's last
+// posting before that time, which the (wallet, created_at, id) indexes find directly.
+func BalanceBefore(db *gorm.DB, walletID uint, beforeMs int64) (float64, error) {
+	var last *domain.Transaction // Latest posting before beforeMs on either side
+	for _, column := range []string{"from_wallet_id", "to_wallet_id"} {
+		var t domain.Transaction // Latest posting on this side
+		err := db.Where(column+" = ? AND created_at < ?", walletID, beforeMs).
+			Order("created_at desc").Order("id desc").Take(&t).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		if last == nil || t.CreatedAt > last.CreatedAt || (t.CreatedAt == last.CreatedAt && t.ID > last.ID) {
+			last = &t
+		}
+	}
+	if last == nil {
+		return 0, nil // Wallets start at zero
+	}
+	if b := last.BalanceAfter(walletID); b != nil {
+		return *b, nil
+	}
+	return SumBefore(db, walletID, beforeMs) // Not recorded yet; see BackfillBalances
+}
+
+// SumBefore computes a wallet's balance before the given time by summing every transaction
+// posted to it. Wallets start at zero and only move through postings.
+func SumBefore(db *gorm.DB, walletID uint, beforeMs int64) (float64, error) {
+	var balance float64 // Credits minus debits
+	err := db.Model(&domain.Transaction{}).
+		Select("COALESCE(SUM(CASE WHEN to_wallet_id = ? THEN amount ELSE 0 END), 0) - "+
+			"COALESCE(SUM(CASE WHEN from_wallet_id = ? THEN amount ELSE 0 END), 0)", walletID, walletID).
+		Where("(from_wallet_id = ? OR to_wallet_id = ?) AND created_at < ?", walletID, walletID, beforeMs).
+		Scan(&balance).Error
+	return balance, err
+}
+
+// BackfillBalances records the balances after each posting on transactions created before they were
+// tracked, replaying the ledger in posting order. Balances already recorded are trusted and carried
+// forward. It returns the number of transactions updated.
+func BackfillBalances(db *gorm.DB) (int64, error) {
+	var missing int64 // Transactions without recorded balances
+	err := db.Model(&domain.Transaction{}).
+		Where("(from_wallet_id IS NOT NULL AND from_balance_after IS NULL) OR (to_wallet_id IS NOT NULL AND to_balance_after IS NULL)").
+		Count(&missing).Error
+	if err != nil || missing == 0 {
+		return 0, err
+	}
+	balances := map[uint]float64{} // Running balance per wallet
+	var updated int64              // Rows changed
+	// replay applies one side of a posting, or takes the recorded balance if there is one
+	replay := func(updates map[string]any, walletID *uint, delta float64, recorded *float64, column string) {
+		if walletID == nil {
+			return
+		}
+		if recorded != nil {
+			balances[*walletID] = *recorded
+			return
+		}
+		balances[*walletID] += delta
+		updates[column] = balances[*walletID]
+	}
+	// Read the ledger in keyset batches rather than streaming it, so updates can share the
+	// connection when called inside a transaction
+	var last *domain.Transaction // Last transaction of the previous batch
+	for {
+		query := db.Order("created_at asc").Order("id asc").Limit(1000)
+		if last != nil {
+			query = query.Where("created_at > ? OR (created_at = ? AND id > ?)", last.CreatedAt, last.CreatedAt, last.ID)
+		}
+		var batch []domain.Transaction // Next postings in order
+		if err := query.Find(&batch).Error; err != nil {
+			return updated, err
+		}
+		if len(batch) == 0 {
+			return updated, nil
+		}
+		for _, t := range batch {
+			updates := map[string]any{} // Balances to record on this row
+			replay(updates, t.FromWalletID, -t.Amount, t.FromBalanceAfter, "from_balance_after")
+			replay(updates, t.ToWalletID, t.Amount, t.ToBalanceAfter, "to_balance_after")
+			if len(updates) == 0 {
+				continue
+			}
+			if err := db.Model(&domain.Transaction{}).Where("id = ?", t.ID).Updates(updates).Error; err != nil {
+				return updated, err
+			}
+			updated++
+		}
+		last = &batch[len(batch)-1]
+	}
+}
@@ -0,0 +1,160 @@
+package logging
+
+import (
+	"context"                       // Request IDs in contexts
+	"fmt"                           // Error messages
+	"log"                           // Standard library logger
+	"strings"                       // Field name matching
+	"wallet_system/internal/config" // Logging settings
+
+	"github.com/gin-gonic/gin"   // Gin web framework
+	"github.com/sirupsen/logrus" // Logging library
+)
+
+// Supported values of LOG_FORMAT
+const (
+	FormatJSON = "json" // One JSON object per line, for log collectors
+	FormatText = "text" // key=value lines, for reading in a terminal
+)
+
+// Redacted replaces the value of sensitive fields
+const Redacted = "[REDACTED]"
+
+// loggerKey stores the per-request logger in the Gin context
+const loggerKey = "logger"
+
+// requestIDKey stores the request ID in a context.Context
+type requestIDKey struct{}
+
+// sensitiveSuffixes are field names, or endings of field names, whose values are never logged
+var sensitiveSuffixes = []string{"password", "token", "secret", "authorization", "cookie", "api_key"}
+
+// Setup configures the standard logrus logger: level, format, request IDs and redaction.
+// Output of the standard library log package goes through logrus as well, so the process
+// writes a single format.
+func Setup(cfg *config.Config) error {
+	level, err := logrus.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL %q: %w", cfg.LogLevel, err)
+	}
+	logrus.SetLevel(level)
+	switch cfg.LogFormat {
+	case FormatJSON:
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	case FormatText:
+		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return fmt.Errorf("unsupported LOG_FORMAT %q; use json or text", cfg.LogFormat)
+	}
+	logrus.AddHook(RequestIDHook{})
+	logrus.AddHook(RedactHook{})
+	log.SetFlags(0)                                 // logrus adds the timestamp
+	log.SetOutput(logrus.StandardLogger().Writer()) // Stdlib log lines become logrus entries
+	return nil
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID in ctx, or an empty string if there is none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// SetLogger stores the per-request logger in the Gin context
+func SetLogger(c *gin.Context, entry *logrus.Entry) {
+	c.Set(loggerKey, entry)
+}
+
+// Logger returns the logger of the request, which tags every entry with its request ID and
+// trace. Outside a request it returns the standard logger.
+func Logger(c *gin.Context) *logrus.Entry {
+	if v, ok := c.Get(loggerKey); ok {
+		if entry, ok := v.(*logrus.Entry); ok {
+			return entry.WithContext(c.Request.Context())
+		}
+	}
+	return logrus.WithContext(c.Request.Context())
+}
+
+// RequestIDHook adds request_id to entries made with a request context, via logrus.WithContext
+type RequestIDHook struct{}
+
+// Levels returns every level; the hook applies to all of them
+func (RequestIDHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire copies the request ID of the entry's context into its fields
+func (RequestIDHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if id := RequestID(entry.Context); id != "" {
+		entry.Data["request_id"] = id
+	}
+	return nil
+}
+
+// RedactHook replaces the values of sensitive fields, including those nested in maps, so a
+// careless log call cannot leak a password or token
+type RedactHook struct{}
+
+// Levels returns every level; the hook applies to all of them
+func (RedactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire redacts the sensitive fields of the entry
+func (RedactHook) Fire(entry *logrus.Entry) error {
+	for k, v := range entry.Data {
+		entry.Data[k] = redact(k, v)
+	}
+	return nil
+}
+
+// Sensitive reports whether a field with this name holds a secret
+func Sensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveSuffixes {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact returns the value to log for the field name
+func redact(name string, v any) any {
+	if Sensitive(name) {
+		return Redacted
+	}
+	switch m := v.(type) {
+	case map[string]any:
+		return redactMap(m)
+	case gin.H:
+		return redactMap(m)
+	case map[string]string:
+		out := make(map[string]string, len(m)) // Copy; the caller still owns m
+		for k, s := range m {
+			if Sensitive(k) {
+				s = Redacted
+			}
+			out[k] = s
+		}
+		return out
+	}
+	return v
+}
+
+// redactMap returns a copy of m with sensitive values redacted
+func redactMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m)) // Copy; the caller still owns m
+	for k, v := range m {
+		out[k] = redact(k, v)
+	}
+	return out
+}
@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"database/sql" // Connection pool statistics
+	"net/http"     // Exposition handler
+	"strconv"      // Status code labels
+	"time"         // Request durations
+
+	"github.com/gin-gonic/gin"                                  // Gin web framework
+	"github.com/prometheus/client_golang/prometheus"            // Metric types
+	"github.com/prometheus/client_golang/prometheus/collectors" // Database pool collector
+	"github.com/prometheus/client_golang/prometheus/promauto"   // Registration on the default registry
+	"github.com/prometheus/client_golang/prometheus/promhttp"   // Exposition handler
+	"github.com/redis/go-redis/v9"                              // Redis client
+)
+
+// namespace prefixes every metric name
+const namespace = "wallet"
+
+// Operations counted by OperationSucceeded and OperationFailed
+const (
+	OpDeposit  = "deposit"  // Money entering a wallet from outside
+	OpTransfer = "transfer" // Money moving between two wallets
+)
+
+// Cache lookup results counted by CacheLookup
+const (
+	CacheHit   = "hit"   // Value served from Redis
+	CacheMiss  = "miss"  // Value not cached
+	CacheError = "error" // Redis failed; the caller falls back to the database
+)
+
+// unmatchedRoute labels requests that match no route, so scanners cannot create a label per path
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	operations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operations_total",
+		Help:      "Successful deposits and transfers.",
+	}, []string{"operation"})
+	operationVolume = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operation_amount_total",
+		Help:      "Amount moved by successful deposits and transfers, in the wallet currency.",
+	}, []string{"operation"})
+	operationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operation_failures_total",
+		Help:      "Rejected or failed deposits and transfers by reason.",
+	}, []string{"operation", "reason"})
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Redis cache lookups by result; the hit ratio is hit / (hit + miss).",
+	}, []string{"result"})
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests rejected with 429 by route group.",
+	}, []string{"group"})
+	rateLimitFallbacks = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_fallbacks_total",
+		Help:      "Rate limit checks made in memory because Redis was unavailable.",
+	})
+)
+
+// Middleware records the count and latency of every request, labelled by route template
+// rather than path so that IDs in the path do not multiply the series
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now() // Request start
+		c.Next()
+		route := c.FullPath() // Route template such as /admin/users/:id
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status()) // Response status code
+		httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the metrics of the default registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// OperationSucceeded counts a completed deposit or transfer and its amount
+func OperationSucceeded(op string, amount float64) {
+	operations.WithLabelValues(op).Inc()
+	operationVolume.WithLabelValues(op).Add(amount)
+}
+
+// OperationFailed counts a deposit or transfer that was rejected or failed, by reason
+func OperationFailed(op, reason string) {
+	operationFailures.WithLabelValues(op, reason).Inc()
+}
+
+// CacheLookup counts a cache read by result
+func CacheLookup(result string) {
+	cacheLookups.WithLabelValues(result).Inc()
+}
+
+// RateLimited counts a request rejected by the rate limit of group
+func RateLimited(group string) {
+	rateLimited.WithLabelValues(group).Inc()
+}
+
+// RateLimitFallback counts a rate limit check made in memory instead of Redis
+func RateLimitFallback() {
+	rateLimitFallbacks.Inc()
+}
+
+// RegisterPools exposes the connection pool statistics of the database and Redis clients.
+// Call it once per process.
+func RegisterPools(db *sql.DB, rdb *redis.Client) error {
+	if err := prometheus.Register(collectors.NewDBStatsCollector(db, namespace)); err != nil {
+		return err
+	}
+	return prometheus.Register(&redisPoolCollector{rdb: rdb})
+}
+
+// Descriptions of the Redis pool metrics
+var (
+	redisHits     = prometheus.NewDesc(namespace+"_redis_pool_hits_total", "Times a free connection was found in the Redis pool.", nil, nil)
+	redisMisses   = prometheus.NewDesc(namespace+"_redis_pool_misses_total", "Times a new Redis connection had to be opened.", nil, nil)
+	redisTimeouts = prometheus.NewDesc(namespace+"_redis_pool_timeouts_total", "Times waiting for a Redis connection timed out.", nil, nil)
+	redisTotal    = prometheus.NewDesc(namespace+"_redis_pool_connections", "Connections in the Redis pool.", nil, nil)
+	redisIdle     = prometheus.NewDesc(namespace+"_redis_pool_idle_connections", "Idle connections in the Redis pool.", nil, nil)
+	redisStale    = prometheus.NewDesc(namespace+"_redis_pool_stale_connections_total", "Stale connections removed from the Redis pool.", nil, nil)
+)
+
+// redisPoolCollector reads the Redis pool statistics at scrape time
+type redisPoolCollector struct {
+	rdb *redis.Client // Client whose pool is reported
+}
+
+// Describe sends the descriptions of the Redis pool metrics
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{redisHits, redisMisses, redisTimeouts, redisTotal, redisIdle, redisStale} {
+		ch <- d
+	}
+}
+
+// Collect sends the current Redis pool statistics
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.rdb.PoolStats() // Snapshot of the pool
+	ch <- prometheus.MustNewConstMetric(redisHits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(redisMisses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(redisTimeouts, prometheus.CounterValue, float64(s.Timeouts))
+	ch <- prometheus.MustNewConstMetric(redisTotal, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(redisIdle, prometheus.GaugeValue, float64(s.IdleConns))
+	ch <- prometheus.MustNewConstMetric(redisStale, prometheus.CounterValue, float64(s.StaleConns))
+}
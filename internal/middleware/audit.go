@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"wallet_system/internal/apierror"   // Error responses
+	"wallet_system/internal/audit"      // Audit log
+	"wallet_system/internal/logging"    // Request logger
+	"wallet_system/internal/repository" // Audit persistence
+
+	"github.com/gin-gonic/gin"   // Gin web framework
+	"github.com/sirupsen/logrus" // Logging library
+)
+
+// AuditAccessMiddleware records who accessed an admin endpoint and with which query before serving it.
+// If the access cannot be recorded, the request is refused rather than served unaudited.
+func AuditAccessMiddleware(records repository.AuditRepository, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entry := audit.FromRequest(c)       // Actor and client details
+		entry.Action = action               // Action name
+		entry.TargetType = "query"          // Reads target a query rather than a single object
+		entry.TargetID = c.FullPath()       // Route that was accessed
+		entry.After = c.Request.URL.Query() // Filters and pagination requested
+		if err := records.Record(c.Request.Context(), entry); err != nil {
+			// Log the failure with context
+			logging.Logger(c).WithFields(logrus.Fields{
+				"action": action,      // Action that failed to record
+				"error":  err.Error(), // Error message
+			}).Error("Failed to record audit entry")
+			apierror.Abort(c, apierror.Internal, "Audit log unavailable")
+			return
+		}
+		c.Next() // Proceed to the next handler
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"errors"                         // Error inspection
+	"net/http"                       // Response controller
+	"time"                           // Deadlines
+	"wallet_system/internal/logging" // Request logger
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// WriteDeadlineMiddleware gives the routes it guards until d from now to write their response,
+// replacing the server's WriteTimeout, so streamed exports of large ledgers are not cut off
+func WriteDeadlineMiddleware(d time.Duration) gin.HandlerFunc {
+	if d <= 0 {
+		return func(c *gin.Context) { c.Next() } // Keep the server's WriteTimeout
+	}
+	return func(c *gin.Context) {
+		err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(d))
+		// Writers without a connection, such as test recorders, have no deadline to extend
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			logging.Logger(c).WithField("error", err.Error()).Warn("Failed to extend write deadline")
+		}
+		c.Next() // Proceed to the next handler
+	}
+}
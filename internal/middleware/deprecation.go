@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"                        // HTTP date formatting
+	"strconv"                         // String conversion
+	"time"                            // Deprecation and sunset dates
+	"wallet_system/internal/apierror" // Response envelopes
+
+	"github.com/gin-gonic/gin" // Gin web framework
+)
+
+// DeprecatedMiddleware marks a route as deprecated, pointing clients at its successor.
+// It sets the Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers on every response.
+func DeprecatedMiddleware(successor string, deprecatedAt, sunset time.Time) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10) // Structured field date
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)              // HTTP date
+	link := "<" + successor + ">; rel=\"successor-version\""        // Successor link
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation) // When the route was deprecated
+		c.Header("Sunset", sunsetDate)       // When the route will be removed
+		c.Header("Link", link)               // Where clients should move to
+		c.Next()                             // Proceed to the next handler
+	}
+}
+
+// UnversionedMiddleware marks the unversioned copy of the API as deprecated like
+// DeprecatedMiddleware, with each route pointing at the same path under prefix
+func UnversionedMiddleware(prefix string, deprecatedAt, sunset time.Time) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10) // Structured field date
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)              // HTTP date
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)                                           // When the route was deprecated
+		c.Header("Sunset", sunsetDate)                                                 // When the route will be removed
+		c.Header("Link", "<"+prefix+c.Request.URL.Path+">; rel=\"successor-version\"") // Versioned route
+		c.Next()                                                                       // Proceed to the next handler
+	}
+}
+
+// VersionMiddleware marks the routes of a versioned API, whose responses are wrapped in the
+// {"data": ...} and {"error": {"code": ...}} envelopes
+func VersionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apierror.EnvelopeKey, true) // Render envelopes
+		c.Next()                          // Proceed to the next handler
+	}
+}
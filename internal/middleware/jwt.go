@@ -1,31 +1,87 @@
 package middleware
 
 import (
-	"net/http"                     // HTTP status codes
-	"strings"                      // String manipulation
-	"wallet_system/internal/utils" // JWT utility functions
+	"context"                           // Context for Redis operations
+	"strings"                           // String manipulation
+	"time"                              // Time durations
+	"wallet_system/internal/apierror"   // Error responses
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/repository" // User lookups
+	"wallet_system/internal/utils"      // JWT utility functions
 
-	"github.com/gin-gonic/gin" // Gin web framework
+	"github.com/gin-gonic/gin"     // Gin web framework
+	"github.com/redis/go-redis/v9" // Redis client
 )
 
-// JWTAuthMiddleware validates JWT tokens and extracts user information
-func JWTAuthMiddleware(secret string) gin.HandlerFunc {
+// userStateCacheTTL bounds how long a role or status change can take to reach in-flight tokens
+const userStateCacheTTL = 60 * time.Second
+
+// userState is the part of a user record checked on every authenticated request
+type userState struct {
+	Role   string `json:"role"`   // Current role
+	Status string `json:"status"` // Current account status
+}
+
+// loadUserState returns the user's role and status from cache, falling back to the database
+func loadUserState(ctx context.Context, users repository.UserRepository, rdb *redis.Client, userID uint) (*userState, error) {
+	cacheKey := utils.UserStateCacheKey(userID) // Cache key for the user's state
+	var state userState                         // State to return
+	// Try to get the state from cache
+	if found, err := utils.GetCache(ctx, rdb, cacheKey, &state); err == nil && found {
+		return &state, nil // Return cached state
+	}
+	user, err := users.FindByID(ctx, userID) // Fetch user from database
+	if err != nil {
+		return nil, err // Return error if user not found
+	}
+	state = userState{Role: user.Role, Status: user.Status}          // Build state
+	_ = utils.SetCache(ctx, rdb, cacheKey, state, userStateCacheTTL) // Cache the state
+	return &state, nil
+}
+
+// JWTAuthMiddleware validates JWT access tokens, rejects revoked ones and closed accounts,
+// and extracts user information
+func JWTAuthMiddleware(secret string, users repository.UserRepository, rdb *redis.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization") // Get Authorization header
 		// Check if the Authorization header is present and properly formatted
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
 			// If not, abort with unauthorized status
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid Authorization header"})
+			apierror.Abort(c, apierror.Unauthenticated, "Missing or invalid Authorization header")
 			return
 		}
-		tokenStr := strings.TrimPrefix(authHeader, "Bearer ") // Extract the token string and parse it
-		claims, err := utils.ParseJWT(tokenStr, secret)       // Parse the JWT token
+		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")                          // Extract the token string and parse it
+		claims, err := utils.ParseTokenOfType(tokenStr, utils.TokenTypeAccess, secret) // Parse the JWT access token
 		if err != nil {
 			// If parsing fails, abort with unauthorized status
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			apierror.Abort(c, apierror.Unauthenticated, "Invalid or expired token")
+			return
+		}
+		// Reject tokens revoked by logout or by an admin
+		revoked, err := utils.IsTokenRevoked(context.Background(), rdb, claims)
+		if err != nil {
+			// If the session store is down we cannot tell whether the token is revoked
+			apierror.Abort(c, apierror.ServiceUnavailable, "Session store unavailable")
+			return
+		}
+		if revoked {
+			// If revoked, abort with unauthorized status
+			apierror.Abort(c, apierror.Unauthenticated, "Invalid or expired token")
+			return
+		}
+		// Reject users whose account no longer exists or has been closed
+		state, err := loadUserState(c.Request.Context(), users, rdb, claims.UserID)
+		if err != nil {
+			apierror.Abort(c, apierror.Unauthenticated, "Invalid or expired token")
+			return
+		}
+		if state.Status == domain.StatusClosed {
+			apierror.Abort(c, apierror.Unauthenticated, "Account closed")
 			return
 		}
-		c.Set("userID", claims.UserID) // Store userID in context
-		c.Next()                       // Proceed to the next handler
+		c.Set("userID", claims.UserID)    // Store userID in context
+		c.Set("userStatus", state.Status) // Store account status in context
+		c.Set("claims", claims)           // Store token claims in context
+		c.Next()                          // Proceed to the next handler
 	}
 }
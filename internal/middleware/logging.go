@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"crypto/rand"                     // Request ID generation
+	"encoding/hex"                    // Request ID encoding
+	"fmt"                             // Panic formatting
+	"regexp"                          // Request ID validation
+	"runtime/debug"                   // Panic stacks
+	"time"                            // Request latency
+	"wallet_system/internal/apierror" // Error responses
+	"wallet_system/internal/logging"  // Request-scoped logging
+
+	"github.com/gin-gonic/gin"   // Gin web framework
+	"github.com/sirupsen/logrus" // Logging library
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits caller-supplied IDs to short tokens, so they cannot inject text into logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// newRequestID returns a random 128-bit ID in hex
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RequestIDMiddleware gives every request an ID, taken from X-Request-ID when the caller sent
+// a valid one so it can be followed across services. The ID is returned in the response header,
+// stored in the request context for logrus.WithContext, and attached to the request logger.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader) // Caller's ID
+		if !validRequestID.MatchString(id) {
+			id = newRequestID() // Missing or unsafe, make one
+		}
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)                            // Echo to the caller
+		logging.SetLogger(c, logrus.WithField("request_id", id)) // Per-request logger
+		c.Next()                                                 // Proceed to the next handler
+	}
+}
+
+// AccessLogMiddleware logs one line per request through logrus, replacing Gin's own logger.
+// Only the path is logged, not the query string, and failures are logged at a higher level.
+// Successful requests to the quiet routes, such as health probes, are logged at debug level.
+func AccessLogMiddleware(quiet ...string) gin.HandlerFunc {
+	quietRoutes := make(map[string]bool, len(quiet)) // Routes logged at debug level
+	for _, r := range quiet {
+		quietRoutes[r] = true
+	}
+	return func(c *gin.Context) {
+		start := time.Now() // Request start
+		c.Next()
+		status := c.Writer.Status() // Response status
+		fields := logrus.Fields{
+			"method":     c.Request.Method,                                 // HTTP method
+			"path":       c.Request.URL.Path,                               // Path without the query
+			"route":      c.FullPath(),                                     // Route template
+			"status":     status,                                           // Response status
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000, // Handling time
+			"client_ip":  c.ClientIP(),                                     // Caller address
+			"bytes":      c.Writer.Size(),                                  // Response size
+		}
+		if userID, ok := c.Get("userID"); ok {
+			fields["user_id"] = userID // Authenticated caller
+		}
+		entry := logging.Logger(c).WithFields(fields)
+		switch {
+		case status >= 500:
+			entry.Error("Request failed")
+		case status >= 400:
+			entry.Warn("Request rejected")
+		case quietRoutes[c.FullPath()]:
+			entry.Debug("Request handled")
+		default:
+			entry.Info("Request handled")
+		}
+	}
+}
+
+// RecoveryMiddleware turns a panic into a 500 response and logs it, with the stack, through
+// the request logger instead of Gin's own writer
+func RecoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		logging.Logger(c).WithFields(logrus.Fields{
+			"panic": fmt.Sprint(recovered), // Panic value
+			"stack": string(debug.Stack()), // Where it happened
+		}).Error("Panic recovered")
+		apierror.Abort(c, apierror.Internal, "Internal server error")
+	})
+}
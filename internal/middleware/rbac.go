@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"wallet_system/internal/apierror"   // Error responses
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/repository" // User lookups
+	"wallet_system/internal/utils"      // Utility functions
+
+	"github.com/gin-gonic/gin"     // Gin web framework
+	"github.com/redis/go-redis/v9" // Redis client
+)
+
+// RequirePermission allows the request only if the token's role grants every listed permission.
+// The role in the token is checked against the (cached) database role so that demotions
+// take effect without waiting for the token to expire. It must run after JWTAuthMiddleware.
+func RequirePermission(users repository.UserRepository, rdb *redis.Client, perms ...domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("claims") // Get token claims from context
+		// Check if claims exist in context
+		if !exists {
+			// If not, abort with unauthorized status
+			apierror.Abort(c, apierror.Unauthenticated, "Unauthorized")
+			return
+		}
+		claims := value.(*utils.Claims) // Token claims set by JWTAuthMiddleware
+		state, err := loadUserState(c.Request.Context(), users, rdb, claims.UserID)
+		if err != nil {
+			// If user not found or any error, abort with unauthorized status
+			apierror.Abort(c, apierror.Unauthenticated, "Unauthorized")
+			return
+		}
+		role := state.Role // Current role from the database
+		// Reject tokens issued before a role change
+		if role != claims.Role {
+			apierror.Abort(c, apierror.Unauthenticated, "Role changed, please log in again")
+			return
+		}
+		// Check that the role grants every required permission
+		for _, perm := range perms {
+			if !domain.HasPermission(role, perm) {
+				// If any permission is missing, abort with forbidden status
+				apierror.Abort(c, apierror.PermissionDenied, "Missing permission: "+string(perm))
+				return
+			}
+		}
+		c.Set("role", role) // Store role in context
+		c.Next()            // Proceed to the next handler
+	}
+}
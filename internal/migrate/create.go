@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"fmt"           // File contents
+	"os"            // Writing files
+	"path/filepath" // File paths
+	"regexp"        // Name validation
+	"time"          // Version timestamps
+)
+
+// validName matches migration names: lowercase words separated by underscores
+var validName = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+// goTemplate is the skeleton of a Go migration
+const goTemplate = `package migrations
+
+import (
+	"wallet_system/internal/migrate" // Migration framework
+
+	"gorm.io/gorm" // GORM ORM library
+)
+
+// %[2]s
+func init() {
+	register(migrate.Migration{
+		Version: %[1]d,
+		Name:    %[2]q,
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`
+
+// Create writes empty migration files for name, versioned with the current UTC time, and returns
+// their paths. SQL migrations get an up and a down file in dir/<dialect> for each dialect, so every
+// database gets the change; Go migrations get one file in dir.
+func Create(dir, name string, goMigration bool, dialects ...string) ([]string, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid migration name %q; use lowercase words separated by underscores", name)
+	}
+	version := time.Now().UTC().Format("20060102150405") // Sortable timestamp
+	files := map[string]string{}                         // Contents by path
+	for _, dialect := range dialects {
+		base := filepath.Join(dir, dialect, version+"_"+name) // Path without extension
+		files[base+".up.sql"] = "-- " + name + "\n"
+		files[base+".down.sql"] = "-- Revert " + name + "\n"
+	}
+	if goMigration {
+		var v int64
+		fmt.Sscan(version, &v)
+		files = map[string]string{filepath.Join(dir, version+"_"+name+".go"): fmt.Sprintf(goTemplate, v, name)}
+	}
+	var paths []string // Created files
+	for p, body := range files {
+		// O_EXCL refuses to overwrite an existing migration
+		f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return paths, err
+		}
+		_, err = f.WriteString(body)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
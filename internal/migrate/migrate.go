@@ -0,0 +1,431 @@
+package migrate
+
+import (
+	"context"  // Request-scoped checks
+	"errors"   // Error values
+	"fmt"      // Error formatting
+	"hash/fnv" // Advisory lock key
+	"io/fs"    // Embedded migration files
+	"path"     // File name handling
+	"regexp"   // File name parsing
+	"sort"     // Ordering by version
+	"strconv"  // Version parsing
+	"strings"  // SQL splitting
+	"time"     // Timestamps
+
+	"gorm.io/gorm" // GORM ORM library
+)
+
+// Errors returned by the migrator
+var (
+	ErrDirty        = errors.New("a migration failed part way; fix the schema by hand, then run force")
+	ErrIrreversible = errors.New("migration cannot be rolled back")
+	ErrUnknown      = errors.New("unknown migration version")
+	ErrLocked       = errors.New("another instance is migrating")
+	ErrPending      = errors.New("migrations are pending")
+)
+
+// lockName identifies the migration lock on the database server
+const lockName = "wallet_system_migrate"
+
+// lockTimeout is how long to wait for another instance to finish migrating
+const lockTimeout = 60 * time.Second
+
+// lockPoll is how often PostgreSQL is asked for the lock while another instance holds it
+const lockPoll = 500 * time.Millisecond
+
+// advisoryLockKey returns the PostgreSQL advisory lock key derived from lockName
+func advisoryLockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(lockName))
+	return int64(h.Sum64())
+}
+
+// Migration is one versioned schema or data change. SQL migrations are loaded from files;
+// Go migrations set Up and Down directly for changes that SQL alone cannot express.
+type Migration struct {
+	Version int64                // Ordering key, a UTC timestamp such as 20261018093000
+	Name    string               // Short description, from the file name
+	Up      func(*gorm.DB) error // Applies the change
+	Down    func(*gorm.DB) error // Reverts the change, nil if it cannot be reverted
+}
+
+// SchemaMigration Model. One row per applied migration.
+type SchemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"` // Migration version
+	Name      string `gorm:"size:255"`                       // Migration name
+	Dirty     bool   `gorm:"not null;default:false"`         // Set while running; left set if the migration failed
+	AppliedAt int64  `gorm:"autoCreateTime:milli"`           // Timestamp of application in milliseconds
+}
+
+// TableName keeps the conventional table name
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// fileName matches <version>_<name>.up.sql and <version>_<name>.down.sql
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// splitStatements splits a SQL file into statements. Statements end with a semicolon at the
+// end of a line, so semicolons inside string literals are safe as long as they are not last.
+func splitStatements(sql string) []string {
+	var stmts []string    // Complete statements
+	var b strings.Builder // Statement being read
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue // Blank lines and comments
+		}
+		b.WriteString(line + "\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(b.String()))
+			b.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(b.String()); rest != "" {
+		stmts = append(stmts, rest) // Last statement without a semicolon
+	}
+	return stmts
+}
+
+// execSQL returns a migration step that runs the statements of a SQL file in order
+func execSQL(sql string) func(*gorm.DB) error {
+	stmts := splitStatements(sql) // Split once at load time
+	return func(tx *gorm.DB) error {
+		for _, stmt := range stmts {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Load reads the SQL migrations in dir of fsys and merges them with the Go migrations,
+// returning them ordered by version. Versions must be unique across both.
+func Load(fsys fs.FS, dir string, goMigrations []Migration) ([]Migration, error) {
+	byVersion := map[int64]*Migration{} // Migrations by version
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue // Not a migration file
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		body, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = execSQL(string(body))
+		} else {
+			mig.Down = execSQL(string(body))
+		}
+	}
+	for i := range goMigrations {
+		g := goMigrations[i]
+		if _, dup := byVersion[g.Version]; dup {
+			return nil, fmt.Errorf("migration %d is defined twice", g.Version)
+		}
+		byVersion[g.Version] = &g
+	}
+	migrations := make([]Migration, 0, len(byVersion)) // Ordered result
+	for _, mig := range byVersion {
+		if mig.Up == nil {
+			return nil, fmt.Errorf("migration %d_%s has no up step", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Status describes one migration known to the binary or recorded in the database
+type Status struct {
+	Version   int64      // Migration version
+	Name      string     // Migration name
+	Applied   bool       // Recorded in schema_migrations
+	Dirty     bool       // Failed part way
+	AppliedAt *time.Time // When it was applied
+	Missing   bool       // Applied but not known to this binary
+}
+
+// Migrator applies and reverts migrations, holding a database lock while it works
+type Migrator struct {
+	db         *gorm.DB    // Database connection pool
+	migrations []Migration // Known migrations, ordered by version
+}
+
+// New returns a Migrator for the given migrations, as returned by Load
+func New(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// withLock runs fn on a single connection while holding the migration lock, so two instances
+// can never migrate at the same time. The lock is released when the connection closes at the
+// latest, so a crashed migrator cannot leave it held. MySQL uses a named lock and PostgreSQL a
+// session advisory lock; SQLite databases are local files, where the dirty flag and the
+// version primary key already stop a second migrator, so they run on the pool as it is.
+func (m *Migrator) withLock(fn func(conn *gorm.DB) error) error {
+	if m.db.Dialector.Name() == "sqlite" {
+		if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+			return err
+		}
+		return fn(m.db) // A held connection would starve a single-connection pool
+	}
+	return m.db.Connection(func(conn *gorm.DB) error {
+		switch conn.Dialector.Name() {
+		case "mysql":
+			var got *int // 1 if acquired, 0 on timeout
+			if err := conn.Raw("SELECT GET_LOCK(?, ?)", lockName, int(lockTimeout.Seconds())).Scan(&got).Error; err != nil {
+				return err
+			}
+			if got == nil || *got != 1 {
+				return ErrLocked
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", lockName)
+		case "postgres":
+			key := advisoryLockKey()                // Lock shared by every instance
+			deadline := time.Now().Add(lockTimeout) // Give up after this
+			for {
+				var got bool // Whether the lock was free
+				if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&got).Error; err != nil {
+					return err
+				}
+				if got {
+					break
+				}
+				if time.Now().After(deadline) {
+					return ErrLocked
+				}
+				time.Sleep(lockPoll)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", key)
+		}
+		if err := conn.AutoMigrate(&SchemaMigration{}); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+// applied returns the recorded migrations by version
+func applied(conn *gorm.DB) (map[int64]SchemaMigration, error) {
+	var rows []SchemaMigration // Recorded migrations
+	if err := conn.Order("version asc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]SchemaMigration, len(rows))
+	for _, r := range rows {
+		if r.Dirty {
+			return nil, fmt.Errorf("%w (version %d)", ErrDirty, r.Version)
+		}
+		byVersion[r.Version] = r
+	}
+	return byVersion, nil
+}
+
+// up applies one migration. The version is recorded as dirty first, so that if the migration
+// fails after a statement that cannot be rolled back (DDL on MySQL commits implicitly) the
+// schema is known to be in between versions.
+func up(conn *gorm.DB, mig Migration) error {
+	if err := conn.Create(&SchemaMigration{Version: mig.Version, Name: mig.Name, Dirty: true}).Error; err != nil {
+		return err
+	}
+	if err := conn.Transaction(func(tx *gorm.DB) error { return mig.Up(tx) }); err != nil {
+		return fmt.Errorf("migration %d_%s failed: %w", mig.Version, mig.Name, err)
+	}
+	return conn.Model(&SchemaMigration{}).Where("version = ?", mig.Version).Update("dirty", false).Error
+}
+
+// down reverts one migration, marking it dirty while it runs
+func down(conn *gorm.DB, mig Migration) error {
+	if mig.Down == nil {
+		return fmt.Errorf("%w: %d_%s", ErrIrreversible, mig.Version, mig.Name)
+	}
+	if err := conn.Model(&SchemaMigration{}).Where("version = ?", mig.Version).Update("dirty", true).Error; err != nil {
+		return err
+	}
+	if err := conn.Transaction(func(tx *gorm.DB) error { return mig.Down(tx) }); err != nil {
+		return fmt.Errorf("rollback of %d_%s failed: %w", mig.Version, mig.Name, err)
+	}
+	return conn.Where("version = ?", mig.Version).Delete(&SchemaMigration{}).Error
+}
+
+// Up applies every pending migration in version order and returns the ones applied
+func (m *Migrator) Up() ([]Migration, error) {
+	return m.Goto(m.latest())
+}
+
+// latest returns the newest known version, zero if there are none
+func (m *Migrator) latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Down reverts the newest steps applied migrations and returns the ones reverted
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var done []Migration // Reverted migrations
+	err := m.withLock(func(conn *gorm.DB) error {
+		recorded, err := applied(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := recorded[mig.Version]; !ok {
+				continue // Not applied
+			}
+			if err := down(conn, mig); err != nil {
+				return err
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Goto migrates to exactly the given version: newer applied migrations are reverted, newest
+// first, then older pending ones are applied in order. Version zero reverts everything.
+func (m *Migrator) Goto(version int64) ([]Migration, error) {
+	if version != 0 && !m.known(version) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknown, version)
+	}
+	var done []Migration // Migrations applied or reverted
+	err := m.withLock(func(conn *gorm.DB) error {
+		recorded, err := applied(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := recorded[mig.Version]; ok && mig.Version > version {
+				if err := down(conn, mig); err != nil {
+					return err
+				}
+				done = append(done, mig)
+			}
+		}
+		for _, mig := range m.migrations {
+			if _, ok := recorded[mig.Version]; !ok && mig.Version <= version {
+				if err := up(conn, mig); err != nil {
+					return err
+				}
+				done = append(done, mig)
+			}
+		}
+		return nil
+	})
+	return done, err
+}
+
+// known reports whether version is one of the migrations
+func (m *Migrator) known(version int64) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Force records the schema as being at exactly the given version without running anything,
+// clearing a dirty flag left by a failed migration once the schema has been fixed by hand
+func (m *Migrator) Force(version int64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("%w: %d", ErrUnknown, version)
+	}
+	return m.withLock(func(conn *gorm.DB) error {
+		return conn.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("1 = 1").Delete(&SchemaMigration{}).Error; err != nil {
+				return err
+			}
+			for _, mig := range m.migrations {
+				if mig.Version > version {
+					break
+				}
+				if err := tx.Create(&SchemaMigration{Version: mig.Version, Name: mig.Name}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// Check reports whether the database schema matches this binary, without taking the lock or
+// creating anything: ErrDirty if a migration failed part way, ErrPending if a known migration
+// has not been applied. A missing schema_migrations table is returned as a query error.
+func (m *Migrator) Check(ctx context.Context) error {
+	var rows []SchemaMigration // Recorded migrations
+	if err := m.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return err
+	}
+	recorded := make(map[int64]bool, len(rows)) // Applied versions
+	for _, r := range rows {
+		if r.Dirty {
+			return fmt.Errorf("%w (version %d)", ErrDirty, r.Version)
+		}
+		recorded[r.Version] = true
+	}
+	pending := 0 // Known but not applied
+	for _, mig := range m.migrations {
+		if !recorded[mig.Version] {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: %d", ErrPending, pending)
+	}
+	return nil
+}
+
+// Status lists every known migration with whether it has been applied, followed by any
+// applied migrations this binary does not know about
+func (m *Migrator) Status() ([]Status, error) {
+	var statuses []Status // Result
+	// Reading needs no lock; a migration in progress shows up as dirty
+	err := m.db.Connection(func(conn *gorm.DB) error {
+		if err := conn.AutoMigrate(&SchemaMigration{}); err != nil {
+			return err
+		}
+		var rows []SchemaMigration // Recorded migrations
+		if err := conn.Order("version asc").Find(&rows).Error; err != nil {
+			return err
+		}
+		recorded := make(map[int64]SchemaMigration, len(rows))
+		for _, r := range rows {
+			recorded[r.Version] = r
+		}
+		for _, mig := range m.migrations {
+			s := Status{Version: mig.Version, Name: mig.Name}
+			if r, ok := recorded[mig.Version]; ok {
+				at := time.UnixMilli(r.AppliedAt)
+				s.Applied, s.Dirty, s.AppliedAt = true, r.Dirty, &at
+				delete(recorded, mig.Version)
+			}
+			statuses = append(statuses, s)
+		}
+		for _, r := range rows {
+			if _, unknown := recorded[r.Version]; unknown {
+				at := time.UnixMilli(r.AppliedAt)
+				statuses = append(statuses, Status{Version: r.Version, Name: r.Name, Applied: true, Dirty: r.Dirty, AppliedAt: &at, Missing: true})
+			}
+		}
+		return nil
+	})
+	return statuses, err
+}
@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"                        // Redis call deadlines
+	"fmt"                            // Window entry names
+	"math/rand/v2"                   // Unique window entries
+	"sync"                           // Local fallback state
+	"sync/atomic"                    // Redis outage state
+	"time"                           // Windows and clocks
+	"wallet_system/internal/metrics" // Fallback counter
+
+	"github.com/redis/go-redis/v9" // Redis client
+	"github.com/sirupsen/logrus"   // Outage logging
+)
+
+const (
+	redisTimeout = 200 * time.Millisecond // Longest a request waits on Redis before falling back
+	retryRedis   = 5 * time.Second        // How long Redis is skipped after a failure
+)
+
+// slidingWindow keeps the timestamps of the requests in the window in a sorted set, so the
+// check and the insert are atomic across every instance sharing Redis.
+// KEYS[1] = set, ARGV = now in ms, window in ms, limit, unique entry name.
+// Returns allowed (0 or 1), remaining requests and milliseconds until a slot frees up.
+var slidingWindow = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+local reset = window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, limit - count, reset}
+`)
+
+// Result is the outcome of one request against a limit
+type Result struct {
+	Allowed   bool          // Whether the request may proceed
+	Limit     int           // Requests allowed per window
+	Remaining int           // Requests left in the current window
+	Reset     time.Duration // Time until the oldest request leaves the window
+}
+
+// Limiter counts requests per key over a sliding window in Redis. When Redis fails it falls
+// back to counting in memory, so limits still hold per instance instead of failing open or
+// rejecting every request.
+type Limiter struct {
+	rdb       *redis.Client // Shared counters
+	downUntil atomic.Int64  // Unix nanoseconds until which Redis is skipped
+	local     *memoryWindow // Fallback counters
+}
+
+// New returns a limiter storing its windows in rdb
+func New(rdb *redis.Client) *Limiter {
+	return &Limiter{rdb: rdb, local: newMemoryWindow()}
+}
+
+// Allow records a request for key and reports whether it fits in limit requests per window
+func (l *Limiter) Allow(ctx context.Context, key string, limit int, window time.Duration) Result {
+	now := time.Now()
+	if now.UnixNano() >= l.downUntil.Load() {
+		res, err := l.allowRedis(ctx, key, limit, window, now)
+		if err == nil {
+			if l.downUntil.Swap(0) != 0 {
+				logrus.WithContext(ctx).Info("Rate limiter: Redis is back, limits are shared again")
+			}
+			return res
+		}
+		if ctx.Err() != nil {
+			return Result{Allowed: true, Limit: limit, Remaining: limit, Reset: window} // The client went away
+		}
+		if l.downUntil.Swap(now.Add(retryRedis).UnixNano()) == 0 {
+			logrus.WithContext(ctx).WithError(err).Warn("Rate limiter: Redis unavailable, limiting per instance")
+		}
+	}
+	metrics.RateLimitFallback()
+	return l.local.allow(key, limit, window, now)
+}
+
+// allowRedis runs the sliding window script
+func (l *Limiter) allowRedis(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	entry := fmt.Sprintf("%d-%x", now.UnixNano(), rand.Uint64()) // Unique even for simultaneous requests
+	values, err := slidingWindow.Run(ctx, l.rdb, []string{key}, now.UnixMilli(), window.Milliseconds(), limit, entry).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 3 {
+		return Result{}, fmt.Errorf("rate limit script returned %d values", len(values))
+	}
+	return Result{
+		Allowed:   values[0] == 1,
+		Limit:     limit,
+		Remaining: int(values[1]),
+		Reset:     time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+// memoryWindow is the in-process sliding window used while Redis is unavailable
+type memoryWindow struct {
+	mu        sync.Mutex              // Guards the fields below
+	windows   map[string]*localWindow // Window per key
+	lastSweep time.Time               // When expired windows were last dropped
+}
+
+// localWindow holds the request times of one key, oldest first
+type localWindow struct {
+	hits   []time.Time   // Requests in the window
+	window time.Duration // Window length, to know when the key can be dropped
+}
+
+// sweepInterval is how often keys without recent requests are dropped from memory
+const sweepInterval = time.Minute
+
+// newMemoryWindow returns an empty in-process window
+func newMemoryWindow() *memoryWindow {
+	return &memoryWindow{windows: make(map[string]*localWindow)}
+}
+
+// allow applies the same sliding window as the Redis script
+func (m *memoryWindow) allow(key string, limit int, window time.Duration, now time.Time) Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		for k, w := range m.windows {
+			if len(w.hits) == 0 || now.Sub(w.hits[len(w.hits)-1]) >= w.window {
+				delete(m.windows, k)
+			}
+		}
+		m.lastSweep = now
+	}
+	w, ok := m.windows[key]
+	if !ok {
+		w = &localWindow{}
+		m.windows[key] = w
+	}
+	w.window = window
+	start := 0 // First request still inside the window
+	for start < len(w.hits) && now.Sub(w.hits[start]) >= window {
+		start++
+	}
+	w.hits = w.hits[start:]
+	res := Result{Limit: limit, Reset: window}
+	if len(w.hits) < limit {
+		w.hits = append(w.hits, now)
+		res.Allowed = true
+	}
+	res.Remaining = limit - len(w.hits)
+	res.Reset = w.hits[0].Add(window).Sub(now)
+	return res
+}
@@ -0,0 +1,276 @@
+package reconcile
+
+import (
+	"context"                       // Cancellation of the background job
+	"database/sql"                  // Transaction isolation options
+	"errors"                        // Error values
+	"math"                          // Rounding to cents
+	"strconv"                       // String conversion
+	"time"                          // Check interval
+	"wallet_system/internal/audit"  // Audit trail for repairs
+	"wallet_system/internal/domain" // Importing domain models
+	"wallet_system/internal/ledger" // Wallet locks and ledger sums
+
+	"github.com/sirupsen/logrus" // Logging library
+	"gorm.io/gorm"               // GORM ORM library
+)
+
+// ErrSystemWallet is returned when asked to repair a system account, which has no counterparty to book against
+var ErrSystemWallet = errors.New("system wallets must be reconciled manually")
+
+// Discrepancy is a wallet whose stored balance does not match the sum of its transactions
+type Discrepancy struct {
+	WalletID   uint    `json:"wallet_id"`   // Wallet
+	UserID     uint    `json:"user_id"`     // Owner, zero if the wallet does not exist
+	IsSystem   bool    `json:"is_system"`   // System account
+	Missing    bool    `json:"missing"`     // Transactions reference a wallet that does not exist
+	Balance    float64 `json:"balance"`     // Wallet.Balance as stored
+	Computed   float64 `json:"computed"`    // Credits minus debits over every transaction
+	Difference float64 `json:"difference"`  // Balance minus Computed
+	LastPosted *uint   `json:"last_posted"` // Latest transaction touching the wallet, if any
+}
+
+// Report is the outcome of a consistency check
+type Report struct {
+	CheckedAt     time.Time     `json:"checked_at"`     // When the check ran
+	Wallets       int           `json:"wallets"`        // Wallets checked
+	Transactions  int64         `json:"transactions"`   // Transactions summed
+	Discrepancies []Discrepancy `json:"discrepancies"`  // Wallets out of balance
+	TotalBalances float64       `json:"total_balances"` // Sum of every wallet balance, system accounts included
+	Inflows       float64       `json:"inflows"`        // Money that entered the system (postings without a sender)
+	Outflows      float64       `json:"outflows"`       // Money that left the system (postings without a receiver)
+	InvariantOK   bool          `json:"invariant_ok"`   // TotalBalances equals Inflows minus Outflows
+}
+
+// Consistent reports whether every wallet balances and the global invariant holds
+func (r *Report) Consistent() bool {
+	return len(r.Discrepancies) == 0 && r.InvariantOK
+}
+
+// cents rounds an amount to whole cents so float noise is not reported as a discrepancy
+func cents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+// walletSum is a per-wallet total of one side of the ledger
+type walletSum struct {
+	WalletID uint    // Wallet
+	Total    float64 // Sum of amounts
+	LastID   uint    // Latest transaction on this side
+}
+
+// sums totals the amounts posted to one side of every wallet
+func sums(tx *gorm.DB, column string) (map[uint]walletSum, error) {
+	var rows []walletSum // One row per wallet
+	if err := tx.Model(&domain.Transaction{}).
+		Select(column + " AS wallet_id, SUM(amount) AS total, MAX(id) AS last_id").
+		Where(column + " IS NOT NULL").Group(column).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	byWallet := make(map[uint]walletSum, len(rows)) // Totals by wallet ID
+	for _, r := range rows {
+		byWallet[r.WalletID] = r
+	}
+	return byWallet, nil
+}
+
+// Check recomputes every wallet's balance from its transactions and compares it with the stored
+// balance, and checks that the money held by all wallets equals the money that entered the
+// system minus the money that left it. Everything is read from one snapshot, so postings made
+// while the check runs cannot show up as discrepancies.
+func Check(db *gorm.DB) (*Report, error) {
+	report := &Report{CheckedAt: time.Now().UTC(), Discrepancies: []Discrepancy{}}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		credits, err := sums(tx, "to_wallet_id") // Money received per wallet
+		if err != nil {
+			return err
+		}
+		debits, err := sums(tx, "from_wallet_id") // Money sent per wallet
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Transaction{}).Count(&report.Transactions).Error; err != nil {
+			return err
+		}
+		// Money crosses the system boundary only through postings with a missing side
+		if err := tx.Model(&domain.Transaction{}).Select("COALESCE(SUM(amount), 0)").
+			Where("from_wallet_id IS NULL").Scan(&report.Inflows).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Transaction{}).Select("COALESCE(SUM(amount), 0)").
+			Where("to_wallet_id IS NULL").Scan(&report.Outflows).Error; err != nil {
+			return err
+		}
+		seen := map[uint]bool{} // Wallets that exist
+		// compare records a discrepancy if a wallet's balance differs from its ledger
+		compare := func(id uint, d Discrepancy) {
+			d.WalletID = id
+			d.Computed = credits[id].Total - debits[id].Total
+			d.Difference = d.Balance - d.Computed
+			if cents(d.Difference) == 0 {
+				return
+			}
+			if last := max(credits[id].LastID, debits[id].LastID); last != 0 {
+				d.LastPosted = &last
+			}
+			report.Discrepancies = append(report.Discrepancies, d)
+		}
+		var batch []domain.Wallet // Wallets are processed in batches to bound memory
+		if err := tx.Order("id asc").FindInBatches(&batch, 500, func(_ *gorm.DB, _ int) error {
+			for _, w := range batch {
+				seen[w.ID] = true
+				report.Wallets++
+				report.TotalBalances += w.Balance
+				compare(w.ID, Discrepancy{UserID: w.UserID, IsSystem: w.IsSystem, Balance: w.Balance})
+			}
+			return nil
+		}).Error; err != nil {
+			return err
+		}
+		// Transactions pointing at wallets that no longer exist
+		for _, side := range []map[uint]walletSum{credits, debits} {
+			for id := range side {
+				if !seen[id] {
+					seen[id] = true
+					compare(id, Discrepancy{Missing: true})
+				}
+			}
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	report.InvariantOK = cents(report.TotalBalances) == cents(report.Inflows-report.Outflows)
+	return report, nil
+}
+
+// Repair books a correcting adjustment for a wallet whose balance differs from its ledger. The
+// stored balance is kept, since it is what the customer has been shown, and the difference is
+// posted against the system adjustments account with reason code "reconciliation", so both
+// wallets balance again and finance can investigate the loss or gain there. The discrepancy is
+// recomputed under the wallet locks; nil is returned if it no longer exists.
+func Repair(db *gorm.DB, walletID uint, meta audit.Entry) (*domain.Transaction, error) {
+	var posted *domain.Transaction // Correcting entry
+	err := db.Transaction(func(tx *gorm.DB) error {
+		system, err := ledger.SystemWallet(tx, domain.SystemAccountAdjustments) // Counterparty account
+		if err != nil {
+			return err
+		}
+		wallets, err := ledger.LockWallets(tx, walletID, system.ID) // Block postings while correcting
+		if err != nil {
+			return err
+		}
+		wallet, system := wallets[walletID], wallets[system.ID]
+		if wallet.IsSystem {
+			return ErrSystemWallet
+		}
+		computed, err := ledger.SumBefore(tx, walletID, math.MaxInt64) // Ledger balance under the lock
+		if err != nil {
+			return err
+		}
+		difference := wallet.Balance - computed
+		if cents(difference) == 0 {
+			return nil // Already consistent
+		}
+		amount := math.Abs(difference) // Correction amount
+		systemAfter := system.Balance  // System balance after the correction
+		walletAfter := wallet.Balance  // Unchanged
+		t := domain.Transaction{
+			Amount:     amount,                      // Size of the discrepancy
+			Type:       domain.TxTypeAdjustment,     // Transaction type
+			Status:     domain.TxStatusCompleted,    // Posted
+			ReasonCode: domain.ReasonReconciliation, // Reason code
+			Note:       "Ledger reconciliation: balance " + money(wallet.Balance) + ", ledger " + money(computed),
+		}
+		// The wallet holds more than its ledger explains: book a credit from the system account, and vice versa
+		if difference > 0 {
+			t.FromWalletID, t.ToWalletID = &system.ID, &wallet.ID
+			systemAfter -= amount
+			t.FromBalanceAfter, t.ToBalanceAfter = &systemAfter, &walletAfter
+			err = tx.Model(system).Update("balance", gorm.Expr("balance - ?", amount)).Error
+		} else {
+			t.FromWalletID, t.ToWalletID = &wallet.ID, &system.ID
+			systemAfter += amount
+			t.FromBalanceAfter, t.ToBalanceAfter = &walletAfter, &systemAfter
+			err = tx.Model(system).Update("balance", gorm.Expr("balance + ?", amount)).Error
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&t).Error; err != nil {
+			return err
+		}
+		entry := meta                                // Actor and client details
+		entry.Action = audit.ActionLedgerReconcile   // Action name
+		entry.TargetType = "wallet"                  // Target kind
+		entry.TargetID = strconv.Itoa(int(walletID)) // Target wallet
+		entry.Before = map[string]float64{           // Discrepancy found
+			"balance":  wallet.Balance,
+			"computed": computed,
+		}
+		entry.After = map[string]any{ // Correction booked
+			"transaction_id": t.ID,
+			"amount":         difference,
+		}
+		if err := audit.Record(tx, entry); err != nil {
+			return err
+		}
+		posted = &t
+		return nil
+	})
+	return posted, err
+}
+
+// money formats an amount with two decimals
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// Run checks the ledger every interval until ctx is cancelled, logging every discrepancy.
+// It never repairs anything; use cmd/reconcile for that.
+func Run(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval) // Check interval
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return // Stop when the server shuts down
+		case <-ticker.C:
+			report, err := Check(db)
+			if err != nil {
+				logrus.WithField("error", err.Error()).Error("Failed to check ledger consistency")
+				continue
+			}
+			Log(report)
+		}
+	}
+}
+
+// Log writes a report to the log, one entry per discrepancy
+func Log(report *Report) {
+	for _, d := range report.Discrepancies {
+		logrus.WithFields(logrus.Fields{
+			"wallet_id":  d.WalletID,   // Wallet
+			"user_id":    d.UserID,     // Owner
+			"is_system":  d.IsSystem,   // System account
+			"missing":    d.Missing,    // Wallet does not exist
+			"balance":    d.Balance,    // Stored balance
+			"computed":   d.Computed,   // Ledger balance
+			"difference": d.Difference, // Stored minus ledger
+		}).Error("Wallet balance does not match its transactions")
+	}
+	if !report.InvariantOK {
+		logrus.WithFields(logrus.Fields{
+			"total_balances": report.TotalBalances, // Money held
+			"inflows":        report.Inflows,       // Money in
+			"outflows":       report.Outflows,      // Money out
+		}).Error("Total balances do not equal inflows minus outflows")
+	}
+	if report.Consistent() {
+		logrus.WithFields(logrus.Fields{
+			"wallets":      report.Wallets,      // Wallets checked
+			"transactions": report.Transactions, // Transactions summed
+		}).Info("Ledger is consistent")
+	}
+}
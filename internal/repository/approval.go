@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"                         // Request-scoped cancellation
+	"time"                            // Approval lifetimes
+	"wallet_system/internal/approval" // Maker-checker workflow
+	"wallet_system/internal/audit"    // Audit log
+	"wallet_system/internal/domain"   // Importing domain models
+
+	"gorm.io/gorm" // GORM ORM library
+)
+
+// gormApprovals is the GORM implementation of ApprovalRepository
+type gormApprovals struct {
+	db *gorm.DB // Database connection
+}
+
+// NewApprovalRepository returns an ApprovalRepository backed by db
+func NewApprovalRepository(db *gorm.DB) ApprovalRepository {
+	return &gormApprovals{db: db}
+}
+
+// FindByID returns the pending action with the given ID, whatever its status
+func (r *gormApprovals) FindByID(ctx context.Context, id uint) (*domain.PendingAction, error) {
+	var action domain.PendingAction
+	if err := r.db.WithContext(ctx).First(&action, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &action, nil
+}
+
+// List returns up to limit actions of the given types in the given status, newest first
+func (r *gormApprovals) List(ctx context.Context, status string, types []string, limit int) ([]domain.PendingAction, error) {
+	var actions []domain.PendingAction
+	err := r.db.WithContext(ctx).Where("status = ? AND type IN ?", status, types).
+		Order("id desc").Limit(limit).Find(&actions).Error
+	return actions, err
+}
+
+// Propose records a pending action and its audit entry
+func (r *gormApprovals) Propose(ctx context.Context, p approval.Proposal, meta audit.Entry, ttl time.Duration) (*domain.PendingAction, error) {
+	return approval.Propose(r.db.WithContext(ctx), p, meta, ttl)
+}
+
+// Approve executes a pending action on behalf of a second admin
+func (r *gormApprovals) Approve(ctx context.Context, id uint, role, note string, meta audit.Entry) (*domain.PendingAction, error) {
+	return approval.Approve(r.db.WithContext(ctx), id, role, note, meta)
+}
+
+// Reject closes a pending action without executing it
+func (r *gormApprovals) Reject(ctx context.Context, id uint, role, note string, meta audit.Entry) (*domain.PendingAction, error) {
+	return approval.Reject(r.db.WithContext(ctx), id, role, note, meta)
+}
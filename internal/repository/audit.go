@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"                       // Request-scoped cancellation
+	"wallet_system/internal/audit"  // Audit log
+	"wallet_system/internal/domain" // Importing domain models
+
+	"gorm.io/gorm" // GORM ORM library
+)
+
+// gormAudit is the GORM implementation of AuditRepository
+type gormAudit struct {
+	db *gorm.DB // Database connection
+}
+
+// NewAuditRepository returns an AuditRepository backed by db
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &gormAudit{db: db}
+}
+
+// auditQuery builds a query for the audit records matching f
+func auditQuery(db *gorm.DB, f AuditFilter) *gorm.DB {
+	query := db.Model(&domain.AuditLog{}) // Start building the query
+	if f.ActorID != nil {
+		query = query.Where("actor_id = ?", *f.ActorID) // Filter by actor
+	}
+	if len(f.Actions) > 0 {
+		query = query.Where("action IN ?", f.Actions) // Filter by actions
+	}
+	if f.TargetType != "" {
+		query = query.Where("target_type = ?", f.TargetType) // Filter by target kind
+	}
+	if f.TargetID != "" {
+		query = query.Where("target_id = ?", f.TargetID) // Filter by target ID
+	}
+	if f.From != nil {
+		query = query.Where("created_at >= ?", *f.From) // Filter by start date
+	}
+	if f.To != nil {
+		query = query.Where("created_at <= ?", *f.To) // Filter by end date
+	}
+	return query
+}
+
+// Record appends an entry to the audit chain; inside Store.Atomic it commits with the change it describes
+func (r *gormAudit) Record(ctx context.Context, e audit.Entry) error {
+	return audit.Record(r.db.WithContext(ctx), e)
+}
+
+// List returns one page of the records matching f, newest first, and the total matching
+func (r *gormAudit) List(ctx context.Context, f AuditFilter, offset, limit int) ([]domain.AuditLog, int64, error) {
+	query := auditQuery(r.db.WithContext(ctx), f)
+	var total int64 // Total record count
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var records []domain.AuditLog // Slice to hold records
+	if err := query.Order("id desc").Offset(offset).Limit(limit).Find(&records).Error; err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+// Latest returns up to limit of the newest records matching f
+func (r *gormAudit) Latest(ctx context.Context, f AuditFilter, limit int) ([]domain.AuditLog, error) {
+	var records []domain.AuditLog
+	err := auditQuery(r.db.WithContext(ctx), f).Order("id desc").Limit(limit).Find(&records).Error
+	return records, err
+}
+
+// Each calls fn for every record matching f in chain order. Records are read in batches of
+// exportBatchSize by ID, so no connection is held while fn runs; iteration stops at the first error.
+func (r *gormAudit) Each(ctx context.Context, f AuditFilter, fn func(*domain.AuditLog) error) error {
+	var lastID uint // ID of the last record of the previous batch
+	for {
+		var batch []domain.AuditLog
+		err := auditQuery(r.db.WithContext(ctx), f).Where("id > ?", lastID).
+			Order("id asc").Limit(exportBatchSize).Find(&batch).Error
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := fn(&batch[i]); err != nil {
+				return err
+			}
+		}
+		if len(batch) < exportBatchSize {
+			return nil // No records beyond this batch
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
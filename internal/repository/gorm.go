@@ -0,0 +1,423 @@
+package repository
+
+import (
+	"context"                       // Request-scoped cancellation
+	"errors"                        // Error inspection
+	"strings"                       // String manipulation
+	"wallet_system/internal/domain" // Importing domain models
+	"wallet_system/internal/ledger" // Ledger postings
+
+	"gorm.io/gorm" // GORM ORM library
+)
+
+// exportBatchSize is the number of rows Each reads per query
+const exportBatchSize = 500
+
+// notFound maps GORM's missing-record error to ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// likeEscape is the LIKE escape character. SQLite has no default and MySQL and PostgreSQL
+// disagree on how to write a backslash, so a plain character is named explicitly.
+const likeEscape = "!"
+
+// likePrefix escapes LIKE wildcards and returns a prefix pattern for use with ESCAPE likeEscape
+func likePrefix(s string) string {
+	r := strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_") // Escape wildcards
+	return r.Replace(s) + "%"
+}
+
+// userSortColumns maps UserFilter.Sort to a column
+var userSortColumns = map[string]string{
+	"id":       "users.id",        // Registration order
+	"username": "users.username",  // Alphabetical
+	"balance":  "wallets.balance", // Wallet balance
+}
+
+// gormUsers is the GORM implementation of UserRepository
+type gormUsers struct {
+	db *gorm.DB // Database connection
+}
+
+// NewUserRepository returns a UserRepository backed by db
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &gormUsers{db: db}
+}
+
+// FindByID returns the user with the given ID
+func (r *gormUsers) FindByID(ctx context.Context, id uint) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+// FindByUsername returns the non-system user with the given username
+func (r *gormUsers) FindByUsername(ctx context.Context, username string) (*domain.User, error) {
+	var user domain.User
+	// System accounts are internal and never addressed by username
+	if err := r.db.WithContext(ctx).Where("username = ? AND role <> ?", username, domain.RoleSystem).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+// FindWithWallet returns the user with the given ID and their wallet, if any
+func (r *gormUsers) FindWithWallet(ctx context.Context, id uint) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.WithContext(ctx).Preload("Wallet").First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+// FindManaged returns the non-system user with the given ID and their wallet, if any
+func (r *gormUsers) FindManaged(ctx context.Context, id uint) (*domain.User, error) {
+	var user domain.User
+	// System accounts are internal and never managed by admins
+	if err := r.db.WithContext(ctx).Where("role <> ?", domain.RoleSystem).Preload("Wallet").First(&user, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+// List returns one page of users matching f with their wallets preloaded, and the total matching
+func (r *gormUsers) List(ctx context.Context, f UserFilter, offset, limit int) ([]domain.User, int64, error) {
+	// Join wallets so balances can be filtered and sorted
+	query := r.db.WithContext(ctx).Model(&domain.User{}).Joins("LEFT JOIN wallets ON wallets.user_id = users.id")
+	if f.UsernamePrefix != "" {
+		query = query.Where("LOWER(users.username) LIKE LOWER(?) ESCAPE '"+likeEscape+"'", likePrefix(f.UsernamePrefix)) // Filter by username prefix, ignoring case on every database
+	}
+	if f.Role != "" {
+		query = query.Where("users.role = ?", f.Role) // Filter by role
+	} else {
+		query = query.Where("users.role <> ?", domain.RoleSystem) // Hide system accounts unless asked for
+	}
+	if f.Status != "" {
+		query = query.Where("users.status = ?", f.Status) // Filter by status
+	}
+	if f.MinBalance != nil {
+		query = query.Where("wallets.balance >= ?", *f.MinBalance) // Filter by minimum balance
+	}
+	if f.MaxBalance != nil {
+		query = query.Where("wallets.balance <= ?", *f.MaxBalance) // Filter by maximum balance
+	}
+	var total int64 // Total user count
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	sortColumn, ok := userSortColumns[f.Sort] // Sort column
+	if !ok {
+		sortColumn = userSortColumns["id"]
+	}
+	order := "asc" // Sort direction
+	if f.Desc {
+		order = "desc"
+	}
+	var users []domain.User // Slice to hold users
+	if err := query.Select("users.*").Preload("Wallet").
+		Order(sortColumn + " " + order).Order("users.id " + order).
+		Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// Create stores a new user
+func (r *gormUsers) Create(ctx context.Context, u *domain.User) error {
+	return r.db.WithContext(ctx).Create(u).Error
+}
+
+// SetStatus changes a user's account status and records why
+func (r *gormUsers) SetStatus(ctx context.Context, id uint, status, reason string) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", id).
+		Updates(map[string]any{"status": status, "status_reason": reason}).Error
+}
+
+// SetRole changes a user's role
+func (r *gormUsers) SetRole(ctx context.Context, id uint, role string) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+// SetMustResetPassword sets or clears the flag that blocks login until the password is reset
+func (r *gormUsers) SetMustResetPassword(ctx context.Context, id uint, must bool) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", id).Update("must_reset_password", must).Error
+}
+
+// SetPassword stores a new password hash and clears the reset flag unless the account is closed.
+// It reports whether the password was stored.
+func (r *gormUsers) SetPassword(ctx context.Context, id uint, hash string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ? AND status <> ?", id, domain.StatusClosed).
+		Updates(map[string]any{"password": hash, "must_reset_password": false})
+	return res.RowsAffected > 0, res.Error
+}
+
+// gormWallets is the GORM implementation of WalletRepository
+type gormWallets struct {
+	db *gorm.DB // Database connection
+}
+
+// NewWalletRepository returns a WalletRepository backed by db
+func NewWalletRepository(db *gorm.DB) WalletRepository {
+	return &gormWallets{db: db}
+}
+
+// FindByID returns the wallet with the given ID
+func (r *gormWallets) FindByID(ctx context.Context, id uint) (*domain.Wallet, error) {
+	var wallet domain.Wallet
+	if err := r.db.WithContext(ctx).First(&wallet, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &wallet, nil
+}
+
+// FindByUserID returns the wallet owned by the given user
+func (r *gormWallets) FindByUserID(ctx context.Context, userID uint) (*domain.Wallet, error) {
+	var wallet domain.Wallet
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &wallet, nil
+}
+
+// FindByUsername returns the wallet owned by the user with the given username
+func (r *gormWallets) FindByUsername(ctx context.Context, username string) (*domain.Wallet, error) {
+	var wallet domain.Wallet
+	if err := r.db.WithContext(ctx).Joins("JOIN users ON users.id = wallets.user_id").
+		Where("users.username = ?", username).First(&wallet).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &wallet, nil
+}
+
+// FindSystem returns the wallet of a system account, creating the account on first use
+func (r *gormWallets) FindSystem(ctx context.Context, username string) (*domain.Wallet, error) {
+	return ledger.SystemWallet(r.db.WithContext(ctx), username)
+}
+
+// Lock loads the given wallets with row locks held until the surrounding transaction ends.
+// A missing wallet is reported as ledger.ErrWalletNotFound.
+func (r *gormWallets) Lock(ctx context.Context, ids ...uint) (map[uint]*domain.Wallet, error) {
+	return ledger.LockWallets(r.db.WithContext(ctx), ids...)
+}
+
+// Create stores a new wallet
+func (r *gormWallets) Create(ctx context.Context, w *domain.Wallet) error {
+	return r.db.WithContext(ctx).Create(w).Error
+}
+
+// SetStatus changes a wallet's status and records why
+func (r *gormWallets) SetStatus(ctx context.Context, id uint, status, reason string) error {
+	return r.db.WithContext(ctx).Model(&domain.Wallet{}).Where("id = ?", id).
+		Updates(map[string]any{"status": status, "status_reason": reason}).Error
+}
+
+// gormTransactions is the GORM implementation of TransactionRepository
+type gormTransactions struct {
+	db *gorm.DB // Database connection
+}
+
+// NewTransactionRepository returns a TransactionRepository backed by db
+func NewTransactionRepository(db *gorm.DB) TransactionRepository {
+	return &gormTransactions{db: db}
+}
+
+// transactionQuery builds a query for the transactions matching f. Columns are qualified so
+// the query can be joined with wallets and users.
+func transactionQuery(db *gorm.DB, f TransactionFilter) *gorm.DB {
+	query := db.Model(&domain.Transaction{}) // Start building the query
+	// touches restricts the query to transactions on either side of the given wallets
+	touches := func(wallets any) {
+		query = query.Where("(transactions.from_wallet_id IN (?) OR transactions.to_wallet_id IN (?))", wallets, wallets)
+	}
+	if f.UserID != nil {
+		touches(db.Model(&domain.Wallet{}).Select("id").Where("user_id = ?", *f.UserID)) // Filter by the user's wallet
+	}
+	if f.WalletID != nil {
+		touches([]uint{*f.WalletID}) // Filter by wallet
+	}
+	if f.Username != "" {
+		// Filter by the user's wallet
+		touches(db.Model(&domain.Wallet{}).Select("wallets.id").
+			Joins("JOIN users ON users.id = wallets.user_id").Where("users.username = ?", f.Username))
+	}
+	if len(f.Types) > 0 {
+		query = query.Where("transactions.type IN ?", f.Types) // Filter by transaction types
+	}
+	if len(f.Statuses) > 0 {
+		query = query.Where("transactions.status IN ?", f.Statuses) // Filter by transaction statuses
+	}
+	if f.MinAmount != nil {
+		query = query.Where("transactions.amount >= ?", *f.MinAmount) // Filter by minimum amount
+	}
+	if f.MaxAmount != nil {
+		query = query.Where("transactions.amount <= ?", *f.MaxAmount) // Filter by maximum amount
+	}
+	if f.From != nil {
+		query = query.Where("transactions.created_at >= ?", *f.From) // Filter by start date
+	}
+	if f.To != nil {
+		query = query.Where("transactions.created_at <= ?", *f.To) // Filter by end date
+	}
+	return query
+}
+
+// withOwners selects the usernames of the owners of both wallets alongside each transaction
+func withOwners(query *gorm.DB) *gorm.DB {
+	return query.Select("transactions.*, fu.username AS from_username, tu.username AS to_username").
+		Joins("LEFT JOIN wallets fw ON fw.id = transactions.from_wallet_id").
+		Joins("LEFT JOIN users fu ON fu.id = fw.user_id").
+		Joins("LEFT JOIN wallets tw ON tw.id = transactions.to_wallet_id").
+		Joins("LEFT JOIN users tu ON tu.id = tw.user_id")
+}
+
+// FindByID returns the transaction with the given ID
+func (r *gormTransactions) FindByID(ctx context.Context, id uint) (*domain.Transaction, error) {
+	var tx domain.Transaction
+	if err := r.db.WithContext(ctx).First(&tx, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &tx, nil
+}
+
+// Recent returns the latest transactions on either side of a wallet, newest first
+func (r *gormTransactions) Recent(ctx context.Context, walletID uint, limit int) ([]domain.Transaction, error) {
+	txs := []domain.Transaction{} // Empty rather than nil for wallets without activity
+	err := r.db.WithContext(ctx).Where("from_wallet_id = ? OR to_wallet_id = ?", walletID, walletID).
+		Order("created_at desc").Order("id desc").Limit(limit).Find(&txs).Error
+	return txs, err
+}
+
+// Between returns up to limit transactions on either side of a wallet posted in [fromMs, toMs),
+// oldest first, with the owners of both sides
+func (r *gormTransactions) Between(ctx context.Context, walletID uint, fromMs, toMs int64, limit int) ([]TransactionWithOwners, error) {
+	var txs []TransactionWithOwners
+	err := withOwners(r.db.WithContext(ctx).Model(&domain.Transaction{})).
+		Where("(transactions.from_wallet_id = ? OR transactions.to_wallet_id = ?)", walletID, walletID).
+		Where("transactions.created_at >= ? AND transactions.created_at < ?", fromMs, toMs).
+		Order("transactions.created_at asc").Order("transactions.id asc").
+		Limit(limit).Scan(&txs).Error
+	return txs, err
+}
+
+// Each calls fn for every transaction matching f in chronological order, with the owners of
+// both sides. Rows are read in batches of exportBatchSize positioned after the last row of the
+// previous batch, so no connection is held while fn runs; iteration stops at the first error.
+func (r *gormTransactions) Each(ctx context.Context, f TransactionFilter, fn func(*TransactionWithOwners) error) error {
+	var last *TransactionWithOwners // Last row of the previous batch
+	for {
+		query := withOwners(transactionQuery(r.db.WithContext(ctx), f))
+		if last != nil {
+			query = query.Where("(transactions.created_at > ? OR (transactions.created_at = ? AND transactions.id > ?))",
+				last.CreatedAt, last.CreatedAt, last.ID) // Resume after the previous batch
+		}
+		var batch []TransactionWithOwners
+		err := query.Order("transactions.created_at asc").Order("transactions.id asc").
+			Limit(exportBatchSize).Scan(&batch).Error
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := fn(&batch[i]); err != nil {
+				return err
+			}
+		}
+		if len(batch) < exportBatchSize {
+			return nil // No rows beyond this batch
+		}
+		last = &batch[len(batch)-1]
+	}
+}
+
+// Page fetches one page of the matching transactions ordered by (p.Column, id) and positioned by
+// p.Cursor. Rows are found with a range condition on the sort key instead of OFFSET, so pages stay
+// cheap deep into the table and rows inserted meanwhile never shift the page boundaries.
+func (r *gormTransactions) Page(ctx context.Context, f TransactionFilter, p PageQuery) ([]domain.Transaction, bool, error) {
+	query := transactionQuery(r.db.WithContext(ctx), f)
+	column := "transactions." + p.Column             // Qualified sort column
+	backward := p.Cursor != nil && p.Cursor.Backward // Paging towards the start of the list
+	scanDesc := p.Desc != backward                   // Backward pages scan against the list order
+	op, dir := ">", "asc"                            // Comparison and direction of the scan
+	if scanDesc {
+		op, dir = "<", "desc"
+	}
+	if cur := p.Cursor; cur != nil {
+		// Rows strictly beyond the edge row in scan order
+		query = query.Where("("+column+" "+op+" ? OR ("+column+" = ? AND transactions.id "+op+" ?))", cur.Value, cur.Value, cur.ID)
+	}
+	var txs []domain.Transaction // One extra row tells whether there is more
+	if err := query.Order(column + " " + dir).Order("transactions.id " + dir).Limit(p.Limit + 1).Find(&txs).Error; err != nil {
+		return nil, false, err
+	}
+	more := len(txs) > p.Limit // More rows beyond this page in scan order
+	if more {
+		txs = txs[:p.Limit]
+	}
+	// Backward pages were scanned in reverse; restore list order
+	if backward {
+		for i, j := 0, len(txs)-1; i < j; i, j = i+1, j-1 {
+			txs[i], txs[j] = txs[j], txs[i]
+		}
+	}
+	return txs, more, nil
+}
+
+// Count returns the number of transactions matching f
+func (r *gormTransactions) Count(ctx context.Context, f TransactionFilter) (int64, error) {
+	var total int64
+	err := transactionQuery(r.db.WithContext(ctx), f).Count(&total).Error
+	return total, err
+}
+
+// WalletUsernames returns the owner's username for each of the given wallets
+func (r *gormTransactions) WalletUsernames(ctx context.Context, walletIDs []uint) (map[uint]string, error) {
+	names := map[uint]string{} // Username by wallet ID
+	if len(walletIDs) == 0 {
+		return names, nil
+	}
+	var rows []struct {
+		ID       uint   // Wallet ID
+		Username string // Owner's username
+	}
+	if err := r.db.WithContext(ctx).Table("wallets").Select("wallets.id, users.username").
+		Joins("JOIN users ON users.id = wallets.user_id").
+		Where("wallets.id IN ?", walletIDs).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		names[row.ID] = row.Username
+	}
+	return names, nil
+}
+
+// BalanceBefore returns a wallet's balance as of the given time in milliseconds
+func (r *gormTransactions) BalanceBefore(ctx context.Context, walletID uint, beforeMs int64) (float64, error) {
+	return ledger.BalanceBefore(r.db.WithContext(ctx), walletID, beforeMs)
+}
+
+// Post posts a movement atomically; funds and statuses are checked under the wallet locks
+func (r *gormTransactions) Post(ctx context.Context, p ledger.Posting) (*ledger.Result, error) {
+	var result *ledger.Result // Outcome of the posting
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = ledger.Post(tx, p)
+		return err // Return error to rollback
+	})
+	return result, err
+}
+
+// Reverse posts the opposite of a completed transaction atomically and marks the original as reversed
+func (r *gormTransactions) Reverse(ctx context.Context, id uint) (*ledger.Result, error) {
+	var result *ledger.Result // Outcome of the reversal
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = ledger.Reverse(tx, id)
+		return err // Return error to rollback
+	})
+	return result, notFound(err)
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"                         // Request-scoped cancellation
+	"errors"                          // Error values
+	"time"                            // Approval lifetimes
+	"wallet_system/internal/approval" // Maker-checker workflow
+	"wallet_system/internal/audit"    // Audit log
+	"wallet_system/internal/domain"   // Importing domain models
+	"wallet_system/internal/ledger"   // Ledger postings
+	"wallet_system/internal/utils"    // Keyset cursors
+)
+
+// ErrNotFound is returned when a requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// UserFilter selects users for admin listings. Zero values do not filter.
+type UserFilter struct {
+	UsernamePrefix string   // Username starts with this, already lowercased
+	Role           string   // Exact role; system accounts are hidden unless asked for by role
+	Status         string   // Exact account status
+	MinBalance     *float64 // Wallet balance at least this
+	MaxBalance     *float64 // Wallet balance at most this
+	Sort           string   // id, username or balance
+	Desc           bool     // Sort descending
+}
+
+// TransactionFilter selects transactions. Zero values do not filter.
+type TransactionFilter struct {
+	WalletID  *uint    // Either side is this wallet
+	UserID    *uint    // Either side is this user's wallet
+	Username  string   // Either side is this user's wallet, already lowercased
+	Types     []string // Transaction type is one of these
+	Statuses  []string // Transaction status is one of these
+	MinAmount *float64 // Amount at least this
+	MaxAmount *float64 // Amount at most this
+	From      *int64   // Posted at or after, in milliseconds
+	To        *int64   // Posted at or before, in milliseconds
+}
+
+// AuditFilter selects audit records. Zero values do not filter.
+type AuditFilter struct {
+	ActorID    *uint    // Acting user
+	Actions    []string // Action is one of these
+	TargetType string   // Kind of object acted upon
+	TargetID   string   // ID of the object acted upon
+	From       *int64   // Recorded at or after, in milliseconds
+	To         *int64   // Recorded at or before, in milliseconds
+}
+
+// TransactionWithOwners is a transaction with the usernames of the owners of both wallets
+type TransactionWithOwners struct {
+	domain.Transaction        // Transaction fields
+	FromUsername       string // Owner of the sending wallet, empty for money entering the system
+	ToUsername         string // Owner of the receiving wallet, empty for money leaving the system
+}
+
+// PageQuery positions a keyset page of transactions ordered by (Column, id)
+type PageQuery struct {
+	Column string        // created_at, amount or id
+	Desc   bool          // List order is descending
+	Cursor *utils.Cursor // Edge row of the neighbouring page, nil for the first page
+	Limit  int           // Rows per page
+}
+
+// UserRepository persists users
+type UserRepository interface {
+	FindByID(ctx context.Context, id uint) (*domain.User, error)                             // User by ID
+	FindByUsername(ctx context.Context, username string) (*domain.User, error)               // Non-system user by username
+	FindWithWallet(ctx context.Context, id uint) (*domain.User, error)                       // User by ID with their wallet, if any
+	FindManaged(ctx context.Context, id uint) (*domain.User, error)                          // Non-system user by ID with their wallet, if any
+	List(ctx context.Context, f UserFilter, offset, limit int) ([]domain.User, int64, error) // Users with wallets, and the total matching
+	Create(ctx context.Context, u *domain.User) error                                        // New user
+	SetStatus(ctx context.Context, id uint, status, reason string) error                     // Change the account status
+	SetRole(ctx context.Context, id uint, role string) error                                 // Change the role
+	SetMustResetPassword(ctx context.Context, id uint, must bool) error                      // Set or clear the forced reset flag
+	SetPassword(ctx context.Context, id uint, hash string) (bool, error)                     // Store a new password hash on an open account and clear the reset flag, reporting whether it was stored
+}
+
+// WalletRepository persists wallets
+type WalletRepository interface {
+	FindByID(ctx context.Context, id uint) (*domain.Wallet, error)               // Wallet by ID
+	FindByUserID(ctx context.Context, userID uint) (*domain.Wallet, error)       // Wallet of a user
+	FindByUsername(ctx context.Context, username string) (*domain.Wallet, error) // Wallet of the user with this username
+	FindSystem(ctx context.Context, username string) (*domain.Wallet, error)     // Wallet of a system account, created on first use
+	Lock(ctx context.Context, ids ...uint) (map[uint]*domain.Wallet, error)      // Wallets by ID with row locks; only inside Store.Atomic
+	Create(ctx context.Context, w *domain.Wallet) error                          // New wallet
+	SetStatus(ctx context.Context, id uint, status, reason string) error         // Change the wallet status
+}
+
+// TransactionRepository persists transactions and posts new ones through the ledger
+type TransactionRepository interface {
+	FindByID(ctx context.Context, id uint) (*domain.Transaction, error)                                         // Transaction by ID
+	Recent(ctx context.Context, walletID uint, limit int) ([]domain.Transaction, error)                         // Latest transactions of a wallet, newest first
+	Between(ctx context.Context, walletID uint, fromMs, toMs int64, limit int) ([]TransactionWithOwners, error) // Transactions of a wallet posted in [fromMs, toMs), oldest first
+	Each(ctx context.Context, f TransactionFilter, fn func(*TransactionWithOwners) error) error                 // Every matching transaction, oldest first, until fn fails
+	Page(ctx context.Context, f TransactionFilter, p PageQuery) ([]domain.Transaction, bool, error)             // One page in list order, and whether more rows lie beyond it in scan order
+	Count(ctx context.Context, f TransactionFilter) (int64, error)                                              // Number of matching transactions
+	WalletUsernames(ctx context.Context, walletIDs []uint) (map[uint]string, error)                             // Owner's username by wallet ID
+	BalanceBefore(ctx context.Context, walletID uint, beforeMs int64) (float64, error)                          // Wallet balance as of a time
+	Post(ctx context.Context, p ledger.Posting) (*ledger.Result, error)                                         // Post a movement in its own database transaction
+	Reverse(ctx context.Context, id uint) (*ledger.Result, error)                                               // Reverse a transaction in its own database transaction
+}
+
+// StatementRepository reads generated statements
+type StatementRepository interface {
+	ListByWallet(ctx context.Context, walletID uint) ([]domain.Statement, error)    // Statements of a wallet without lines, newest period first
+	FindByWallet(ctx context.Context, walletID, id uint) (*domain.Statement, error) // Statement of a wallet with its lines
+}
+
+// AuditRepository appends to and reads the audit log
+type AuditRepository interface {
+	Record(ctx context.Context, e audit.Entry) error                                              // Append a record to the chain
+	List(ctx context.Context, f AuditFilter, offset, limit int) ([]domain.AuditLog, int64, error) // Records newest first, and the total matching
+	Latest(ctx context.Context, f AuditFilter, limit int) ([]domain.AuditLog, error)              // Newest matching records without counting
+	Each(ctx context.Context, f AuditFilter, fn func(*domain.AuditLog) error) error               // Every matching record in chain order, until fn fails
+}
+
+// ApprovalRepository stores pending actions through the approval workflow
+type ApprovalRepository interface {
+	FindByID(ctx context.Context, id uint) (*domain.PendingAction, error)                                                 // Action by ID
+	List(ctx context.Context, status string, types []string, limit int) ([]domain.PendingAction, error)                   // Actions of the given types and status, newest first
+	Propose(ctx context.Context, p approval.Proposal, meta audit.Entry, ttl time.Duration) (*domain.PendingAction, error) // Record a proposal
+	Approve(ctx context.Context, id uint, role, note string, meta audit.Entry) (*domain.PendingAction, error)             // Approve and execute an action
+	Reject(ctx context.Context, id uint, role, note string, meta audit.Entry) (*domain.PendingAction, error)              // Reject an action
+}
+
+// Store hands out the repositories, bound either to the connection pool or to one database transaction
+type Store interface {
+	Users() UserRepository                                     // Users
+	Wallets() WalletRepository                                 // Wallets
+	Transactions() TransactionRepository                       // Transactions and postings
+	Statements() StatementRepository                           // Statements
+	Audit() AuditRepository                                    // Audit log
+	Approvals() ApprovalRepository                             // Pending actions
+	Atomic(ctx context.Context, fn func(tx Store) error) error // Run fn in one database transaction, rolled back if it fails
+}
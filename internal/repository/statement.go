@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"                       // Request-scoped cancellation
+	"wallet_system/internal/domain" // Importing domain models
+
+	"gorm.io/gorm" // GORM ORM library
+)
+
+// gormStatements is the GORM implementation of StatementRepository
+type gormStatements struct {
+	db *gorm.DB // Database connection
+}
+
+// NewStatementRepository returns a StatementRepository backed by db
+func NewStatementRepository(db *gorm.DB) StatementRepository {
+	return &gormStatements{db: db}
+}
+
+// ListByWallet returns the statements of a wallet without their lines, newest period first
+func (r *gormStatements) ListByWallet(ctx context.Context, walletID uint) ([]domain.Statement, error) {
+	var statements []domain.Statement
+	err := r.db.WithContext(ctx).Where("wallet_id = ?", walletID).Order("period_start desc").Find(&statements).Error
+	return statements, err
+}
+
+// FindByWallet returns a statement of the given wallet with its lines in posting order.
+// Statements of other wallets are reported as ErrNotFound.
+func (r *gormStatements) FindByWallet(ctx context.Context, walletID, id uint) (*domain.Statement, error) {
+	var st domain.Statement
+	if err := r.db.WithContext(ctx).Where("wallet_id = ?", walletID).
+		Preload("Lines", func(tx *gorm.DB) *gorm.DB { return tx.Order("posted_at asc").Order("id asc") }).
+		First(&st, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &st, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"                       // Request-scoped cancellation
+	"wallet_system/internal/audit"  // Audit log
+	"wallet_system/internal/domain" // Importing domain models
+
+	"gorm.io/gorm" // GORM ORM library
+)
+
+// gormStore is the GORM implementation of Store
+type gormStore struct {
+	db *gorm.DB // Connection pool or open transaction
+}
+
+// NewStore returns a Store whose repositories use db
+func NewStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) Users() UserRepository               { return NewUserRepository(s.db) }
+func (s *gormStore) Wallets() WalletRepository           { return NewWalletRepository(s.db) }
+func (s *gormStore) Transactions() TransactionRepository { return NewTransactionRepository(s.db) }
+func (s *gormStore) Statements() StatementRepository     { return NewStatementRepository(s.db) }
+func (s *gormStore) Audit() AuditRepository              { return NewAuditRepository(s.db) }
+func (s *gormStore) Approvals() ApprovalRepository       { return NewApprovalRepository(s.db) }
+
+// Atomic runs fn with repositories bound to one database transaction. The transaction commits
+// if fn returns nil and rolls back otherwise; inside another transaction it uses a savepoint.
+func (s *gormStore) Atomic(ctx context.Context, fn func(tx Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx})
+	})
+}
+
+// ApprovalExecutor adapts fn to approval.Action.Execute, so an approved action runs against
+// repositories bound to the approval's transaction
+func ApprovalExecutor(fn func(ctx context.Context, tx Store, action *domain.PendingAction, meta audit.Entry) (any, error)) func(*gorm.DB, *domain.PendingAction, audit.Entry) (any, error) {
+	return func(tx *gorm.DB, action *domain.PendingAction, meta audit.Entry) (any, error) {
+		return fn(tx.Statement.Context, &gormStore{db: tx}, action, meta)
+	}
+}
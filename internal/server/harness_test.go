@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"wallet_system/internal/config"
+	database "wallet_system/internal/db"
+	"wallet_system/internal/domain"
+	"wallet_system/internal/utils"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testPassword is the password of every fixture user
+const testPassword = "password123"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	logrus.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// env is a running router backed by an in-memory SQLite database and an in-process Redis
+type env struct {
+	t      *testing.T
+	cfg    *config.Config
+	db     *gorm.DB
+	mr     *miniredis.Miniredis
+	rdb    *redis.Client
+	router *gin.Engine
+}
+
+// newEnv boots the full router against a fresh database and Redis
+func newEnv(t *testing.T) *env {
+	t.Helper()
+	// A named in-memory database lives as long as a connection to it is open; one connection
+	// also serializes transactions the way row locks would on MySQL
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared&_pragma=foreign_keys(1)", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	// The schema comes from the SQLite migrations, exactly as `migrate up` creates it
+	m, err := database.Migrator(db)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	cfg := &config.Config{
+		JWTSecret:                 "test-secret",
+		ApprovalTTL:               time.Hour,
+		ReversalApprovalThreshold: 1000,
+		Currency:                  "USD",
+	}
+	router, err := NewRouter(cfg, db, rdb)
+	if err != nil {
+		t.Fatalf("new router: %v", err)
+	}
+	return &env{t: t, cfg: cfg, db: db, mr: mr, rdb: rdb, router: router}
+}
+
+// user creates a user with the given role and testPassword
+func (e *env) user(username, role string) *domain.User {
+	e.t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		e.t.Fatalf("hash password: %v", err)
+	}
+	u := &domain.User{Username: username, Password: string(hash), Role: role}
+	if err := e.db.Create(u).Error; err != nil {
+		e.t.Fatalf("create user %s: %v", username, err)
+	}
+	return u
+}
+
+// wallet creates a wallet for u holding balance. The balance is funded by a deposit so the
+// ledger stays consistent.
+func (e *env) wallet(u *domain.User, balance float64) *domain.Wallet {
+	e.t.Helper()
+	w := &domain.Wallet{UserID: u.ID}
+	if err := e.db.Create(w).Error; err != nil {
+		e.t.Fatalf("create wallet: %v", err)
+	}
+	if balance > 0 {
+		rec := e.do(http.MethodPost, "/wallet/deposit", e.token(u), gin.H{"amount": balance})
+		e.expect(rec, http.StatusOK)
+		w.Balance = balance
+	}
+	return w
+}
+
+// customer creates a user with a funded wallet
+func (e *env) customer(username string, balance float64) (*domain.User, string) {
+	e.t.Helper()
+	u := e.user(username, domain.RoleUser)
+	e.wallet(u, balance)
+	return u, e.token(u)
+}
+
+// token returns a valid access token for u
+func (e *env) token(u *domain.User) string {
+	e.t.Helper()
+	version, err := utils.SessionVersion(context.Background(), e.rdb, u.ID)
+	if err != nil {
+		e.t.Fatalf("session version: %v", err)
+	}
+	pair, err := utils.GenerateTokenPair(u.ID, u.Role, version, e.cfg.JWTSecret)
+	if err != nil {
+		e.t.Fatalf("generate token: %v", err)
+	}
+	return pair.AccessToken
+}
+
+// balance reads a wallet balance from the database
+func (e *env) balance(u *domain.User) float64 {
+	e.t.Helper()
+	var w domain.Wallet
+	if err := e.db.Where("user_id = ?", u.ID).First(&w).Error; err != nil {
+		e.t.Fatalf("load wallet: %v", err)
+	}
+	return w.Balance
+}
+
+// do sends a request through the router; body is encoded as JSON unless nil
+func (e *env) do(method, path, token string, body any) *httptest.ResponseRecorder {
+	e.t.Helper()
+	return e.doWithHeaders(method, path, token, body, nil)
+}
+
+// doWithHeaders sends a request like do with extra headers
+func (e *env) doWithHeaders(method, path, token string, body any, headers map[string]string) *httptest.ResponseRecorder {
+	e.t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			e.t.Fatalf("encode body: %v", err)
+		}
+		r = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, r)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// expect fails the test unless rec has the given status
+func (e *env) expect(rec *httptest.ResponseRecorder, status int) {
+	e.t.Helper()
+	if rec.Code != status {
+		e.t.Fatalf("status = %d, want %d; body: %s", rec.Code, status, rec.Body.String())
+	}
+}
+
+// expectError fails the test unless rec has the given status and error message
+func (e *env) expectError(rec *httptest.ResponseRecorder, status int, msg string) {
+	e.t.Helper()
+	e.expect(rec, status)
+	var body struct {
+		Error string `json:"error"`
+	}
+	decode(e.t, rec, &body)
+	if body.Error != msg {
+		e.t.Fatalf("error = %q, want %q", body.Error, msg)
+	}
+}
+
+// decode unmarshals a JSON response body
+func decode(t *testing.T, rec *httptest.ResponseRecorder, dest any) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), dest); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body.String(), err)
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"net/http"                           // net/http package is needed for the HTTP server
+	"time"                               // time package is needed for deprecation dates
+	"wallet_system/internal/api"         // Custom package for API handlers
+	"wallet_system/internal/audit"       // Custom package for the audit log
+	"wallet_system/internal/config"      // Custom package for configuration
+	database "wallet_system/internal/db" // Custom package for database migrations
+	"wallet_system/internal/domain"      // Custom package for domain models
+	"wallet_system/internal/metrics"     // Custom package for Prometheus metrics
+	"wallet_system/internal/middleware"  // Custom package for middleware
+	"wallet_system/internal/ratelimit"   // Custom package for rate limiting
+	"wallet_system/internal/repository"  // Custom package for persistence
+	"wallet_system/internal/service"     // Custom package for business logic
+	"wallet_system/internal/tracing"     // Custom package for OpenTelemetry tracing
+
+	"github.com/gin-gonic/gin"     // Gin web framework
+	"github.com/redis/go-redis/v9" // Redis client
+	"gorm.io/gorm"                 // GORM ORM library
+)
+
+// NewRouter wires the services and every HTTP route against db and rdb. Handlers reach the database
+// only through the services; the readiness probe alone pings it directly. It also registers the
+// four-eyes approval actions, which drop caches in the same Redis. Background jobs are left to the caller.
+func NewRouter(cfg *config.Config, db *gorm.DB, rdb *redis.Client) (*gin.Engine, error) {
+	// Repositories and the services built on them
+	store := repository.NewStore(db)                                                        // Persistence
+	cache := service.NewRedisCache(rdb)                                                     // Read cache
+	authService := service.NewAuthService(store, rdb, cfg.JWTSecret)                        // Registration, logins and sessions
+	walletService := service.NewWalletService(store, cache, cfg.CacheTTL, cfg.JWTSecret)    // Customer wallets
+	userService := service.NewUserService(store, rdb, cache, cfg.CacheTTL, cfg.ApprovalTTL) // Admin user management
+	reportService := service.NewReportService(store, cfg.Currency)                          // Balances, statements and exports
+	auditService := service.NewAuditService(store)                                          // Audit log reads
+	approvalService := service.NewApprovalService(store)                                    // Four-eyes decisions
+	// Admin transactions; reversals above the threshold and every adjustment wait for a second admin
+	txService := service.NewTransactionService(store, cache, cfg.CacheTTL, cfg.JWTSecret, cfg.ReversalApprovalThreshold, cfg.ApprovalTTL)
+
+	// Setup Gin without its own logger; requests are logged through logrus
+	r := gin.New() // Gin router instance
+
+	// Set trusted proxies for Gin
+	if err := r.SetTrustedProxies([]string{"127.0.0.1"}); err != nil {
+		return nil, err
+	}
+
+	// Identify, trace, log, count and time every request
+	r.Use(middleware.RequestIDMiddleware())
+	r.Use(tracing.Middleware(cfg.ServiceName)...)
+	r.Use(middleware.AccessLogMiddleware("/healthz", "/readyz", "/metrics"))
+	r.Use(middleware.RecoveryMiddleware())
+	r.Use(metrics.Middleware())
+
+	// Probes for the orchestrator, without authentication
+	migrator, err := database.Migrator(db) // Known migrations, for the readiness check
+	if err != nil {
+		return nil, err
+	}
+	r.GET("/healthz", api.HealthzHandler())                // Liveness endpoint
+	r.GET("/readyz", api.ReadyzHandler(db, rdb, migrator)) // Readiness endpoint
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))        // Prometheus scrape endpoint
+
+	// Rate limits shared by every instance through Redis
+	limiter := ratelimit.New(rdb)                                                         // Sliding window counters
+	authLimit := middleware.RateLimitMiddleware(limiter, "auth", cfg.RateLimitAuth)       // Credential routes, including the legacy ones
+	walletLimit := middleware.RateLimitMiddleware(limiter, "wallet", cfg.RateLimitWallet) // Customer routes
+	adminLimit := middleware.RateLimitMiddleware(limiter, "admin", cfg.RateLimitAdmin)    // Admin routes
+	// Exports and camt messages outlast the server's write timeout
+	exportDeadline := middleware.WriteDeadlineMiddleware(cfg.HTTPExportTimeout)
+
+	// The API routes, registered under /v1 and, for existing clients, without a version
+	routes := func(g *gin.RouterGroup) {
+		// Auth routes
+		authGroup := g.Group("/auth")
+		authGroup.POST("/register", authLimit, api.RegisterHandler(authService))            // Registration endpoint
+		authGroup.POST("/login", authLimit, api.LoginHandler(authService))                  // Login endpoint
+		authGroup.POST("/refresh", authLimit, api.RefreshHandler(authService))              // Token refresh endpoint
+		authGroup.POST("/password/reset", authLimit, api.PasswordResetHandler(authService)) // Password reset endpoint
+		// Session routes require a valid access token
+		authGroup.POST("/logout", middleware.JWTAuthMiddleware(cfg.JWTSecret, store.Users(), rdb), api.LogoutHandler(authService)) // Logout endpoint
+		authGroup.GET("/me", middleware.JWTAuthMiddleware(cfg.JWTSecret, store.Users(), rdb), api.MeHandler(authService))          // Current user endpoint
+
+		// Wallet routes (protected by JWT)
+		walletGroup := g.Group("/wallet")
+		// Protect wallet routes with JWT middleware, then limit each user
+		walletGroup.Use(middleware.JWTAuthMiddleware(cfg.JWTSecret, store.Users(), rdb), walletLimit)
+		walletGroup.POST("", api.CreateWalletHandler(walletService))                                                // Create wallet endpoint
+		walletGroup.GET("", api.GetWalletHandler(walletService))                                                    // Get wallet endpoint
+		walletGroup.GET("/balance", api.GetBalanceHandler(reportService, cfg.Currency))                             // Balance, optionally at a past time
+		walletGroup.POST("/deposit", api.DepositHandler(walletService))                                             // Deposit endpoint
+		walletGroup.POST("/transfer", api.TransferHandler(walletService))                                           // Transfer endpoint
+		walletGroup.GET("/transactions", api.GetTransactionHistoryHandler(walletService))                           // Transaction history endpoint
+		walletGroup.GET("/transactions/export", exportDeadline, api.ExportTransactionHistoryHandler(reportService)) // Transaction history export endpoint
+		walletGroup.GET("/statements", api.ListStatementsHandler(reportService))                                    // List statements endpoint
+		walletGroup.GET("/statements/:id", api.GetStatementHandler(reportService))                                  // Get statement endpoint
+
+		// Admin routes (protected, permission checked per route)
+		adminGroup := g.Group("/admin")
+		// Protect admin routes with JWT and limit each admin; each route then checks the permissions it needs
+		adminGroup.Use(middleware.JWTAuthMiddleware(cfg.JWTSecret, store.Users(), rdb), adminLimit)
+		requirePermission := func(perms ...domain.Permission) gin.HandlerFunc { // Shorthand for per-route permission checks
+			return middleware.RequirePermission(store.Users(), rdb, perms...)
+		}
+		auditAccess := func(action string) gin.HandlerFunc { // Shorthand for recording admin reads
+			return middleware.AuditAccessMiddleware(store.Audit(), action)
+		}
+		adminGroup.GET("/users", requirePermission(domain.PermUserRead), auditAccess(audit.ActionAdminUsersList), api.ListUsersHandler(userService))        // List users endpoint
+		adminGroup.GET("/users/:id", requirePermission(domain.PermUserRead), auditAccess(audit.ActionAdminUserGet), api.GetUserHandler(userService))        // Get user endpoint
+		adminGroup.GET("/transactions", requirePermission(domain.PermTxRead), auditAccess(audit.ActionAdminTxList), api.ListTransactionsHandler(txService)) // List transactions endpoint
+		adminGroup.GET("/roles", requirePermission(domain.PermRoleAssign), api.ListRolesHandler())                                                          // List roles endpoint
+		adminGroup.PUT("/users/:id/role", requirePermission(domain.PermRoleAssign), api.AssignRoleHandler(userService))                                     // Assign role endpoint
+		adminGroup.GET("/audit", requirePermission(domain.PermAuditRead), auditAccess(audit.ActionAdminAuditList), api.ListAuditLogsHandler(auditService))  // Query audit log endpoint
+		// Exports stream past the server's write timeout; the transaction export takes the listing filters
+		adminGroup.GET("/audit/export", requirePermission(domain.PermAuditRead), auditAccess(audit.ActionAdminAuditExport), exportDeadline, api.ExportAuditLogsHandler(auditService))
+		adminGroup.GET("/transactions/export", requirePermission(domain.PermTxRead), auditAccess(audit.ActionAdminTxExport), exportDeadline, api.AdminExportTransactionsHandler(reportService))
+		// ISO 20022 messages for bank reconciliation, also for system accounts
+		adminGroup.GET("/wallets/:id/camt053", requirePermission(domain.PermTxRead), auditAccess(audit.ActionAdminCamtExport), exportDeadline, api.CamtExportHandler(reportService, service.CamtStatement))    // End-of-day statement endpoint
+		adminGroup.GET("/wallets/:id/camt054", requirePermission(domain.PermTxRead), auditAccess(audit.ActionAdminCamtExport), exportDeadline, api.CamtExportHandler(reportService, service.CamtNotification)) // Debit/credit notification endpoint
+		// Point-in-time balances
+		adminGroup.GET("/wallets/:id/balance", requirePermission(domain.PermTxRead), auditAccess(audit.ActionAdminBalanceGet), api.AdminGetBalanceHandler(reportService, cfg.Currency))
+		// Reversals above the threshold and role changes go through four-eyes approval
+		reverseHandler := api.ReverseTransactionHandler(txService)
+		adminGroup.POST("/transactions/:id/reverse", requirePermission(domain.PermTxReverse), reverseHandler)                   // Reverse transaction endpoint
+		adminGroup.POST("/wallets/:id/adjustments", requirePermission(domain.PermTxAdjust), api.AdjustWalletHandler(txService)) // Manual adjustment endpoint
+		// Account restrictions
+		adminGroup.PUT("/users/:id/status", requirePermission(domain.PermUserFreeze), api.SetUserStatusHandler(userService))     // Freeze or unfreeze user endpoint
+		adminGroup.PUT("/wallets/:id/status", requirePermission(domain.PermUserFreeze), api.SetWalletStatusHandler(userService)) // Freeze or unfreeze wallet endpoint
+		adminGroup.POST("/users/:id/close", requirePermission(domain.PermUserFreeze), api.CloseUserHandler(userService))         // Close account endpoint
+		// Credential management
+		adminGroup.POST("/users/:id/password-reset", requirePermission(domain.PermUserManage), api.ForcePasswordResetHandler(userService)) // Force password reset endpoint
+		adminGroup.POST("/users/:id/sessions/revoke", requirePermission(domain.PermUserManage), api.RevokeSessionsHandler(userService))    // Revoke sessions endpoint
+		// Approval routes also filter by the permission of each action type
+		adminGroup.GET("/approvals", requirePermission(domain.PermApprovalDecide), api.ListApprovalsHandler(approvalService))        // List pending actions endpoint
+		adminGroup.GET("/approvals/:id", requirePermission(domain.PermApprovalDecide), api.GetApprovalHandler(approvalService))      // Get pending action endpoint
+		adminGroup.POST("/approvals/:id/approve", requirePermission(domain.PermApprovalDecide), api.ApproveHandler(approvalService)) // Approve pending action endpoint
+		adminGroup.POST("/approvals/:id/reject", requirePermission(domain.PermApprovalDecide), api.RejectHandler(approvalService))   // Reject pending action endpoint
+	}
+	// Versioned routes wrap responses in envelopes and give errors stable codes
+	routes(r.Group("/v1", middleware.VersionMiddleware()))
+	// Unversioned routes keep their original response bodies until the sunset date
+	unversionedDeprecatedAt := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC) // Date /v1 was introduced
+	unversionedSunset := time.Date(2027, time.October, 31, 0, 0, 0, 0, time.UTC)       // Date the unversioned routes will be removed
+	unversioned := r.Group("", middleware.UnversionedMiddleware("/v1", unversionedDeprecatedAt, unversionedSunset))
+	routes(unversioned)
+	r.NoRoute(api.NotFoundHandler("/v1")) // Unknown paths, with the envelope under /v1
+
+	// Legacy auth routes, kept until the sunset date
+	legacyDeprecatedAt := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC) // Date the legacy routes were deprecated
+	legacySunset := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)         // Date the legacy routes will be removed
+	// Deprecated registration endpoint
+	unversioned.POST("/user", middleware.DeprecatedMiddleware("/v1/auth/register", legacyDeprecatedAt, legacySunset), authLimit, api.RegisterHandler(authService))
+	// Deprecated login endpoint
+	unversioned.GET("/user", middleware.DeprecatedMiddleware("/v1/auth/login", legacyDeprecatedAt, legacySunset), authLimit, api.LoginHandler(authService))
+
+	// Register four-eyes actions
+	service.RegisterApprovalActions(cache)
+
+	return r, nil
+}
+
+// NewHTTPServer wraps handler in an http.Server listening on the application port with the
+// configured timeouts, so slow or stalled clients cannot hold connections indefinitely
+func NewHTTPServer(cfg *config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + cfg.AppPort,    // Listen address
+		Handler:           handler,              // Router
+		ReadHeaderTimeout: cfg.HTTPReadTimeout,  // Time to read the request headers
+		ReadTimeout:       cfg.HTTPReadTimeout,  // Time to read the whole request
+		WriteTimeout:      cfg.HTTPWriteTimeout, // Time to write the response; exports get HTTPExportTimeout
+		IdleTimeout:       cfg.HTTPIdleTimeout,  // How long keep-alive connections stay open
+	}
+}
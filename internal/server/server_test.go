@@ -0,0 +1,1194 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"wallet_system/internal/approval"
+	"wallet_system/internal/audit"
+	"wallet_system/internal/config"
+	database "wallet_system/internal/db"
+	"wallet_system/internal/domain"
+	"wallet_system/internal/ledger"
+	"wallet_system/internal/logging"
+	"wallet_system/internal/middleware"
+	"wallet_system/internal/reconcile"
+	"wallet_system/internal/repository"
+	"wallet_system/internal/statement"
+	"wallet_system/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"gorm.io/gorm"
+)
+
+// historyResponse is the body of GET /wallet/transactions
+type historyResponse struct {
+	Transactions []struct {
+		ID           uint     `json:"id"`
+		Amount       float64  `json:"amount"`
+		Type         string   `json:"type"`
+		BalanceAfter *float64 `json:"balance_after"`
+	} `json:"transactions"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+	Total      *int64  `json:"total"`
+	Cached     bool    `json:"cached"`
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	e := newEnv(t)
+	creds := gin.H{"username": "Alice", "password": testPassword}
+
+	e.expect(e.do(http.MethodPost, "/auth/register", "", creds), http.StatusCreated)
+	e.expectError(e.do(http.MethodPost, "/auth/register", "", gin.H{"username": "alice", "password": testPassword}), http.StatusBadRequest, "Username already exists")
+	e.expectError(e.do(http.MethodPost, "/auth/register", "", gin.H{"username": "bob1", "password": testPassword}), http.StatusBadRequest, "Username must be alphabetic only")
+	e.expectError(e.do(http.MethodPost, "/auth/register", "", gin.H{"username": "bob", "password": "short"}), http.StatusBadRequest, "Password must be 8-15 characters")
+	e.expectError(e.do(http.MethodPost, "/auth/register", "", gin.H{"username": "bob"}), http.StatusBadRequest, "Invalid request")
+
+	rec := e.do(http.MethodPost, "/auth/login", "", creds)
+	e.expect(rec, http.StatusOK)
+	var auth struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+	}
+	decode(t, rec, &auth)
+	if auth.Token == "" || auth.RefreshToken == "" || auth.TokenType != "Bearer" {
+		t.Fatalf("unexpected login response: %s", rec.Body.String())
+	}
+
+	rec = e.do(http.MethodGet, "/auth/me", auth.Token, nil)
+	e.expect(rec, http.StatusOK)
+	var me struct {
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}
+	decode(t, rec, &me)
+	if me.Username != "alice" || me.Role != domain.RoleUser {
+		t.Fatalf("me = %+v, want alice with role user", me)
+	}
+
+	e.expectError(e.do(http.MethodPost, "/auth/login", "", gin.H{"username": "alice", "password": "wrongpass"}), http.StatusUnauthorized, "Invalid credentials")
+	e.expectError(e.do(http.MethodPost, "/auth/login", "", gin.H{"username": "nobody", "password": testPassword}), http.StatusUnauthorized, "Invalid credentials")
+	e.expectError(e.do(http.MethodGet, "/auth/me", "", nil), http.StatusUnauthorized, "Missing or invalid Authorization header")
+	e.expectError(e.do(http.MethodGet, "/auth/me", "not-a-token", nil), http.StatusUnauthorized, "Invalid or expired token")
+
+	// Failed and successful logins are audited
+	var logins int64
+	e.db.Model(&domain.AuditLog{}).Where("target_id = ?", "alice").Count(&logins)
+	if logins != 2 {
+		t.Fatalf("audited logins for alice = %d, want 2", logins)
+	}
+
+	// A login that cannot be given tokens is audited as failed, not as a success
+	e.mr.Close()
+	e.expectError(e.do(http.MethodPost, "/auth/login", "", creds), http.StatusInternalServerError, "Failed to generate token")
+	var last domain.AuditLog
+	e.db.Where("target_id = ?", "alice").Order("id desc").First(&last)
+	if last.Action != audit.ActionLoginFailed {
+		t.Fatalf("last login action = %s, want %s", last.Action, audit.ActionLoginFailed)
+	}
+}
+
+func TestCreateWallet(t *testing.T) {
+	e := newEnv(t)
+	token := e.token(e.user("alice", domain.RoleUser))
+
+	e.expectError(e.do(http.MethodGet, "/wallet", token, nil), http.StatusNotFound, "Wallet not found")
+	e.expect(e.do(http.MethodPost, "/wallet", token, nil), http.StatusCreated)
+	e.expectError(e.do(http.MethodPost, "/wallet", token, nil), http.StatusBadRequest, "Wallet already exists")
+	e.expectError(e.do(http.MethodPost, "/wallet", "", nil), http.StatusUnauthorized, "Missing or invalid Authorization header")
+
+	// The new wallet is read from the database first, then from the cache
+	rec := e.do(http.MethodGet, "/wallet", token, nil)
+	e.expect(rec, http.StatusOK)
+	var body struct {
+		Wallet domain.Wallet `json:"wallet"`
+		Cached bool          `json:"cached"`
+	}
+	decode(t, rec, &body)
+	if body.Wallet.Balance != 0 || body.Cached {
+		t.Fatalf("wallet = %+v cached=%v, want empty uncached wallet", body.Wallet, body.Cached)
+	}
+	decode(t, e.do(http.MethodGet, "/wallet", token, nil), &body)
+	if !body.Cached {
+		t.Fatal("second wallet read was not served from the cache")
+	}
+}
+
+func TestDeposit(t *testing.T) {
+	e := newEnv(t)
+	alice, token := e.customer("alice", 0)
+
+	e.expect(e.do(http.MethodPost, "/wallet/deposit", token, gin.H{"amount": 150.5}), http.StatusOK)
+	if got := e.balance(alice); got != 150.5 {
+		t.Fatalf("balance = %v, want 150.5", got)
+	}
+	e.expectError(e.do(http.MethodPost, "/wallet/deposit", token, gin.H{"amount": -5}), http.StatusBadRequest, "Invalid amount")
+	e.expectError(e.do(http.MethodPost, "/wallet/deposit", token, gin.H{}), http.StatusBadRequest, "Invalid amount")
+
+	// Users without a wallet cannot deposit
+	bob := e.user("bob", domain.RoleUser)
+	e.expectError(e.do(http.MethodPost, "/wallet/deposit", e.token(bob), gin.H{"amount": 10}), http.StatusNotFound, "Wallet not found")
+
+	// Fully frozen accounts cannot receive money
+	e.db.Model(alice).Update("status", domain.StatusFrozenAll)
+	e.expectError(e.do(http.MethodPost, "/wallet/deposit", token, gin.H{"amount": 10}), http.StatusForbidden, "Account is frozen")
+	if got := e.balance(alice); got != 150.5 {
+		t.Fatalf("balance after rejected deposit = %v, want 150.5", got)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	e := newEnv(t)
+	alice, token := e.customer("alice", 100)
+	bob, _ := e.customer("bob", 0)
+
+	e.expect(e.do(http.MethodPost, "/wallet/transfer", token, gin.H{"to_username": "bob", "amount": 40}), http.StatusOK)
+	if a, b := e.balance(alice), e.balance(bob); a != 60 || b != 40 {
+		t.Fatalf("balances = %v, %v; want 60, 40", a, b)
+	}
+
+	e.expectError(e.do(http.MethodPost, "/wallet/transfer", token, gin.H{"to_username": "bob", "amount": 61}), http.StatusBadRequest, "Insufficient funds")
+	e.expectError(e.do(http.MethodPost, "/wallet/transfer", token, gin.H{"to_username": "carol", "amount": 1}), http.StatusNotFound, "Target user not found")
+	e.expectError(e.do(http.MethodPost, "/wallet/transfer", token, gin.H{"to_username": "alice", "amount": 1}), http.StatusBadRequest, "Cannot transfer to yourself")
+	e.expectError(e.do(http.MethodPost, "/wallet/transfer", token, gin.H{"to_username": "bob", "amount": 0}), http.StatusBadRequest, "Invalid request")
+
+	e.user("carol", domain.RoleUser) // No wallet
+	e.expectError(e.do(http.MethodPost, "/wallet/transfer", token, gin.H{"to_username": "carol", "amount": 1}), http.StatusNotFound, "Recipient wallet not found")
+
+	// Restrictions on either side
+	e.db.Model(bob).Update("status", domain.StatusFrozenAll)
+	e.expectError(e.do(http.MethodPost, "/wallet/transfer", token, gin.H{"to_username": "bob", "amount": 1}), http.StatusForbidden, "Recipient cannot receive funds")
+	e.db.Model(alice).Update("status", domain.StatusFrozenDebits)
+	e.expectError(e.do(http.MethodPost, "/wallet/transfer", token, gin.H{"to_username": "bob", "amount": 1}), http.StatusForbidden, "Account is frozen")
+
+	if a, b := e.balance(alice), e.balance(bob); a != 60 || b != 40 {
+		t.Fatalf("balances after rejected transfers = %v, %v; want 60, 40", a, b)
+	}
+}
+
+func TestTransactionHistoryCaching(t *testing.T) {
+	e := newEnv(t)
+	_, token := e.customer("alice", 100)
+	e.customer("bob", 0)
+	e.expect(e.do(http.MethodPost, "/wallet/transfer", token, gin.H{"to_username": "bob", "amount": 30}), http.StatusOK)
+
+	var page historyResponse
+	decode(t, e.do(http.MethodGet, "/wallet/transactions", token, nil), &page)
+	if page.Cached || len(page.Transactions) != 2 {
+		t.Fatalf("first read: cached=%v, %d transactions; want uncached with 2", page.Cached, len(page.Transactions))
+	}
+	// Newest first, with the wallet's balance after each posting
+	if tx := page.Transactions[0]; tx.Type != domain.TxTypeTransfer || tx.BalanceAfter == nil || *tx.BalanceAfter != 70 {
+		t.Fatalf("newest transaction = %+v, want transfer leaving 70", tx)
+	}
+	decode(t, e.do(http.MethodGet, "/wallet/transactions", token, nil), &page)
+	if !page.Cached {
+		t.Fatal("second read was not served from the cache")
+	}
+	if !e.mr.Exists("txhistory:user:1:first") {
+		t.Fatal("first page is not cached under its key")
+	}
+
+	// A posting invalidates the cached page
+	e.expect(e.do(http.MethodPost, "/wallet/deposit", token, gin.H{"amount": 5}), http.StatusOK)
+	decode(t, e.do(http.MethodGet, "/wallet/transactions", token, nil), &page)
+	if page.Cached || len(page.Transactions) != 3 {
+		t.Fatalf("after deposit: cached=%v, %d transactions; want uncached with 3", page.Cached, len(page.Transactions))
+	}
+
+	// Page through one row at a time; only the default first page is cached
+	var ids []uint
+	path := "/wallet/transactions?page_size=1&include_total=true"
+	for i := 0; i < 3; i++ {
+		decode(t, e.do(http.MethodGet, path, token, nil), &page)
+		if page.Cached || len(page.Transactions) != 1 || page.Total == nil || *page.Total != 3 {
+			t.Fatalf("page %d = %+v", i, page)
+		}
+		ids = append(ids, page.Transactions[0].ID)
+		if page.NextCursor == nil {
+			break
+		}
+		path = "/wallet/transactions?page_size=1&include_total=true&cursor=" + *page.NextCursor
+	}
+	if len(ids) != 3 || ids[0] <= ids[1] || ids[1] <= ids[2] || page.NextCursor != nil {
+		t.Fatalf("paged ids = %v, want 3 descending ids and no further page", ids)
+	}
+	// And back again
+	decode(t, e.do(http.MethodGet, "/wallet/transactions?page_size=1&cursor="+*page.PrevCursor, token, nil), &page)
+	if len(page.Transactions) != 1 || page.Transactions[0].ID != ids[1] {
+		t.Fatalf("previous page = %+v, want transaction %d", page, ids[1])
+	}
+
+	e.expectError(e.do(http.MethodGet, "/wallet/transactions?cursor=garbage", token, nil), http.StatusBadRequest, "Invalid cursor")
+	// Cursors are bound to the wallet they were issued for
+	_, other := e.customer("carol", 1)
+	e.expectError(e.do(http.MethodGet, "/wallet/transactions?cursor="+*page.PrevCursor, other, nil), http.StatusBadRequest, "Invalid cursor")
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	e := newEnv(t)
+	alice, aliceToken := e.customer("alice", 100)
+	e.customer("bob", 0)
+	admin := e.token(e.user("root", domain.RoleAdmin))
+	support := e.token(e.user("helpdesk", domain.RoleSupport))
+	e.expect(e.do(http.MethodPost, "/wallet/transfer", aliceToken, gin.H{"to_username": "bob", "amount": 25}), http.StatusOK)
+
+	// Permission checks
+	e.expectError(e.do(http.MethodGet, "/admin/users", aliceToken, nil), http.StatusForbidden, "Missing permission: "+string(domain.PermUserRead))
+	e.expectError(e.do(http.MethodGet, "/admin/users", "", nil), http.StatusUnauthorized, "Missing or invalid Authorization header")
+	e.expectError(e.do(http.MethodPost, "/admin/wallets/1/adjustments", support, gin.H{}), http.StatusForbidden, "Missing permission: "+string(domain.PermTxAdjust))
+	e.expectError(e.do(http.MethodGet, "/admin/approvals", aliceToken, nil), http.StatusForbidden, "Missing permission: "+string(domain.PermApprovalDecide))
+	e.expectError(e.do(http.MethodPost, "/admin/approvals/1/approve", support, gin.H{}), http.StatusForbidden, "Missing permission: "+string(domain.PermApprovalDecide))
+	e.expect(e.do(http.MethodGet, "/admin/approvals", admin, nil), http.StatusOK)
+
+	// User listing hides system accounts and supports filters
+	var users struct {
+		Users []struct {
+			Username string        `json:"username"`
+			Wallet   domain.Wallet `json:"wallet"`
+		} `json:"users"`
+		Total  int64 `json:"total"`
+		Cached bool  `json:"cached"`
+	}
+	rec := e.do(http.MethodGet, "/admin/users?sort=balance&order=desc", support, nil)
+	e.expect(rec, http.StatusOK)
+	decode(t, rec, &users)
+	if users.Total != 4 || users.Users[0].Username != "alice" || users.Users[0].Wallet.Balance != 75 {
+		t.Fatalf("users = %s", rec.Body.String())
+	}
+	decode(t, e.do(http.MethodGet, "/admin/users?sort=balance&order=desc", support, nil), &users)
+	if !users.Cached {
+		t.Fatal("repeated user listing was not served from the cache")
+	}
+	decode(t, e.do(http.MethodGet, "/admin/users?username=B", support, nil), &users)
+	if users.Total != 1 || users.Users[0].Username != "bob" {
+		t.Fatalf("username filter returned %+v", users.Users)
+	}
+	decode(t, e.do(http.MethodGet, "/admin/users?username=_", support, nil), &users)
+	if users.Total != 0 {
+		t.Fatalf("username filter treated _ as a wildcard: %+v", users.Users)
+	}
+	e.expectError(e.do(http.MethodGet, "/admin/users?sort=password", support, nil), http.StatusBadRequest, "Invalid sort; use id, username or balance")
+	e.expectError(e.do(http.MethodGet, "/admin/users?status=asleep", support, nil), http.StatusBadRequest, "Invalid status")
+
+	// Single user
+	rec = e.do(http.MethodGet, "/admin/users/"+strconv.Itoa(int(alice.ID)), support, nil)
+	e.expect(rec, http.StatusOK)
+	e.expectError(e.do(http.MethodGet, "/admin/users/999", support, nil), http.StatusNotFound, "User not found")
+
+	// Transaction listing with usernames and filters
+	var txs struct {
+		Transactions []struct {
+			ID           uint   `json:"id"`
+			Type         string `json:"type"`
+			FromUsername string `json:"from_username"`
+			ToUsername   string `json:"to_username"`
+		} `json:"transactions"`
+		Total *int64 `json:"total"`
+	}
+	rec = e.do(http.MethodGet, "/admin/transactions?type=transfer&username=bob&include_total=true", support, nil)
+	e.expect(rec, http.StatusOK)
+	decode(t, rec, &txs)
+	if len(txs.Transactions) != 1 || txs.Total == nil || *txs.Total != 1 {
+		t.Fatalf("transactions = %s", rec.Body.String())
+	}
+	transfer := txs.Transactions[0]
+	if transfer.FromUsername != "alice" || transfer.ToUsername != "bob" {
+		t.Fatalf("transfer = %+v, want alice to bob", transfer)
+	}
+	e.expectError(e.do(http.MethodGet, "/admin/transactions?type=gift", support, nil), http.StatusBadRequest, "Invalid type; use "+strings.Join(domain.TransactionTypes, ", "))
+	e.expectError(e.do(http.MethodGet, "/admin/transactions?min_amount=5&max_amount=1", support, nil), http.StatusBadRequest, "min_amount must not exceed max_amount")
+	e.expectError(e.do(http.MethodGet, "/admin/transactions?cursor=garbage", support, nil), http.StatusBadRequest, "Invalid cursor")
+
+	// Reads are audited
+	var reads int64
+	e.db.Model(&domain.AuditLog{}).Where("action LIKE ?", "admin.%").Count(&reads)
+	if reads == 0 {
+		t.Fatal("admin reads were not audited")
+	}
+
+	// Small reversals apply at once and refresh the cached balances
+	e.expectError(e.do(http.MethodPost, "/admin/transactions/"+strconv.Itoa(int(transfer.ID))+"/reverse", support, gin.H{"note": "mistake"}), http.StatusForbidden, "Missing permission: "+string(domain.PermTxReverse))
+	e.expectError(e.do(http.MethodPost, "/admin/transactions/"+strconv.Itoa(int(transfer.ID))+"/reverse", admin, gin.H{}), http.StatusBadRequest, "Invalid request")
+	e.expect(e.do(http.MethodPost, "/admin/transactions/"+strconv.Itoa(int(transfer.ID))+"/reverse", admin, gin.H{"note": "mistake"}), http.StatusOK)
+	if got := e.balance(alice); got != 100 {
+		t.Fatalf("balance after reversal = %v, want 100", got)
+	}
+	e.expectError(e.do(http.MethodPost, "/admin/transactions/"+strconv.Itoa(int(transfer.ID))+"/reverse", admin, gin.H{"note": "again"}), http.StatusConflict, "Transaction cannot be reversed")
+	e.expectError(e.do(http.MethodPost, "/admin/transactions/999/reverse", admin, gin.H{"note": "missing"}), http.StatusNotFound, "Transaction not found")
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	e := newEnv(t)
+	type readiness struct {
+		Status string `json:"status"`
+		Checks map[string]struct {
+			Status string `json:"status"`
+			Error  string `json:"error"`
+		} `json:"checks"`
+	}
+
+	e.expect(e.do(http.MethodGet, "/healthz", "", nil), http.StatusOK)
+	rec := e.do(http.MethodGet, "/readyz", "", nil)
+	e.expect(rec, http.StatusOK)
+	var ready readiness
+	decode(t, rec, &ready)
+	for _, dep := range []string{"database", "redis", "migrations"} {
+		if ready.Checks[dep].Status != "ok" {
+			t.Fatalf("%s check = %+v, want ok", dep, ready.Checks[dep])
+		}
+	}
+
+	// A pending migration makes the instance unready but leaves it alive
+	m, err := database.Migrator(e.db)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := m.Down(1); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	rec = e.do(http.MethodGet, "/readyz", "", nil)
+	e.expect(rec, http.StatusServiceUnavailable)
+	decode(t, rec, &ready)
+	if ready.Status != "not ready" || ready.Checks["migrations"].Status != "error" || ready.Checks["database"].Status != "ok" {
+		t.Fatalf("readiness = %s", rec.Body.String())
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	// So does losing Redis
+	e.mr.Close()
+	rec = e.do(http.MethodGet, "/readyz", "", nil)
+	e.expect(rec, http.StatusServiceUnavailable)
+	decode(t, rec, &ready)
+	if ready.Checks["redis"].Status != "error" || ready.Checks["redis"].Error == "" || ready.Checks["migrations"].Status != "ok" {
+		t.Fatalf("readiness = %s", rec.Body.String())
+	}
+	e.expect(e.do(http.MethodGet, "/healthz", "", nil), http.StatusOK)
+}
+
+func TestMetrics(t *testing.T) {
+	e := newEnv(t)
+	_, alice := e.customer("alice", 50)
+	e.customer("bob", 0)
+	e.expectError(e.do(http.MethodPost, "/wallet/transfer", alice, gin.H{"to_username": "bob", "amount": 500}), http.StatusBadRequest, "Insufficient funds")
+	e.expect(e.do(http.MethodGet, "/wallet", alice, nil), http.StatusOK)
+	e.expect(e.do(http.MethodGet, "/wallet", alice, nil), http.StatusOK)
+	e.expect(e.do(http.MethodGet, "/no/such/route", "", nil), http.StatusNotFound)
+
+	rec := e.do(http.MethodGet, "/metrics", "", nil)
+	e.expect(rec, http.StatusOK)
+	body := rec.Body.String()
+	for _, want := range []string{
+		`wallet_http_requests_total{method="POST",route="/wallet/deposit",status="200"}`,
+		`wallet_http_request_duration_seconds_bucket{method="GET",route="/wallet",status="200",le="+Inf"}`,
+		`wallet_http_requests_total{method="GET",route="unmatched",status="404"}`,
+		`wallet_operations_total{operation="deposit"}`,
+		`wallet_operation_amount_total{operation="deposit"}`,
+		`wallet_operation_failures_total{operation="transfer",reason="insufficient_funds"}`,
+		`wallet_cache_lookups_total{result="hit"}`,
+		`wallet_cache_lookups_total{result="miss"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	e := newEnv(t)
+	if err := tracing.InstrumentDB(e.db); err != nil {
+		t.Fatalf("instrument db: %v", err)
+	}
+	if err := tracing.InstrumentRedis(e.rdb); err != nil {
+		t.Fatalf("instrument redis: %v", err)
+	}
+	_, alice := e.customer("alice", 50)
+	e.customer("bob", 0)
+
+	// The trace started by the caller continues through the handler, the queries and Redis
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodPost, "/wallet/transfer", strings.NewReader(`{"to_username": "bob", "amount": 10}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+alice)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	e.expect(rec, http.StatusOK)
+	if got := rec.Header().Get(tracing.TraceIDHeader); got != traceID {
+		t.Fatalf("%s = %q, want %q", tracing.TraceIDHeader, got, traceID)
+	}
+	scopes := map[string]int{} // Spans of the trace by instrumentation
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			scopes[span.InstrumentationScope().Name]++
+		}
+	}
+	for _, want := range []string{"gin", "gorm", "redis"} {
+		found := false
+		for scope := range scopes {
+			found = found || strings.Contains(scope, want)
+		}
+		if !found {
+			t.Errorf("no %s span in the trace; spans by scope: %v", want, scopes)
+		}
+	}
+
+	// Log entries made with the request context carry the trace
+	entry := logrus.WithContext(trace.ContextWithSpanContext(context.Background(), recorder.Ended()[0].SpanContext()))
+	if err := (tracing.LogHook{}).Fire(entry); err != nil {
+		t.Fatalf("log hook: %v", err)
+	}
+	if entry.Data["trace_id"] == nil || entry.Data["span_id"] == nil {
+		t.Fatalf("log fields = %v, want trace_id and span_id", entry.Data)
+	}
+}
+
+func TestRequestIDAndLogging(t *testing.T) {
+	var logs bytes.Buffer
+	if err := logging.Setup(&config.Config{LogLevel: "debug", LogFormat: logging.FormatJSON}); err != nil {
+		t.Fatalf("setup logging: %v", err)
+	}
+	logrus.SetOutput(&logs)
+	t.Cleanup(func() {
+		logrus.SetOutput(io.Discard)
+		logrus.SetLevel(logrus.InfoLevel)
+		logrus.SetFormatter(&logrus.TextFormatter{})
+		logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+		log.SetOutput(os.Stderr)
+	})
+	e := newEnv(t)
+	_, alice := e.customer("alice", 0)
+
+	// IDs are generated when missing or unsafe, and kept when the caller sends a valid one
+	rec := e.do(http.MethodGet, "/wallet", alice, nil)
+	if id := rec.Header().Get(middleware.RequestIDHeader); len(id) != 32 {
+		t.Fatalf("generated request ID = %q, want 32 hex characters", id)
+	}
+	rec = e.doWithHeaders(http.MethodGet, "/wallet", alice, nil, map[string]string{middleware.RequestIDHeader: "bad id\x01"})
+	if id := rec.Header().Get(middleware.RequestIDHeader); id == "bad id\x01" || len(id) != 32 {
+		t.Fatalf("unsafe request ID was kept: %q", id)
+	}
+	// The audit log records the ID the request was handled under, not the rejected header
+	rec = e.doWithHeaders(http.MethodPost, "/auth/login", "", gin.H{"username": "alice", "password": testPassword}, map[string]string{middleware.RequestIDHeader: "bad id\x01"})
+	e.expect(rec, http.StatusOK)
+	var login domain.AuditLog
+	if err := e.db.Where("action = ?", audit.ActionLogin).Order("id desc").First(&login).Error; err != nil {
+		t.Fatalf("load login record: %v", err)
+	}
+	if login.RequestID != rec.Header().Get(middleware.RequestIDHeader) {
+		t.Fatalf("audit request ID = %q, want %q", login.RequestID, rec.Header().Get(middleware.RequestIDHeader))
+	}
+	// Client-controlled values are cut to their column widths, and the hash covers what is stored
+	rec = e.doWithHeaders(http.MethodPost, "/auth/login", "", gin.H{"username": "alice", "password": testPassword}, map[string]string{
+		middleware.RequestIDHeader: strings.Repeat("r", 128),
+		"User-Agent":               strings.Repeat("é", 300),
+	})
+	e.expect(rec, http.StatusOK)
+	login = domain.AuditLog{}
+	if err := e.db.Where("action = ?", audit.ActionLogin).Order("id desc").First(&login).Error; err != nil {
+		t.Fatalf("load login record: %v", err)
+	}
+	if login.RequestID != strings.Repeat("r", 64) || login.UserAgent != strings.Repeat("é", 255) {
+		t.Fatalf("audit record = %q, %q, want them cut to 64 and 255 characters", login.RequestID, login.UserAgent)
+	}
+	if res, err := audit.Verify(e.db); err != nil || !res.Valid {
+		t.Fatalf("verify = %+v, %v", res, err)
+	}
+	logs.Reset()
+	rec = e.doWithHeaders(http.MethodPost, "/wallet/deposit", alice, gin.H{"amount": 25}, map[string]string{middleware.RequestIDHeader: "deposit-1"})
+	e.expect(rec, http.StatusOK)
+	if id := rec.Header().Get(middleware.RequestIDHeader); id != "deposit-1" {
+		t.Fatalf("request ID = %q, want deposit-1", id)
+	}
+
+	// Both the service log line and the access log line carry the request ID
+	messages := map[string]bool{} // Messages logged with the request ID
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line is not JSON: %q", line)
+		}
+		if entry["request_id"] == "deposit-1" {
+			messages[entry["msg"].(string)] = true
+		}
+	}
+	if !messages["Deposit transaction"] || !messages["Request handled"] {
+		t.Fatalf("messages with the request ID = %v", messages)
+	}
+
+	// Sensitive fields are redacted, including nested ones
+	logs.Reset()
+	logrus.WithFields(logrus.Fields{"password": "hunter2", "body": gin.H{"refresh_token": "abc.def", "username": "alice"}}).Info("Careless")
+	if out := logs.String(); strings.Contains(out, "hunter2") || strings.Contains(out, "abc.def") || !strings.Contains(out, logging.Redacted) || !strings.Contains(out, "alice") {
+		t.Fatalf("log line = %s", out)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	e := newEnv(t)
+	alice, _ := e.customer("alice", 0)
+	bob, _ := e.customer("bob", 0)
+	e.cfg.RateLimitAuth = config.RateLimit{Requests: 3, Window: time.Minute, By: "ip"}
+	e.cfg.RateLimitWallet = config.RateLimit{Requests: 2, Window: time.Minute, By: "user"}
+	e.cfg.RateLimitAdmin = config.RateLimit{Requests: 2, Window: time.Minute, By: "api_key"}
+	admin := e.token(e.user("root", domain.RoleAdmin))
+	router, err := NewRouter(e.cfg, e.db, e.rdb)
+	if err != nil {
+		t.Fatalf("new router: %v", err)
+	}
+	e.router = router
+
+	// Login attempts are counted per client, and the legacy route shares the count
+	login := gin.H{"username": "alice", "password": "wrong"}
+	rec := e.do(http.MethodPost, "/auth/login", "", login)
+	if rec.Header().Get("RateLimit-Limit") != "3" || rec.Header().Get("RateLimit-Remaining") != "2" || rec.Header().Get("RateLimit-Policy") != "3;w=60" {
+		t.Fatalf("rate limit headers = %v", rec.Header())
+	}
+	e.do(http.MethodPost, "/auth/login", "", login)
+	e.do(http.MethodPost, "/auth/register", "", gin.H{"username": "carol", "password": testPassword})
+	rec = e.do(http.MethodPost, "/auth/login", "", login)
+	e.expectError(rec, http.StatusTooManyRequests, "Too many requests, retry later")
+	if retry, _ := strconv.Atoi(rec.Header().Get("Retry-After")); retry < 1 || retry > 60 || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("Retry-After = %q, RateLimit-Remaining = %q", rec.Header().Get("Retry-After"), rec.Header().Get("RateLimit-Remaining"))
+	}
+	e.expect(e.do(http.MethodGet, "/user", "", nil), http.StatusTooManyRequests)
+
+	// Wallet routes are counted per user
+	aliceToken, bobToken := e.token(alice), e.token(bob)
+	e.expect(e.do(http.MethodGet, "/wallet", aliceToken, nil), http.StatusOK)
+	e.expect(e.do(http.MethodGet, "/wallet", aliceToken, nil), http.StatusOK)
+	e.expect(e.do(http.MethodGet, "/wallet", aliceToken, nil), http.StatusTooManyRequests)
+	e.expect(e.do(http.MethodGet, "/wallet", bobToken, nil), http.StatusOK)
+	// Admin routes are counted per API key, and requests without one per client
+	keyA, keyB := map[string]string{"X-API-Key": "key-a"}, map[string]string{"X-API-Key": "key-b"}
+	e.expect(e.doWithHeaders(http.MethodGet, "/v1/admin/users", admin, nil, keyA), http.StatusOK)
+	e.expect(e.doWithHeaders(http.MethodGet, "/v1/admin/users", admin, nil, keyA), http.StatusOK)
+	e.expect(e.doWithHeaders(http.MethodGet, "/v1/admin/users", admin, nil, keyA), http.StatusTooManyRequests)
+	e.expect(e.doWithHeaders(http.MethodGet, "/v1/admin/users", admin, nil, keyB), http.StatusOK)
+	e.expect(e.do(http.MethodGet, "/v1/admin/users", admin, nil), http.StatusOK)
+	for _, k := range e.mr.Keys() {
+		if strings.Contains(k, "key-a") {
+			t.Fatalf("raw API key stored in %s", k)
+		}
+	}
+	// Probes are never limited
+	e.expect(e.do(http.MethodGet, "/healthz", "", nil), http.StatusOK)
+
+	// Without Redis each instance keeps limiting on its own instead of failing
+	e.mr.Close()
+	rec = e.do(http.MethodPost, "/auth/login", "", login)
+	if rec.Code == http.StatusTooManyRequests || rec.Header().Get("RateLimit-Remaining") != "2" {
+		t.Fatalf("status = %d, headers = %v; want a fresh local window", rec.Code, rec.Header())
+	}
+	e.do(http.MethodPost, "/auth/login", "", login)
+	e.do(http.MethodPost, "/auth/login", "", login)
+	e.expect(e.do(http.MethodPost, "/auth/login", "", login), http.StatusTooManyRequests)
+}
+
+// errorEnvelope is the error body of the /v1 routes
+type errorEnvelope struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"details"`
+		Meta      map[string]any `json:"meta"`
+		RequestID string         `json:"request_id"`
+	} `json:"error"`
+}
+
+// expectCode fails the test unless rec is a /v1 error with the given status and code
+func (e *env) expectCode(rec *httptest.ResponseRecorder, status int, code string) errorEnvelope {
+	e.t.Helper()
+	e.expect(rec, status)
+	var body errorEnvelope
+	decode(e.t, rec, &body)
+	if body.Error.Code != code || body.Error.Message == "" || body.Error.RequestID == "" {
+		e.t.Fatalf("error = %+v, want code %s with a message and request ID", body.Error, code)
+	}
+	return body
+}
+
+func TestVersionedAPI(t *testing.T) {
+	e := newEnv(t)
+	_, alice := e.customer("alice", 50)
+	e.customer("bob", 0)
+	finance := e.token(e.user("fiona", domain.RoleFinance))
+
+	// Binding errors name every offending field by its JSON name
+	body := e.expectCode(e.do(http.MethodPost, "/v1/wallet/transfer", alice, gin.H{"amount": -1}), http.StatusBadRequest, "INVALID_REQUEST")
+	details := map[string]string{} // Message by field
+	for _, d := range body.Error.Details {
+		details[d.Field] = d.Message
+	}
+	if details["to_username"] != "is required" || details["amount"] != "must be greater than 0" {
+		t.Fatalf("details = %+v", body.Error.Details)
+	}
+	body = e.expectCode(e.do(http.MethodPost, "/v1/wallet/deposit", alice, gin.H{"amount": "ten"}), http.StatusBadRequest, "INVALID_REQUEST")
+	if len(body.Error.Details) != 1 || body.Error.Details[0].Field != "amount" || body.Error.Details[0].Message != "must be a number" {
+		t.Fatalf("details = %+v", body.Error.Details)
+	}
+	e.expectCode(e.do(http.MethodGet, "/v1/wallet/transactions?cursor=bogus", alice, nil), http.StatusBadRequest, "INVALID_CURSOR")
+	body = e.expectCode(e.do(http.MethodPost, "/v1/admin/wallets/1/adjustments", finance, gin.H{"direction": "credit", "amount": 5, "reason_code": "whim", "note": "n"}), http.StatusBadRequest, "UNKNOWN_REASON_CODE")
+	if body.Error.Meta["reason_codes"] == nil || len(body.Error.Details) != 1 || body.Error.Details[0].Field != "reason_code" {
+		t.Fatalf("error = %+v, want the accepted reason codes", body.Error)
+	}
+
+	// Business errors carry stable codes
+	e.expectCode(e.do(http.MethodPost, "/v1/wallet/transfer", alice, gin.H{"to_username": "bob", "amount": 500}), http.StatusBadRequest, "INSUFFICIENT_FUNDS")
+	e.expectCode(e.do(http.MethodPost, "/v1/wallet/transfer", alice, gin.H{"to_username": "nobody", "amount": 5}), http.StatusNotFound, "RECIPIENT_NOT_FOUND")
+	e.expectCode(e.do(http.MethodGet, "/v1/wallet", "", nil), http.StatusUnauthorized, "UNAUTHENTICATED")
+	e.expectCode(e.do(http.MethodGet, "/v1/admin/users", alice, nil), http.StatusForbidden, "PERMISSION_DENIED")
+	e.expectCode(e.do(http.MethodGet, "/v1/no/such/route", "", nil), http.StatusNotFound, "ROUTE_NOT_FOUND")
+
+	// Successful responses are wrapped in data
+	rec := e.do(http.MethodGet, "/v1/wallet", alice, nil)
+	e.expect(rec, http.StatusOK)
+	var wallet struct {
+		Data struct {
+			Wallet struct {
+				Balance float64 `json:"balance"`
+			} `json:"wallet"`
+		} `json:"data"`
+	}
+	decode(t, rec, &wallet)
+	if wallet.Data.Wallet.Balance != 50 || rec.Header().Get("Deprecation") != "" {
+		t.Fatalf("body = %s, Deprecation = %q", rec.Body.String(), rec.Header().Get("Deprecation"))
+	}
+
+	// Unversioned routes keep their bodies and point at their successor
+	rec = e.do(http.MethodGet, "/wallet", alice, nil)
+	e.expect(rec, http.StatusOK)
+	if rec.Header().Get("Deprecation") == "" || rec.Header().Get("Link") != `</v1/wallet>; rel="successor-version"` || !strings.HasPrefix(rec.Body.String(), `{"cached"`) {
+		t.Fatalf("headers = %v, body = %s", rec.Header(), rec.Body.String())
+	}
+	e.expectError(e.do(http.MethodPost, "/wallet/transfer", alice, gin.H{"to_username": "bob", "amount": 500}), http.StatusBadRequest, "Insufficient funds")
+	rec = e.do(http.MethodGet, "/user", "", nil)
+	if rec.Header().Get("Link") != `</v1/auth/login>; rel="successor-version"` {
+		t.Fatalf("Link = %q", rec.Header().Get("Link"))
+	}
+}
+
+func TestAdjustmentRecordsReason(t *testing.T) {
+	e := newEnv(t)
+	alice, _ := e.customer("alice", 10)
+	proposer := e.token(e.user("fiona", domain.RoleFinance))
+	approver := e.token(e.user("adam", domain.RoleAdmin))
+	var w domain.Wallet
+	if err := e.db.Where("user_id = ?", alice.ID).First(&w).Error; err != nil {
+		t.Fatalf("load wallet: %v", err)
+	}
+
+	// Adjustments always go through approval; the reason code and note must survive to the ledger
+	rec := e.do(http.MethodPost, "/admin/wallets/"+strconv.Itoa(int(w.ID))+"/adjustments", proposer, gin.H{
+		"direction": "credit", "amount": 25, "reason_code": domain.ReasonGoodwill, "note": "Delayed transfer, ticket 4812",
+	})
+	e.expect(rec, http.StatusAccepted)
+	var proposal struct {
+		Approval struct {
+			ID uint `json:"id"`
+		} `json:"approval"`
+	}
+	decode(t, rec, &proposal)
+	e.expect(e.doWithHeaders(http.MethodPost, "/admin/approvals/"+strconv.Itoa(int(proposal.Approval.ID))+"/approve", approver, gin.H{"note": "ok"}, map[string]string{
+		middleware.RequestIDHeader: "approve-1",
+		"User-Agent":               "console/2.0",
+	}), http.StatusOK)
+
+	// The adjustment is audited with the approving request's client details
+	var entry domain.AuditLog
+	if err := e.db.Where("action = ?", audit.ActionWalletAdjust).First(&entry).Error; err != nil {
+		t.Fatalf("load audit record: %v", err)
+	}
+	if entry.RequestID != "approve-1" || entry.UserAgent != "console/2.0" || entry.IP == "" {
+		t.Fatalf("audit record = %+v, want the approver's request details", entry)
+	}
+
+	var adj domain.Transaction
+	if err := e.db.Where("type = ?", domain.TxTypeAdjustment).First(&adj).Error; err != nil {
+		t.Fatalf("load adjustment: %v", err)
+	}
+	if adj.ReasonCode != domain.ReasonGoodwill || adj.Note != "Delayed transfer, ticket 4812" || adj.Amount != 25 {
+		t.Fatalf("adjustment = %+v, want the reason code and note", adj)
+	}
+	if got := e.balance(alice); got != 35 {
+		t.Fatalf("balance = %v, want 35", got)
+	}
+
+	// Adjustments are corrected with an opposite adjustment, never reversed
+	e.expectError(e.do(http.MethodPost, "/admin/transactions/"+strconv.Itoa(int(adj.ID))+"/reverse", approver, gin.H{"note": "undo"}), http.StatusConflict, "Transaction cannot be reversed")
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		_, err := ledger.Reverse(tx, adj.ID)
+		return err
+	})
+	if !errors.Is(err, ledger.ErrNotReversible) {
+		t.Fatalf("reverse adjustment = %v, want ErrNotReversible", err)
+	}
+	if got := e.balance(alice); got != 35 {
+		t.Fatalf("balance after rejected reversals = %v, want 35", got)
+	}
+}
+
+func TestLoginAfterSessionRevoke(t *testing.T) {
+	e := newEnv(t)
+	alice := e.user("alice", domain.RoleUser)
+	admin := e.token(e.user("root", domain.RoleAdmin))
+	login := func() (string, string) {
+		rec := e.do(http.MethodPost, "/auth/login", "", gin.H{"username": "alice", "password": testPassword})
+		e.expect(rec, http.StatusOK)
+		var auth struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refresh_token"`
+		}
+		decode(t, rec, &auth)
+		return auth.Token, auth.RefreshToken
+	}
+	oldAccess, oldRefresh := login()
+	e.expect(e.do(http.MethodGet, "/auth/me", oldAccess, nil), http.StatusOK)
+
+	revoke := "/admin/users/" + strconv.Itoa(int(alice.ID)) + "/sessions/revoke"
+	e.expect(e.do(http.MethodPost, revoke, admin, gin.H{"reason": "lost phone"}), http.StatusOK)
+	e.expectError(e.do(http.MethodGet, "/auth/me", oldAccess, nil), http.StatusUnauthorized, "Invalid or expired token")
+	e.expectError(e.do(http.MethodPost, "/auth/refresh", "", gin.H{"refresh_token": oldRefresh}), http.StatusUnauthorized, "Invalid or expired refresh token")
+
+	// A login in the same second as the revoke yields working tokens
+	access, refresh := login()
+	e.expect(e.do(http.MethodGet, "/auth/me", access, nil), http.StatusOK)
+	e.expect(e.do(http.MethodPost, "/auth/refresh", "", gin.H{"refresh_token": refresh}), http.StatusOK)
+}
+
+func TestReconcile(t *testing.T) {
+	e := newEnv(t)
+	alice, aliceToken := e.customer("alice", 100)
+	e.customer("bob", 0)
+	e.expect(e.do(http.MethodPost, "/wallet/transfer", aliceToken, gin.H{"to_username": "bob", "amount": 40}), http.StatusOK)
+
+	report, err := reconcile.Check(e.db)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if !report.Consistent() {
+		t.Fatalf("fresh ledger is inconsistent: %+v", report)
+	}
+
+	// Corrupt alice's balance behind the ledger's back
+	var w domain.Wallet
+	if err := e.db.Where("user_id = ?", alice.ID).First(&w).Error; err != nil {
+		t.Fatalf("load wallet: %v", err)
+	}
+	if err := e.db.Model(&w).Update("balance", 72.35).Error; err != nil {
+		t.Fatalf("corrupt balance: %v", err)
+	}
+	report, err = reconcile.Check(e.db)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if len(report.Discrepancies) != 1 || report.InvariantOK {
+		t.Fatalf("report = %+v, want one discrepancy and a broken invariant", report)
+	}
+	d := report.Discrepancies[0]
+	if d.WalletID != w.ID || d.Balance != 72.35 || d.Computed != 60 || math.Round(d.Difference*100) != 1235 {
+		t.Fatalf("discrepancy = %+v, want 72.35 stored against 60 computed", d)
+	}
+
+	posted, err := reconcile.Repair(e.db, w.ID, audit.Entry{UserAgent: "test"})
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if posted == nil || posted.ReasonCode != domain.ReasonReconciliation || math.Round(posted.Amount*100) != 1235 || posted.ToWalletID == nil || *posted.ToWalletID != w.ID {
+		t.Fatalf("correction = %+v, want 12.35 credited to wallet %d", posted, w.ID)
+	}
+	var corrections, audits int64
+	e.db.Model(&domain.Transaction{}).Where("reason_code = ?", domain.ReasonReconciliation).Count(&corrections)
+	e.db.Model(&domain.AuditLog{}).Where("action = ? AND target_id = ?", audit.ActionLedgerReconcile, strconv.Itoa(int(w.ID))).Count(&audits)
+	if corrections != 1 || audits != 1 {
+		t.Fatalf("corrections = %d, audit entries = %d, want 1 each", corrections, audits)
+	}
+	// The stored balance is what the customer was shown, so it stays
+	if got := e.balance(alice); got != 72.35 {
+		t.Fatalf("balance = %v, want 72.35", got)
+	}
+
+	report, err = reconcile.Check(e.db)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	if !report.Consistent() {
+		t.Fatalf("repaired ledger is inconsistent: %+v", report)
+	}
+	if again, err := reconcile.Repair(e.db, w.ID, audit.Entry{UserAgent: "test"}); err != nil || again != nil {
+		t.Fatalf("second repair = %+v, %v, want nothing to do", again, err)
+	}
+}
+
+func TestBalanceAt(t *testing.T) {
+	e := newEnv(t)
+	_, aliceToken := e.customer("alice", 0)
+	_, bobToken := e.customer("bob", 0)
+	_, carolToken := e.customer("carol", 0)
+	e.expect(e.do(http.MethodPost, "/wallet/deposit", aliceToken, gin.H{"amount": 100}), http.StatusOK)
+	e.expect(e.do(http.MethodPost, "/wallet/transfer", aliceToken, gin.H{"to_username": "bob", "amount": 40}), http.StatusOK)
+	e.expect(e.do(http.MethodPost, "/wallet/deposit", aliceToken, gin.H{"amount": 5}), http.StatusOK)
+
+	// Pin the postings to known times: the deposit at t1, the transfer and second deposit both at t2
+	t1 := time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC).UnixMilli()
+	t2 := t1 + time.Minute.Milliseconds()
+	var txs []domain.Transaction
+	e.db.Order("id asc").Find(&txs)
+	if len(txs) != 3 {
+		t.Fatalf("got %d transactions, want 3", len(txs))
+	}
+	for i, at := range []int64{t1, t2, t2} {
+		e.db.Model(&txs[i]).Update("created_at", at)
+	}
+
+	balanceAt := func(token string, at int64) float64 {
+		t.Helper()
+		path := "/wallet/balance"
+		if at >= 0 {
+			path += "?at=" + strconv.FormatInt(at, 10)
+		}
+		rec := e.do(http.MethodGet, path, token, nil)
+		e.expect(rec, http.StatusOK)
+		var body struct {
+			Balance float64 `json:"balance"`
+		}
+		decode(t, rec, &body)
+		return body.Balance
+	}
+	cases := []struct {
+		name  string
+		token string
+		at    int64
+		want  float64
+	}{
+		{"before any posting", aliceToken, t1 - 1, 0},
+		{"exactly on the deposit", aliceToken, t1, 100},
+		{"between postings", aliceToken, t1 + 1, 100},
+		{"just before the transfer", aliceToken, t2 - 1, 100},
+		{"exactly on two postings in the same millisecond", aliceToken, t2, 65},
+		{"after every posting", aliceToken, t2 + 1, 65},
+		{"now", aliceToken, -1, 65},
+		{"receiver before the transfer", bobToken, t2 - 1, 0},
+		{"receiver on the transfer", bobToken, t2, 40},
+		{"wallet without postings", carolToken, t2, 0},
+	}
+	for _, tc := range cases {
+		if got := balanceAt(tc.token, tc.at); got != tc.want {
+			t.Errorf("%s: balance = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	e.expectError(e.do(http.MethodGet, "/wallet/balance?at=yesterday", aliceToken, nil), http.StatusBadRequest, "Invalid at; use RFC 3339 or epoch milliseconds")
+
+	// Forget the recorded balances, except bob's side of the transfer, and rerun the backfill migration
+	e.db.Model(&domain.Transaction{}).Where("1 = 1").Updates(map[string]any{"from_balance_after": nil, "to_balance_after": nil})
+	e.db.Model(&txs[1]).Update("to_balance_after", 40)
+	m, err := database.Migrator(e.db)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := m.Goto(20261018000001); err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	e.db.Order("id asc").Find(&txs)
+	deposit, transfer, second := txs[0], txs[1], txs[2]
+	if deposit.FromBalanceAfter != nil || deposit.ToBalanceAfter == nil || *deposit.ToBalanceAfter != 100 {
+		t.Errorf("deposit balances = %v, %v, want none and 100", deposit.FromBalanceAfter, deposit.ToBalanceAfter)
+	}
+	if transfer.FromBalanceAfter == nil || *transfer.FromBalanceAfter != 60 || transfer.ToBalanceAfter == nil || *transfer.ToBalanceAfter != 40 {
+		t.Errorf("transfer balances = %v, %v, want 60 and 40", transfer.FromBalanceAfter, transfer.ToBalanceAfter)
+	}
+	if second.ToBalanceAfter == nil || *second.ToBalanceAfter != 65 {
+		t.Errorf("second deposit balance = %v, want 65", second.ToBalanceAfter)
+	}
+	if got, err := ledger.BalanceBefore(e.db, *transfer.ToWalletID, t2+1); err != nil || got != 40 {
+		t.Errorf("bob's backfilled balance = %v, %v, want 40", got, err)
+	}
+	if n, err := ledger.BackfillBalances(e.db); err != nil || n != 0 {
+		t.Errorf("second backfill updated %d, %v, want nothing", n, err)
+	}
+}
+
+func TestExportWriteDeadline(t *testing.T) {
+	r := gin.New()
+	// A slow export writes after the server's write timeout has passed
+	slow := func(c *gin.Context) {
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	}
+	r.GET("/plain", slow)
+	r.GET("/export", middleware.WriteDeadlineMiddleware(5*time.Second), slow)
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config = NewHTTPServer(&config.Config{HTTPReadTimeout: time.Second, HTTPWriteTimeout: 50 * time.Millisecond}, r)
+	srv.Start()
+	defer srv.Close()
+
+	if res, err := http.Get(srv.URL + "/plain"); err == nil {
+		res.Body.Close()
+		t.Fatal("response outlived the write timeout")
+	}
+	res, err := http.Get(srv.URL + "/export")
+	if err != nil {
+		t.Fatalf("export cut off: %v", err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK || string(body) != "done" {
+		t.Fatalf("export = %d %q", res.StatusCode, body)
+	}
+}
+
+func TestExportBatches(t *testing.T) {
+	e := newEnv(t)
+	w := e.wallet(e.user("alice", domain.RoleUser), 0)
+	admin := e.token(e.user("root", domain.RoleSuperAdmin))
+	// More rows than one batch, with postings sharing a timestamp across the batch boundary
+	const n = 1203
+	base := time.Now().Add(-time.Hour).UnixMilli()
+	txs := make([]domain.Transaction, n)
+	records := make([]domain.AuditLog, n)
+	for i := range txs {
+		txs[i] = domain.Transaction{ToWalletID: &w.ID, Amount: 1, Type: domain.TxTypeDeposit, CreatedAt: base + int64(i/7)}
+		records[i] = domain.AuditLog{Action: "test.batch", TargetType: "test", CreatedAt: base, Hash: "batch-" + strconv.Itoa(i)}
+	}
+	// Insert in reverse so ID order differs from time order within a timestamp
+	for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+		txs[i], txs[j] = txs[j], txs[i]
+	}
+	if err := e.db.CreateInBatches(txs, 200).Error; err != nil {
+		t.Fatalf("seed transactions: %v", err)
+	}
+	if err := e.db.CreateInBatches(records, 200).Error; err != nil {
+		t.Fatalf("seed audit records: %v", err)
+	}
+
+	rec := e.do(http.MethodGet, "/v1/admin/transactions/export?format=jsonl&wallet_id="+strconv.Itoa(int(w.ID)), admin, nil)
+	e.expect(rec, http.StatusOK)
+	type key struct {
+		at int64
+		id uint
+	}
+	var prev key
+	seen := map[uint]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n") {
+		var row struct {
+			ID             uint      `json:"id"`
+			CreatedAt      time.Time `json:"created_at"`
+			RunningBalance float64   `json:"running_balance"`
+		}
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		k := key{row.CreatedAt.UnixMilli(), row.ID}
+		if k.at < prev.at || (k.at == prev.at && k.id <= prev.id) {
+			t.Fatalf("row %+v after %+v", k, prev)
+		}
+		prev, seen[row.ID] = k, true
+		if row.RunningBalance != float64(len(seen)) {
+			t.Fatalf("running balance %v at row %d", row.RunningBalance, len(seen))
+		}
+	}
+	if len(seen) != n {
+		t.Fatalf("exported %d transactions, want %d", len(seen), n)
+	}
+
+	rec = e.do(http.MethodGet, "/v1/admin/audit/export?action=test.batch", admin, nil)
+	e.expect(rec, http.StatusOK)
+	var lastID uint
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	for _, line := range lines {
+		var r struct {
+			ID uint `json:"id"`
+		}
+		if err := json.Unmarshal([]byte(line), &r); err != nil || r.ID <= lastID {
+			t.Fatalf("audit record %q after %d: %v", line, lastID, err)
+		}
+		lastID = r.ID
+	}
+	if len(lines) != n {
+		t.Fatalf("exported %d audit records, want %d", len(lines), n)
+	}
+
+	// The only connection is free while rows are handed out, so other requests are not blocked
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	store := repository.NewStore(e.db)
+	err := store.Audit().Each(ctx, repository.AuditFilter{Actions: []string{"test.batch"}}, func(*domain.AuditLog) error {
+		_, err := store.Users().FindByID(ctx, w.UserID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("query during export: %v", err)
+	}
+}
+
+func TestStatementCatchUp(t *testing.T) {
+	e := newEnv(t)
+	alice, token := e.customer("alice", 0)
+	// One deposit in each month from June to September 2026
+	months := []time.Time{
+		time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for range months {
+		e.expect(e.do(http.MethodPost, "/wallet/deposit", token, gin.H{"amount": 10}), http.StatusOK)
+	}
+	var txs []domain.Transaction
+	e.db.Order("id asc").Find(&txs)
+	for i, m := range months {
+		e.db.Model(&txs[i]).Update("created_at", m.Add(48*time.Hour).UnixMilli())
+	}
+	var w domain.Wallet
+	e.db.Where("user_id = ?", alice.ID).First(&w)
+	periods := func() string {
+		var starts []int64
+		e.db.Model(&domain.Statement{}).Where("wallet_id = ?", w.ID).Order("period_start asc").Pluck("period_start", &starts)
+		var out []string
+		for _, s := range starts {
+			out = append(out, time.UnixMilli(s).UTC().Format("2006-01"))
+		}
+		return strings.Join(out, ",")
+	}
+
+	// The June statement was generated, then no instance ran until mid-October
+	if _, err := statement.GenerateMonth(e.db, months[0], "USD"); err != nil {
+		t.Fatalf("generate June: %v", err)
+	}
+	// Right after September ends its postings may still be committing, so it waits
+	if _, err := statement.CatchUp(e.db, time.Date(2026, 10, 1, 0, 5, 0, 0, time.UTC), "USD"); err != nil {
+		t.Fatalf("catch up: %v", err)
+	}
+	if got := periods(); got != "2026-06,2026-07,2026-08" {
+		t.Fatalf("statements = %s, want June to August", got)
+	}
+	n, err := statement.CatchUp(e.db, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), "USD")
+	if err != nil {
+		t.Fatalf("catch up: %v", err)
+	}
+	if got := periods(); n != 1 || got != "2026-06,2026-07,2026-08,2026-09" {
+		t.Fatalf("statements = %s after storing %d, want June to September", got, n)
+	}
+	var sept domain.Statement
+	e.db.Where("wallet_id = ?", w.ID).Order("period_start desc").First(&sept)
+	if sept.OpeningBalance != 30 || sept.ClosingBalance != 40 {
+		t.Fatalf("September = %v to %v, want 30 to 40", sept.OpeningBalance, sept.ClosingBalance)
+	}
+}
+
+func TestAuditChainLock(t *testing.T) {
+	e := newEnv(t)
+	// Concurrent first appends to an empty log must still form one chain
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- audit.Record(e.db, audit.Entry{Action: "test.append", TargetID: strconv.Itoa(i)})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	res, err := audit.Verify(e.db)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !res.Valid || res.Checked != 20 {
+		t.Fatalf("verify = %+v, want 20 chained records", res)
+	}
+
+	// Without the lock row appends cannot be serialized, so they are refused
+	e.db.Exec("DELETE FROM audit_chain_lock")
+	if err := audit.Record(e.db, audit.Entry{Action: "test.append"}); !errors.Is(err, audit.ErrChainLockMissing) {
+		t.Fatalf("record without lock row = %v, want ErrChainLockMissing", err)
+	}
+}
+
+func TestApprovalAlongsideLogins(t *testing.T) {
+	e := newEnv(t)
+	proposer := e.user("fiona", domain.RoleAdmin)
+	approver := e.user("adam", domain.RoleAdmin)
+	alice := e.user("alice", domain.RoleUser)
+
+	// The action stands in for a reversal or adjustment: it appends to the audit log inside the
+	// approval transaction while a login that has already checked its password appends on its own
+	logins := make(chan error, 1)
+	approval.Register("test.audit_race", approval.Action{
+		Permission: domain.PermTxAdjust,
+		Execute: func(tx *gorm.DB, action *domain.PendingAction, meta audit.Entry) (any, error) {
+			go func() {
+				logins <- audit.Record(e.db, audit.Entry{ActorID: &alice.ID, Action: audit.ActionLogin, TargetType: "user", TargetID: "alice"})
+			}()
+			time.Sleep(50 * time.Millisecond) // Let the login queue for the database
+			meta.Action = "test.execute"
+			return nil, audit.Record(tx, meta)
+		},
+	})
+	action, err := approval.Propose(e.db, approval.Proposal{Type: "test.audit_race"}, audit.Entry{ActorID: &proposer.ID}, time.Hour)
+	if err != nil {
+		t.Fatalf("propose: %v", err)
+	}
+
+	approved := make(chan error, 1)
+	go func() {
+		_, err := approval.Approve(e.db, action.ID, domain.RoleAdmin, "ok", audit.Entry{ActorID: &approver.ID})
+		approved <- err
+	}()
+	for _, ch := range []chan error{approved, logins} {
+		select {
+		case err := <-ch:
+			if err != nil {
+				t.Fatalf("append: %v", err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("approval and login deadlocked")
+		}
+	}
+	res, err := audit.Verify(e.db)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !res.Valid || res.Checked != 4 {
+		t.Fatalf("verify = %+v, want 4 chained records", res)
+	}
+}
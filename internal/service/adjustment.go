@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"                           // Request-scoped cancellation
+	"errors"                            // Error inspection
+	"strconv"                           // String conversion
+	"wallet_system/internal/approval"   // Maker-checker workflow
+	"wallet_system/internal/audit"      // Audit log
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/ledger"     // Ledger postings
+	"wallet_system/internal/repository" // Persistence
+)
+
+// Adjustment directions
+const (
+	AdjustmentCredit = "credit" // Add money to the wallet
+	AdjustmentDebit  = "debit"  // Take money from the wallet
+)
+
+// Adjustment describes a manual credit or debit of a wallet
+type Adjustment struct {
+	Direction  string  // credit or debit
+	Amount     float64 // Amount to move
+	ReasonCode string  // One of domain.AdjustmentReasons
+	Note       string  // Free-text explanation
+}
+
+// adjustmentPayload holds the parameters of a wallet.adjust action
+type adjustmentPayload struct {
+	WalletID   uint    `json:"wallet_id"`   // Wallet to adjust
+	Direction  string  `json:"direction"`   // credit or debit
+	Amount     float64 `json:"amount"`      // Amount to move
+	ReasonCode string  `json:"reason_code"` // Reason code
+	Note       string  `json:"note"`        // Note
+}
+
+// adjustmentResult is the outcome of an adjustment
+type adjustmentResult struct {
+	TransactionID uint    `json:"transaction_id"` // Adjustment transaction
+	WalletID      uint    `json:"wallet_id"`      // Adjusted wallet
+	UserID        uint    `json:"user_id"`        // Owner of the adjusted wallet
+	Direction     string  `json:"direction"`      // credit or debit
+	Amount        float64 `json:"amount"`         // Amount moved
+}
+
+// adjustWallet posts an adjustment against the system adjustments account, using the
+// same wallet locks as transfers, and records it in the audit log
+func adjustWallet(ctx context.Context, tx repository.Store, p adjustmentPayload, meta audit.Entry) (*adjustmentResult, error) {
+	system, err := tx.Wallets().FindSystem(ctx, domain.SystemAccountAdjustments) // Counterparty account
+	if err != nil {
+		return nil, err
+	}
+	if p.WalletID == system.ID {
+		return nil, ErrSystemWallet // Never adjust the counterparty itself
+	}
+	posting := ledger.Posting{
+		Amount:         p.Amount,                // Amount to move
+		Type:           domain.TxTypeAdjustment, // Transaction type
+		ReasonCode:     p.ReasonCode,            // Reason code
+		Note:           p.Note,                  // Note
+		Administrative: true,                    // Corrections apply to frozen wallets too
+	}
+	// Credits move money from the system account, debits move it back
+	if p.Direction == AdjustmentCredit {
+		posting.FromWalletID, posting.ToWalletID = &system.ID, &p.WalletID
+	} else {
+		posting.FromWalletID, posting.ToWalletID = &p.WalletID, &system.ID
+	}
+	res, err := tx.Transactions().Post(ctx, posting) // Lock, check and post
+	if err != nil {
+		return nil, err
+	}
+	target := res.Wallets[p.WalletID] // Adjusted wallet
+	if target.IsSystem {
+		return nil, ErrSystemWallet // Never adjust another system account
+	}
+	result := &adjustmentResult{
+		TransactionID: res.Transaction.ID, // Adjustment transaction
+		WalletID:      p.WalletID,         // Adjusted wallet
+		UserID:        target.UserID,      // Owner
+		Direction:     p.Direction,        // Direction
+		Amount:        p.Amount,           // Amount
+	}
+	entry := meta                                            // Actor and client details
+	entry.Action = audit.ActionWalletAdjust                  // Action name
+	entry.TargetType = "wallet"                              // Target kind
+	entry.TargetID = strconv.Itoa(int(p.WalletID))           // Target wallet
+	entry.Before = map[string]any{"balance": target.Balance} // Balance before
+	entry.After = p                                          // Adjustment applied
+	return result, tx.Audit().Record(ctx, entry)
+}
+
+// ProposeAdjustment submits a manual credit or debit of a wallet. Every adjustment needs a
+// second admin's approval before it is posted.
+func (s *transactionService) ProposeAdjustment(ctx context.Context, walletID uint, a Adjustment, meta audit.Entry) (*domain.PendingAction, error) {
+	if !domain.IsValidAdjustmentReason(a.ReasonCode) {
+		return nil, ErrUnknownReason
+	}
+	wallet, err := s.store.Wallets().FindByID(ctx, walletID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrWalletNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if wallet.IsSystem {
+		return nil, ErrSystemWallet
+	}
+	return s.store.Approvals().Propose(ctx, approval.Proposal{
+		Type: ActionTypeWalletAdjust, // Action type
+		Payload: adjustmentPayload{
+			WalletID:   wallet.ID,    // Wallet to adjust
+			Direction:  a.Direction,  // credit or debit
+			Amount:     a.Amount,     // Amount
+			ReasonCode: a.ReasonCode, // Reason code
+			Note:       a.Note,       // Note
+		},
+		TargetType: "wallet",                     // Target kind
+		TargetID:   strconv.Itoa(int(wallet.ID)), // Target wallet
+		Note:       a.Note,                       // Reason
+	}, meta, s.approvalTTL)
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"                           // Request-scoped cancellation
+	"encoding/json"                     // Decoding action payloads
+	"errors"                            // Error inspection
+	"strconv"                           // String conversion
+	"wallet_system/internal/approval"   // Maker-checker workflow
+	"wallet_system/internal/audit"      // Audit log
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/repository" // Persistence
+)
+
+// Action types handled by the approval workflow
+const (
+	ActionTypeRoleAssign   = "role.assign"         // Grant or revoke a role
+	ActionTypeTxReverse    = "transaction.reverse" // Reverse a transaction above the threshold
+	ActionTypeWalletAdjust = "wallet.adjust"       // Manual adjustment
+)
+
+// actionTypes lists every action type registered by RegisterApprovalActions
+var actionTypes = []string{ActionTypeRoleAssign, ActionTypeTxReverse, ActionTypeWalletAdjust}
+
+// maxApprovals caps the pending actions listed at once
+const maxApprovals = 100
+
+// roleAssignPayload holds the parameters of a role.assign action
+type roleAssignPayload struct {
+	UserID uint   `json:"user_id"` // Target user
+	Role   string `json:"role"`    // New role
+}
+
+// roleAssignResult is the outcome of a role.assign action
+type roleAssignResult struct {
+	UserID       uint   `json:"user_id"`       // Target user
+	PreviousRole string `json:"previous_role"` // Role before
+	Role         string `json:"role"`          // Role after
+}
+
+// txReversePayload holds the parameters of a transaction.reverse action
+type txReversePayload struct {
+	TransactionID uint `json:"transaction_id"` // Transaction to reverse
+}
+
+// RegisterApprovalActions registers the operations that require four-eyes approval. Caches
+// touched by an approved action are dropped from cache once it commits.
+func RegisterApprovalActions(cache Cache) {
+	approval.Register(ActionTypeRoleAssign, approval.Action{
+		Permission: domain.PermRoleAssign, // Only role managers may propose and approve
+		Execute: repository.ApprovalExecutor(func(ctx context.Context, tx repository.Store, action *domain.PendingAction, meta audit.Entry) (any, error) {
+			var p roleAssignPayload // Decode parameters
+			if err := json.Unmarshal([]byte(action.Payload), &p); err != nil {
+				return nil, err
+			}
+			return assignRole(ctx, tx, p, meta)
+		}),
+		OnCommit: func(result any) {
+			// Drop the cached role so outstanding tokens are re-checked immediately
+			dropUserState(context.Background(), cache, result.(*roleAssignResult).UserID)
+		},
+	})
+	approval.Register(ActionTypeTxReverse, approval.Action{
+		Permission: domain.PermTxReverse, // Only reversers may propose and approve
+		Execute: repository.ApprovalExecutor(func(ctx context.Context, tx repository.Store, action *domain.PendingAction, meta audit.Entry) (any, error) {
+			var p txReversePayload // Decode parameters
+			if err := json.Unmarshal([]byte(action.Payload), &p); err != nil {
+				return nil, err
+			}
+			return reverseTransaction(ctx, tx, p.TransactionID, meta)
+		}),
+		OnCommit: func(result any) {
+			// Balances changed for the owners of both wallets
+			InvalidateWallets(context.Background(), cache, result.(*ReversalResult).UserIDs...)
+		},
+	})
+	approval.Register(ActionTypeWalletAdjust, approval.Action{
+		Permission: domain.PermTxAdjust, // Only finance may propose and approve
+		Execute: repository.ApprovalExecutor(func(ctx context.Context, tx repository.Store, action *domain.PendingAction, meta audit.Entry) (any, error) {
+			var p adjustmentPayload // Decode parameters
+			if err := json.Unmarshal([]byte(action.Payload), &p); err != nil {
+				return nil, err
+			}
+			return adjustWallet(ctx, tx, p, meta)
+		}),
+		OnCommit: func(result any) {
+			InvalidateWallets(context.Background(), cache, result.(*adjustmentResult).UserID) // Balance changed
+		},
+	})
+}
+
+// assignRole changes a user's role and records it in the audit log. meta carries the approver and client details.
+func assignRole(ctx context.Context, tx repository.Store, p roleAssignPayload, meta audit.Entry) (*roleAssignResult, error) {
+	// The approver cannot approve a change to their own role
+	if isActor(meta, p.UserID) {
+		return nil, approval.ErrSelfApproval
+	}
+	user, err := tx.Users().FindByID(ctx, p.UserID) // Fetch target user
+	if err != nil {
+		return nil, err
+	}
+	result := &roleAssignResult{UserID: user.ID, PreviousRole: user.Role, Role: p.Role} // Outcome
+	if err := tx.Users().SetRole(ctx, user.ID, p.Role); err != nil {
+		return nil, err
+	}
+	entry := meta                                              // Approver applies the change
+	entry.Action = audit.ActionRoleAssign                      // Action name
+	entry.TargetType = "user"                                  // Target kind
+	entry.TargetID = strconv.Itoa(int(user.ID))                // Target user
+	entry.Before = map[string]any{"role": result.PreviousRole} // Role before
+	entry.After = map[string]any{"role": result.Role}          // Role after
+	return result, tx.Audit().Record(ctx, entry)
+}
+
+// ApprovalService covers deciding pending actions. Callers only see the action types their role
+// holds the permission for.
+type ApprovalService interface {
+	List(ctx context.Context, role, status string) ([]domain.PendingAction, error)                                   // Latest actions in a status
+	Get(ctx context.Context, role string, id uint) (*domain.PendingAction, error)                                    // One action
+	Approve(ctx context.Context, role string, id uint, note string, meta audit.Entry) (*domain.PendingAction, error) // Approve and execute an action
+	Reject(ctx context.Context, role string, id uint, note string, meta audit.Entry) (*domain.PendingAction, error)  // Reject an action
+}
+
+// approvalService is the default ApprovalService
+type approvalService struct {
+	approvals repository.ApprovalRepository // Pending actions
+}
+
+// NewApprovalService returns an ApprovalService
+func NewApprovalService(store repository.Store) ApprovalService {
+	return &approvalService{approvals: store.Approvals()}
+}
+
+// canDecide reports whether role holds the permission of an action type
+func canDecide(role, actionType string) bool {
+	def, ok := approval.Lookup(actionType)
+	return ok && domain.HasPermission(role, def.Permission)
+}
+
+// List returns the latest actions in the given status that role may decide
+func (s *approvalService) List(ctx context.Context, role, status string) ([]domain.PendingAction, error) {
+	var types []string // Action types the role may decide
+	for _, t := range actionTypes {
+		if canDecide(role, t) {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return nil, nil
+	}
+	return s.approvals.List(ctx, status, types, maxApprovals)
+}
+
+// Get returns a single action; actions role could not decide are reported as approval.ErrNotFound
+func (s *approvalService) Get(ctx context.Context, role string, id uint) (*domain.PendingAction, error) {
+	action, err := s.approvals.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, approval.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	// Hide actions the caller could not decide
+	if !canDecide(role, action.Type) {
+		return nil, approval.ErrNotFound
+	}
+	return action, nil
+}
+
+// Approve executes a pending action on behalf of a second admin. Workflow errors such as
+// approval.ErrSelfApproval are passed through.
+func (s *approvalService) Approve(ctx context.Context, role string, id uint, note string, meta audit.Entry) (*domain.PendingAction, error) {
+	return s.approvals.Approve(ctx, id, role, note, meta)
+}
+
+// Reject closes a pending action without executing it
+func (s *approvalService) Reject(ctx context.Context, role string, id uint, note string, meta audit.Entry) (*domain.PendingAction, error) {
+	return s.approvals.Reject(ctx, id, role, note, meta)
+}
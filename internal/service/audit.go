@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"                           // Request-scoped cancellation
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/repository" // Persistence
+)
+
+// Audit listing page sizes
+const (
+	DefaultAuditPageSize = 50  // Page size when none is given
+	MaxAuditPageSize     = 200 // Largest page size accepted
+)
+
+// AuditList is one page of the audit log, newest first
+type AuditList struct {
+	Records    []domain.AuditLog // Records on the page
+	Page       int               // Current page
+	PageSize   int               // Page size
+	Total      int64             // Total number of matching records
+	TotalPages int               // Total pages
+}
+
+// AuditService covers reading the audit log
+type AuditService interface {
+	List(ctx context.Context, f repository.AuditFilter, page, pageSize int) (*AuditList, error)  // One page of records
+	Export(ctx context.Context, f repository.AuditFilter, fn func(*domain.AuditLog) error) error // Every matching record in chain order, until fn fails
+}
+
+// auditService is the default AuditService
+type auditService struct {
+	audit repository.AuditRepository // Audit log
+}
+
+// NewAuditService returns an AuditService
+func NewAuditService(store repository.Store) AuditService {
+	return &auditService{audit: store.Audit()}
+}
+
+// List returns one page of the records matching f
+func (s *auditService) List(ctx context.Context, f repository.AuditFilter, page, pageSize int) (*AuditList, error) {
+	records, total, err := s.audit.List(ctx, f, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditList{
+		Records:    records,                                // Records on the page
+		Page:       page,                                   // Current page
+		PageSize:   pageSize,                               // Page size
+		Total:      total,                                  // Total number of records
+		TotalPages: (int(total) + pageSize - 1) / pageSize, // Total pages
+	}, nil
+}
+
+// Export calls fn for every record matching f, oldest first, so the chain can be verified offline
+func (s *auditService) Export(ctx context.Context, f repository.AuditFilter, fn func(*domain.AuditLog) error) error {
+	return s.audit.Each(ctx, f, fn)
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"                           // Request-scoped cancellation
+	"errors"                            // Error inspection
+	"regexp"                            // Username validation
+	"strconv"                           // String conversion
+	"strings"                           // String manipulation
+	"wallet_system/internal/audit"      // Audit log
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/repository" // Persistence
+	"wallet_system/internal/utils"      // Tokens and sessions
+
+	"github.com/redis/go-redis/v9" // Redis client
+	"github.com/sirupsen/logrus"   // Logging library
+	"golang.org/x/crypto/bcrypt"   // Password hashing
+)
+
+// usernamePattern matches valid usernames: alphabetic characters only
+var usernamePattern = regexp.MustCompile(`^[A-Za-z]+$`)
+
+// isValidPassword checks if the password length is between 8 and 15 characters
+func isValidPassword(password string) bool {
+	return len(password) >= 8 && len(password) <= 15
+}
+
+// Tokens is a freshly issued access and refresh token pair
+type Tokens struct {
+	AccessToken  string // JWT access token
+	RefreshToken string // JWT refresh token
+	ExpiresIn    int64  // Access token lifetime in seconds
+}
+
+// AuthService covers registration, logins and the sessions they open
+type AuthService interface {
+	Register(ctx context.Context, username, password string) error                           // Create a customer account
+	Login(ctx context.Context, username, password string, meta audit.Entry) (*Tokens, error) // Check credentials and open a session
+	Refresh(ctx context.Context, refreshToken string) (*Tokens, error)                       // Exchange a refresh token for a new pair
+	Logout(ctx context.Context, access *utils.Claims, refreshToken string) error             // Revoke the access token and, if given, the refresh token
+	Me(ctx context.Context, userID uint) (*domain.User, error)                               // The caller's account with their wallet
+	ResetPassword(ctx context.Context, token, newPassword string, meta audit.Entry) error    // Set a new password with a reset token
+}
+
+// authService is the default AuthService
+type authService struct {
+	users    repository.UserRepository  // Users
+	audit    repository.AuditRepository // Audit log
+	sessions *redis.Client              // Refresh tokens, revocations and reset tokens
+	secret   string                     // Key signing tokens
+}
+
+// NewAuthService returns an AuthService that keeps sessions in Redis and signs tokens with secret
+func NewAuthService(store repository.Store, sessions *redis.Client, secret string) AuthService {
+	return &authService{users: store.Users(), audit: store.Audit(), sessions: sessions, secret: secret}
+}
+
+// Register creates a user with a lowercase username and a hashed password
+func (s *authService) Register(ctx context.Context, username, password string) error {
+	if !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	if !isValidPassword(password) {
+		return ErrInvalidPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	// Lowercase usernames keep them unique regardless of case
+	user := &domain.User{Username: strings.ToLower(username), Password: string(hash)}
+	if err := s.users.Create(ctx, user); err != nil {
+		return ErrUsernameTaken // The unique index rejected a duplicate
+	}
+	return nil
+}
+
+// issueTokens creates a token pair for a user and records the refresh token
+func (s *authService) issueTokens(ctx context.Context, user *domain.User) (*Tokens, error) {
+	version, err := utils.SessionVersion(ctx, s.sessions, user.ID) // Tokens are tied to the current session version
+	if err != nil {
+		return nil, err
+	}
+	pair, err := utils.GenerateTokenPair(user.ID, user.Role, version, s.secret)
+	if err != nil {
+		return nil, err
+	}
+	// Record the refresh token so it can be rotated and revoked
+	if err := utils.StoreRefreshToken(ctx, s.sessions, pair.RefreshClaims); err != nil {
+		return nil, err
+	}
+	return &Tokens{
+		AccessToken:  pair.AccessToken,                      // Access token
+		RefreshToken: pair.RefreshToken,                     // Refresh token
+		ExpiresIn:    int64(utils.AccessTokenTTL.Seconds()), // Access token lifetime
+	}, nil
+}
+
+// recordLogin writes a login attempt to the audit log; failures are logged but do not block the login
+func (s *authService) recordLogin(ctx context.Context, meta audit.Entry, username string, user *domain.User) {
+	entry := meta             // Client details
+	entry.TargetType = "user" // Logins target a user account
+	entry.TargetID = username // Username that was attempted
+	if user != nil {
+		entry.Action = audit.ActionLogin // Successful login
+		entry.ActorID = &user.ID         // The user is the actor
+	} else {
+		entry.Action = audit.ActionLoginFailed // Failed login
+	}
+	if err := s.audit.Record(ctx, entry); err != nil {
+		// Log the failure with context
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"action": entry.Action, // Action that failed to record
+			"error":  err.Error(),  // Error message
+		}).Error("Failed to record audit entry")
+	}
+}
+
+// Login checks the credentials and issues a token pair. Every attempt is audited, and it
+// counts as successful only once the tokens exist.
+func (s *authService) Login(ctx context.Context, username, password string, meta audit.Entry) (*Tokens, error) {
+	username = strings.ToLower(username) // Usernames are stored lowercase
+	user, err := s.users.FindByUsername(ctx, username)
+	if err != nil {
+		s.recordLogin(ctx, meta, username, nil) // Record the failed attempt
+		return nil, ErrInvalidCredentials
+	}
+	// Compare provided password with stored hash
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		s.recordLogin(ctx, meta, username, nil)
+		return nil, ErrInvalidCredentials
+	}
+	// Closed accounts cannot log in
+	if user.Status == domain.StatusClosed {
+		s.recordLogin(ctx, meta, username, nil)
+		return nil, ErrAccountClosed
+	}
+	// Users flagged by an admin must reset their password first
+	if user.MustResetPassword {
+		s.recordLogin(ctx, meta, username, nil)
+		return nil, ErrPasswordResetRequired
+	}
+	tokens, err := s.issueTokens(ctx, user)
+	if err != nil {
+		s.recordLogin(ctx, meta, username, nil) // No session was issued, so the attempt failed
+		return nil, err
+	}
+	s.recordLogin(ctx, meta, username, user)
+	return tokens, nil
+}
+
+// Refresh exchanges a valid refresh token for a new token pair. Refresh tokens are single use:
+// the presented token is revoked and a new one issued.
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	claims, err := utils.ParseTokenOfType(refreshToken, utils.TokenTypeRefresh, s.secret)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	// Consume the refresh token so it cannot be replayed
+	valid, err := utils.ConsumeRefreshToken(ctx, s.sessions, claims)
+	if err != nil {
+		return nil, ErrSessionStore
+	}
+	if !valid {
+		return nil, ErrInvalidRefreshToken // Already used or revoked
+	}
+	// Reject refresh tokens issued before the user's sessions were revoked
+	if revoked, err := utils.IsTokenRevoked(ctx, s.sessions, claims); err != nil || revoked {
+		return nil, ErrInvalidRefreshToken
+	}
+	// Make sure the user still exists and is not closed
+	user, err := s.users.FindByID(ctx, claims.UserID)
+	if err != nil || user.Status == domain.StatusClosed {
+		return nil, ErrInvalidRefreshToken
+	}
+	return s.issueTokens(ctx, user)
+}
+
+// Logout revokes the access token used for the request and the refresh token, if it belongs to
+// the same user
+func (s *authService) Logout(ctx context.Context, access *utils.Claims, refreshToken string) error {
+	if err := utils.RevokeAccessToken(ctx, s.sessions, access); err != nil {
+		return ErrSessionStore
+	}
+	if refreshToken != "" {
+		refresh, err := utils.ParseTokenOfType(refreshToken, utils.TokenTypeRefresh, s.secret)
+		if err == nil && refresh.UserID == access.UserID {
+			_, _ = utils.ConsumeRefreshToken(ctx, s.sessions, refresh) // Delete the refresh token
+		}
+	}
+	return nil
+}
+
+// Me returns the user with their wallet, if created
+func (s *authService) Me(ctx context.Context, userID uint) (*domain.User, error) {
+	user, err := s.users.FindWithWallet(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// ResetPassword spends a reset token issued by an admin to set a new password, which also
+// lifts the block on logging in
+func (s *authService) ResetPassword(ctx context.Context, token, newPassword string, meta audit.Entry) error {
+	// Validate password length before spending the token
+	if !isValidPassword(newPassword) {
+		return ErrInvalidPassword
+	}
+	userID, ok, err := utils.ConsumePasswordResetToken(ctx, s.sessions, token)
+	if err != nil {
+		return ErrSessionStore
+	}
+	if !ok {
+		return ErrInvalidResetToken
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	// Closed accounts keep their old password
+	if stored, err := s.users.SetPassword(ctx, userID, string(hash)); err != nil || !stored {
+		return ErrInvalidResetToken
+	}
+	entry := meta                                // Client details
+	entry.ActorID = &userID                      // The user completes the reset
+	entry.Action = audit.ActionPasswordResetDone // Action name
+	entry.TargetType = "user"                    // Target kind
+	entry.TargetID = strconv.Itoa(int(userID))   // Target user
+	if err := s.audit.Record(ctx, entry); err != nil {
+		logrus.WithContext(ctx).WithField("error", err.Error()).Error("Failed to record audit entry")
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"                         // Error inspection
+	"wallet_system/internal/ledger"  // Ledger errors
+	"wallet_system/internal/metrics" // Operation counters
+)
+
+// recordOperation counts a deposit or transfer as succeeded or failed, by reason
+func recordOperation(op string, amount float64, err error) {
+	if err == nil {
+		metrics.OperationSucceeded(op, amount)
+		return
+	}
+	metrics.OperationFailed(op, failureReason(err))
+}
+
+// failureReason maps an operation error to a short, bounded metric label
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidAmount), errors.Is(err, ledger.ErrInvalidAmount):
+		return "invalid_amount"
+	case errors.Is(err, ledger.ErrInsufficientFunds):
+		return "insufficient_funds"
+	case errors.Is(err, ErrAccountFrozen), errors.Is(err, ledger.ErrDebitsFrozen), errors.Is(err, ledger.ErrWalletClosed):
+		return "account_frozen"
+	case errors.Is(err, ErrRecipientFrozen), errors.Is(err, ledger.ErrCreditsFrozen):
+		return "recipient_frozen"
+	case errors.Is(err, ErrRecipientNotFound), errors.Is(err, ErrRecipientWallet):
+		return "recipient_not_found"
+	case errors.Is(err, ErrSelfTransfer):
+		return "self_transfer"
+	case errors.Is(err, ErrWalletNotFound), errors.Is(err, ledger.ErrWalletNotFound):
+		return "wallet_not_found"
+	}
+	return "internal"
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"                           // Request-scoped cancellation
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/repository" // Persistence
+	"wallet_system/internal/utils"      // Keyset cursors
+)
+
+// txPage is one keyset page of transactions with the cursors to its neighbours
+type txPage struct {
+	rows []domain.Transaction // Rows in list order
+	next *string              // Cursor to the following page, nil on the last page
+	prev *string              // Cursor to the preceding page, nil on the first page
+}
+
+// txSortKey returns a transaction's value for a sort column
+func txSortKey(tx *domain.Transaction, column string) float64 {
+	switch column {
+	case "amount":
+		return tx.Amount
+	case "id":
+		return float64(tx.ID)
+	}
+	return float64(tx.CreatedAt) // created_at in milliseconds
+}
+
+// fetchPage fetches one page of transactions and signs cursors at its edges, bound to scope
+func fetchPage(ctx context.Context, txs repository.TransactionRepository, f repository.TransactionFilter, p repository.PageQuery, scope, secret string) (*txPage, error) {
+	rows, more, err := txs.Page(ctx, f, p)
+	if err != nil {
+		return nil, err
+	}
+	page := &txPage{rows: rows}
+	if len(rows) == 0 {
+		return page, nil // Nothing to point at
+	}
+	backward := p.Cursor != nil && p.Cursor.Backward // Came from a following page
+	// encode builds a cursor at the given edge row
+	encode := func(tx *domain.Transaction, back bool) (*string, error) {
+		s, err := utils.EncodeCursor(utils.Cursor{Value: txSortKey(tx, p.Column), ID: tx.ID, Backward: back}, scope, secret)
+		if err != nil {
+			return nil, err
+		}
+		return &s, nil
+	}
+	// A following page exists if the forward scan found more, or if we came back from one
+	if more || backward {
+		if page.next, err = encode(&rows[len(rows)-1], false); err != nil {
+			return nil, err
+		}
+	}
+	// A preceding page exists if the backward scan found more, or if we came forward from one
+	if (backward && more) || (!backward && p.Cursor != nil) {
+		if page.prev, err = encode(&rows[0], true); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"                           // Request-scoped cancellation
+	"errors"                            // Error inspection
+	"time"                              // Timestamps
+	"wallet_system/internal/camt"       // ISO 20022 messages
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/export"     // Export formats
+	"wallet_system/internal/repository" // Persistence
+)
+
+// MaxCamtEntries caps the entries in one camt message; larger periods must be split
+const MaxCamtEntries = 50000
+
+// Kinds of camt message
+const (
+	CamtStatement    = "053" // camt.053 end-of-day statement
+	CamtNotification = "054" // camt.054 debit/credit notification
+)
+
+// Balance is a wallet balance at a point in time
+type Balance struct {
+	WalletID uint      // Wallet
+	Balance  float64   // Balance at that time
+	At       time.Time // Point in time
+}
+
+// ExportOpener starts an export once its metadata is known, returning the writer rows go to.
+// Errors from export.NewWriter are passed back to the caller unchanged.
+type ExportOpener func(meta export.Meta) (export.Writer, error)
+
+// ReportService covers read-only reports on wallets: balances, statements, exports and camt messages
+type ReportService interface {
+	UserBalance(ctx context.Context, userID uint, at *int64) (*Balance, error)                       // The user's balance, now or at a time
+	WalletBalance(ctx context.Context, walletID uint, at *int64) (*Balance, error)                   // Any wallet's balance, now or at a time
+	Statements(ctx context.Context, userID uint) ([]domain.Statement, error)                         // The user's statements without lines
+	Statement(ctx context.Context, userID, id uint) (*domain.Statement, string, error)               // One of the user's statements with lines, and the owner's username
+	ExportHistory(ctx context.Context, userID uint, from, to *int64, open ExportOpener) error        // Stream the user's transactions
+	ExportTransactions(ctx context.Context, f repository.TransactionFilter, open ExportOpener) error // Stream transactions matching admin filters
+	Camt(ctx context.Context, walletID uint, kind string, period camt.Period) (any, error)           // camt.053 or camt.054 document of any wallet
+}
+
+// reportService is the default ReportService
+type reportService struct {
+	users      repository.UserRepository        // Users
+	wallets    repository.WalletRepository      // Wallets
+	txs        repository.TransactionRepository // Transactions
+	statements repository.StatementRepository   // Statements
+	currency   string                           // ISO 4217 currency code
+}
+
+// NewReportService returns a ReportService reporting amounts in currency
+func NewReportService(store repository.Store, currency string) ReportService {
+	return &reportService{users: store.Users(), wallets: store.Wallets(), txs: store.Transactions(), statements: store.Statements(), currency: currency}
+}
+
+// walletOf returns the user's wallet, mapping a missing one to ErrWalletNotFound
+func (s *reportService) walletOf(ctx context.Context, userID uint) (*domain.Wallet, error) {
+	wallet, err := s.wallets.FindByUserID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrWalletNotFound
+	}
+	return wallet, err
+}
+
+// balanceAt returns the wallet's balance after every posting made at or before at, or the
+// current balance if at is nil
+func (s *reportService) balanceAt(ctx context.Context, wallet *domain.Wallet, at *int64) (*Balance, error) {
+	if at == nil {
+		return &Balance{WalletID: wallet.ID, Balance: wallet.Balance, At: time.Now()}, nil
+	}
+	b, err := s.txs.BalanceBefore(ctx, wallet.ID, *at+1) // Include postings made at exactly at
+	if err != nil {
+		return nil, err
+	}
+	return &Balance{WalletID: wallet.ID, Balance: b, At: time.UnixMilli(*at)}, nil
+}
+
+// UserBalance returns the balance of the user's wallet
+func (s *reportService) UserBalance(ctx context.Context, userID uint, at *int64) (*Balance, error) {
+	wallet, err := s.walletOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.balanceAt(ctx, wallet, at)
+}
+
+// WalletBalance returns the balance of any wallet, including system accounts
+func (s *reportService) WalletBalance(ctx context.Context, walletID uint, at *int64) (*Balance, error) {
+	wallet, err := s.wallets.FindByID(ctx, walletID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrWalletNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return s.balanceAt(ctx, wallet, at)
+}
+
+// Statements returns the user's statements, newest period first
+func (s *reportService) Statements(ctx context.Context, userID uint) ([]domain.Statement, error) {
+	wallet, err := s.walletOf(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.statements.ListByWallet(ctx, wallet.ID)
+}
+
+// Statement returns one of the user's statements with its lines; statements of other wallets
+// are reported as ErrStatementNotFound
+func (s *reportService) Statement(ctx context.Context, userID, id uint) (*domain.Statement, string, error) {
+	wallet, err := s.walletOf(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	st, err := s.statements.FindByWallet(ctx, wallet.ID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, "", ErrStatementNotFound
+	} else if err != nil {
+		return nil, "", err
+	}
+	var owner string // Owner's name for headings
+	if user, err := s.users.FindByID(ctx, userID); err == nil {
+		owner = user.Username
+	}
+	return st, owner, nil
+}
+
+// exportMeta builds the export metadata for a wallet and period, including its opening balance
+func (s *reportService) exportMeta(ctx context.Context, walletID *uint, from, to *int64) (export.Meta, error) {
+	meta := export.Meta{WalletID: walletID, Currency: s.currency} // Account and currency
+	if from != nil {
+		meta.From = time.UnixMilli(*from) // Start of the period
+	}
+	if to != nil {
+		meta.To = time.UnixMilli(*to) // End of the period
+	}
+	// The running balance starts from the balance before the period
+	if walletID != nil && from != nil {
+		opening, err := s.txs.BalanceBefore(ctx, *walletID, *from)
+		if err != nil {
+			return meta, err
+		}
+		meta.OpeningBalance = opening
+	}
+	return meta, nil
+}
+
+// stream writes every transaction matched by f in chronological order. For single-wallet exports
+// amounts are signed from the wallet's side; the running balance is tracked when withBalance is
+// set, i.e. when f matches every movement of the wallet, and is otherwise taken from the balances
+// recorded on each transaction.
+func (s *reportService) stream(ctx context.Context, f repository.TransactionFilter, meta export.Meta, withBalance bool, open ExportOpener) error {
+	w, err := open(meta) // Writer for the requested format
+	if err != nil {
+		return err
+	}
+	balance := meta.OpeningBalance // Running balance
+	err = s.txs.Each(ctx, f, func(tx *repository.TransactionWithOwners) error {
+		row := export.Row{
+			ID:           tx.ID,                        // Transaction ID
+			CreatedAt:    time.UnixMilli(tx.CreatedAt), // Posting time
+			Type:         tx.Type,                      // Transaction type
+			Status:       tx.Status,                    // Transaction status
+			Amount:       tx.Amount,                    // Amount
+			FromWalletID: tx.FromWalletID,              // Sender
+			FromUsername: tx.FromUsername,              // Sender's owner
+			ToWalletID:   tx.ToWalletID,                // Receiver
+			ToUsername:   tx.ToUsername,                // Receiver's owner
+			ReferenceID:  tx.ReferenceID,               // Reversed transaction
+			ReasonCode:   tx.ReasonCode,                // Adjustment reason
+			Note:         tx.Note,                      // Note
+		}
+		// Present the movement from the wallet's side
+		if meta.WalletID != nil {
+			if tx.ToWalletID != nil && *tx.ToWalletID == *meta.WalletID {
+				row.Direction, row.Counterparty = "credit", tx.FromUsername // Money in
+			} else {
+				row.Direction, row.Counterparty, row.Amount = "debit", tx.ToUsername, -tx.Amount // Money out
+			}
+			if withBalance {
+				balance += row.Amount
+				b := balance
+				row.RunningBalance = &b // Balance after this row
+			} else {
+				row.RunningBalance = tx.BalanceAfter(*meta.WalletID) // Balance recorded at posting, if any
+			}
+		}
+		return w.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+	return w.Close() // Write any trailer
+}
+
+// ExportHistory streams every movement of the user's wallet in the period, oldest first, with
+// counterparties and a running balance
+func (s *reportService) ExportHistory(ctx context.Context, userID uint, from, to *int64, open ExportOpener) error {
+	wallet, err := s.walletOf(ctx, userID)
+	if err != nil {
+		return err
+	}
+	meta, err := s.exportMeta(ctx, &wallet.ID, from, to) // Opening balance for the period
+	if err != nil {
+		return err
+	}
+	return s.stream(ctx, repository.TransactionFilter{WalletID: &wallet.ID, From: from, To: to}, meta, true, open)
+}
+
+// exportWallet returns the single wallet an admin export is filtered to, if any
+func (s *reportService) exportWallet(ctx context.Context, f repository.TransactionFilter) (*uint, error) {
+	var wallet *domain.Wallet // Wallet identified by the filters
+	var err error
+	switch {
+	case f.WalletID != nil:
+		return f.WalletID, nil
+	case f.UserID != nil:
+		wallet, err = s.wallets.FindByUserID(ctx, *f.UserID)
+	case f.Username != "":
+		wallet, err = s.wallets.FindByUsername(ctx, f.Username)
+	default:
+		return nil, nil // Multi-wallet export
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrWalletNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &wallet.ID, nil
+}
+
+// ExportTransactions streams transactions matching the admin listing filters, oldest first. When
+// the filters select a single wallet amounts are signed from its side, with the wallet's balance
+// after each row.
+func (s *reportService) ExportTransactions(ctx context.Context, f repository.TransactionFilter, open ExportOpener) error {
+	walletID, err := s.exportWallet(ctx, f) // Single wallet, if the filters select one
+	if err != nil {
+		return err
+	}
+	meta, err := s.exportMeta(ctx, walletID, f.From, f.To)
+	if err != nil {
+		return err
+	}
+	// A running balance needs every movement of the wallet in the period
+	complete := walletID != nil && len(f.Types) == 0 && len(f.Statuses) == 0 &&
+		f.MinAmount == nil && f.MaxAmount == nil
+	return s.stream(ctx, f, meta, complete, open)
+}
+
+// Camt builds the camt.053 statement or camt.054 notification of any wallet, including system
+// accounts, for the entries booked in [period.From, period.To). Periods with more than
+// MaxCamtEntries entries are rejected with ErrExportTooLarge.
+func (s *reportService) Camt(ctx context.Context, walletID uint, kind string, period camt.Period) (any, error) {
+	wallet, err := s.wallets.FindByID(ctx, walletID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrWalletNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	txs, err := s.txs.Between(ctx, wallet.ID, period.From.UnixMilli(), period.To.UnixMilli(), MaxCamtEntries+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(txs) > MaxCamtEntries {
+		return nil, ErrExportTooLarge
+	}
+	entries := make([]camt.Entry, 0, len(txs))
+	for _, tx := range txs {
+		counterparty := tx.FromUsername // Payer on credits
+		if tx.ToWalletID == nil || *tx.ToWalletID != wallet.ID {
+			counterparty = tx.ToUsername // Payee on debits
+		}
+		entries = append(entries, camt.EntryFromTransaction(tx.Transaction, wallet.ID, counterparty))
+	}
+	acct := camt.Account{WalletID: wallet.ID, Currency: s.currency} // Account block
+	if user, err := s.users.FindByID(ctx, wallet.UserID); err == nil {
+		acct.Owner = user.Username // Owner's name
+	}
+	if kind == CamtNotification {
+		return camt.Notification(acct, period, entries), nil
+	}
+	opening, err := s.txs.BalanceBefore(ctx, wallet.ID, period.From.UnixMilli()) // Balance at the start
+	if err != nil {
+		return nil, err
+	}
+	return camt.Statement(acct, period, opening, entries), nil
+}
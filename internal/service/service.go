@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"                           // Request-scoped cancellation
+	"errors"                            // Error values
+	"strconv"                           // String conversion
+	"time"                              // Cache lifetimes
+	"wallet_system/internal/repository" // Persistence
+	"wallet_system/internal/utils"      // Cache helpers
+
+	"github.com/redis/go-redis/v9" // Redis client
+)
+
+// Errors returned by the services. Ledger errors such as ledger.ErrInsufficientFunds are passed through.
+var (
+	ErrNotFound          = repository.ErrNotFound                       // Requested record does not exist
+	ErrWalletNotFound    = errors.New("wallet not found")               // User has no wallet
+	ErrWalletExists      = errors.New("wallet already exists")          // One wallet per user
+	ErrRecipientNotFound = errors.New("target user not found")          // Unknown or system recipient
+	ErrRecipientWallet   = errors.New("recipient wallet not found")     // Recipient has no wallet
+	ErrSelfTransfer      = errors.New("cannot transfer to yourself")    // Sender and recipient are the same
+	ErrAccountFrozen     = errors.New("account is frozen")              // Caller's account cannot move money
+	ErrRecipientFrozen   = errors.New("recipient cannot receive funds") // Recipient's account cannot receive money
+	ErrInvalidAmount     = errors.New("amount must be positive")        // Zero or negative amount
+	ErrInvalidCursor     = utils.ErrInvalidCursor                       // Tampered or foreign cursor
+
+	ErrInvalidUsername       = errors.New("username must be alphabetic only")    // Username has other characters
+	ErrInvalidPassword       = errors.New("password must be 8-15 characters")    // Password too short or too long
+	ErrUsernameTaken         = errors.New("username already exists")             // Usernames are unique
+	ErrInvalidCredentials    = errors.New("invalid credentials")                 // Unknown user or wrong password
+	ErrAccountClosed         = errors.New("account is closed")                   // Closed accounts cannot log in or be changed
+	ErrPasswordResetRequired = errors.New("password reset required")             // Login blocked until the forced reset is done
+	ErrInvalidRefreshToken   = errors.New("invalid or expired refresh token")    // Refresh token expired, used or revoked
+	ErrInvalidResetToken     = errors.New("invalid or expired reset token")      // Reset token expired or already used
+	ErrSessionStore          = errors.New("session store unavailable")           // Redis could not be reached
+	ErrAuditUnavailable      = errors.New("audit log unavailable")               // Change applied but not recorded
+	ErrUserNotFound          = errors.New("user not found")                      // Unknown or system user
+	ErrSelfAction            = errors.New("cannot act on your own account")      // Admins cannot restrict or promote themselves
+	ErrAlreadyClosed         = errors.New("already closed")                      // Closed accounts and wallets stay closed
+	ErrBalanceNotZero        = errors.New("wallet balance must be zero")         // Closure needs an empty wallet or a payout
+	ErrUnknownRole           = errors.New("unknown role")                        // Role is not in the catalogue
+	ErrRoleUnchanged         = errors.New("user already has this role")          // Nothing to approve
+	ErrTransactionNotFound   = errors.New("transaction not found")               // Unknown transaction
+	ErrSystemWallet          = errors.New("cannot adjust a system wallet")       // System accounts are never adjusted by hand
+	ErrUnknownReason         = errors.New("unknown reason code")                 // Reason is not in domain.AdjustmentReasons
+	ErrStatementNotFound     = errors.New("statement not found")                 // Unknown statement or another wallet's
+	ErrExportTooLarge        = errors.New("too many entries; narrow the period") // Message would exceed MaxCamtEntries
+)
+
+// DefaultCacheTTL is how long cached reads are served when no lifetime is configured
+const DefaultCacheTTL = 60 * time.Second
+
+// cacheLifetime returns ttl, or DefaultCacheTTL if it is not positive
+func cacheLifetime(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return DefaultCacheTTL
+	}
+	return ttl
+}
+
+// Cache stores JSON-encodable values by key for a limited time
+type Cache interface {
+	Get(ctx context.Context, key string, dest any) (bool, error)             // Load a value into dest, reporting whether it was found
+	Set(ctx context.Context, key string, value any, ttl time.Duration) error // Store a value
+	Delete(ctx context.Context, keys ...string) error                        // Drop values
+}
+
+// redisCache is the Redis implementation of Cache
+type redisCache struct {
+	rdb *redis.Client // Redis client
+}
+
+// NewRedisCache returns a Cache backed by Redis
+func NewRedisCache(rdb *redis.Client) Cache {
+	return &redisCache{rdb: rdb}
+}
+
+// Get loads a cached value into dest
+func (c *redisCache) Get(ctx context.Context, key string, dest any) (bool, error) {
+	return utils.GetCache(ctx, c.rdb, key, dest)
+}
+
+// Set caches a value for ttl
+func (c *redisCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	return utils.SetCache(ctx, c.rdb, key, value, ttl)
+}
+
+// Delete drops cached values
+func (c *redisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.rdb.Del(ctx, keys...).Err()
+}
+
+// WalletCacheKey returns the cache key of a user's wallet
+func WalletCacheKey(userID uint) string {
+	return "wallet:user:" + strconv.Itoa(int(userID))
+}
+
+// HistoryCacheKey returns the cache key of the first page of a user's transaction history
+func HistoryCacheKey(userID uint) string {
+	return "txhistory:user:" + strconv.Itoa(int(userID)) + ":first"
+}
+
+// InvalidateWallets drops the cached wallet and transaction history of the given users.
+// Call it after anything that changes their balances or wallet status.
+func InvalidateWallets(ctx context.Context, cache Cache, userIDs ...uint) {
+	var keys []string // Keys to drop
+	for _, id := range userIDs {
+		keys = append(keys, WalletCacheKey(id), HistoryCacheKey(id))
+	}
+	_ = cache.Delete(ctx, keys...)
+}
+
+// dropUserState drops the cached role and status of the given users so the next request re-reads them
+func dropUserState(ctx context.Context, cache Cache, userIDs ...uint) {
+	var keys []string // Keys to drop
+	for _, id := range userIDs {
+		keys = append(keys, utils.UserStateCacheKey(id))
+	}
+	_ = cache.Delete(ctx, keys...)
+}
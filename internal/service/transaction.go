@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"                           // Request-scoped cancellation
+	"crypto/sha256"                     // Scope digests
+	"encoding/hex"                      // Scope digests
+	"encoding/json"                     // Scope digests
+	"errors"                            // Error inspection
+	"strconv"                           // String conversion
+	"time"                              // Cache lifetimes
+	"wallet_system/internal/approval"   // Maker-checker workflow
+	"wallet_system/internal/audit"      // Audit log
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/ledger"     // Ledger postings
+	"wallet_system/internal/repository" // Persistence
+	"wallet_system/internal/utils"      // Keyset cursors
+
+	"github.com/sirupsen/logrus" // Logging library
+)
+
+// AdminTransaction represents a transaction with the usernames and balances on both sides
+type AdminTransaction struct {
+	domain.Transaction          // Transaction fields
+	FromUsername       string   `json:"from_username,omitempty"`      // Owner of the sending wallet
+	ToUsername         string   `json:"to_username,omitempty"`        // Owner of the receiving wallet
+	FromBalance        *float64 `json:"from_balance_after,omitempty"` // Sender's balance after the posting
+	ToBalance          *float64 `json:"to_balance_after,omitempty"`   // Receiver's balance after the posting
+}
+
+// TransactionQuery sorts and positions an admin transaction listing
+type TransactionQuery struct {
+	Sort         string // created_at, amount or id
+	Desc         bool   // Sort descending
+	Cursor       string // next_cursor or prev_cursor of an earlier page, empty for the first page
+	PageSize     int    // Rows per page
+	IncludeTotal bool   // Count every matching transaction
+}
+
+// TransactionList is one page of an admin transaction listing
+type TransactionList struct {
+	Transactions []AdminTransaction `json:"transactions"`    // List of transactions
+	PageSize     int                `json:"page_size"`       // Page size
+	NextCursor   *string            `json:"next_cursor"`     // Cursor to the next page
+	PrevCursor   *string            `json:"prev_cursor"`     // Cursor to the previous page
+	Total        *int64             `json:"total,omitempty"` // Total number of transactions, if requested
+}
+
+// ReversalResult is the outcome of a reversal
+type ReversalResult struct {
+	TransactionID uint    `json:"transaction_id"` // Reversed transaction
+	ReversalID    uint    `json:"reversal_id"`    // New reversal transaction
+	Amount        float64 `json:"amount"`         // Amount moved back
+	UserIDs       []uint  `json:"user_ids"`       // Owners of the wallets touched
+}
+
+// TransactionService covers admin access to transactions: listings, reversals and manual adjustments.
+// meta carries the acting admin and client details for the audit log.
+type TransactionService interface {
+	List(ctx context.Context, f repository.TransactionFilter, q TransactionQuery) (*TransactionList, bool, error)        // One page of transactions, and whether it came from the cache
+	Reverse(ctx context.Context, id uint, note string, meta audit.Entry) (*ReversalResult, *domain.PendingAction, error) // Reverse a transaction, or submit it for approval above the threshold
+	ProposeAdjustment(ctx context.Context, walletID uint, a Adjustment, meta audit.Entry) (*domain.PendingAction, error) // Submit a manual adjustment for approval
+}
+
+// transactionService is the default TransactionService
+type transactionService struct {
+	store       repository.Store                 // Repositories and transactions
+	txs         repository.TransactionRepository // Transactions
+	cache       Cache                            // Read cache
+	cacheTTL    time.Duration                    // How long pages are cached
+	secret      string                           // Key signing pagination cursors
+	threshold   float64                          // Largest reversal applied without approval
+	approvalTTL time.Duration                    // How long proposals wait for approval
+}
+
+// NewTransactionService returns a TransactionService; pages are cached for cacheTTL, or
+// DefaultCacheTTL if zero, and secret signs pagination cursors. Reversals above threshold and
+// every adjustment wait approvalTTL for a second admin.
+func NewTransactionService(store repository.Store, cache Cache, cacheTTL time.Duration, secret string, threshold float64, approvalTTL time.Duration) TransactionService {
+	return &transactionService{store: store, txs: store.Transactions(), cache: cache, cacheTTL: cacheLifetime(cacheTTL), secret: secret, threshold: threshold, approvalTTL: approvalTTL}
+}
+
+// listingScope identifies the filters and sort of a listing; cursors are bound to it
+func listingScope(f repository.TransactionFilter, q TransactionQuery) string {
+	b, _ := json.Marshal(struct {
+		Filter repository.TransactionFilter // Filters
+		Sort   string                       // Sort column
+		Desc   bool                         // Sort direction
+	}{f, q.Sort, q.Desc})
+	sum := sha256.Sum256(b)
+	return "admin:txs:" + hex.EncodeToString(sum[:16])
+}
+
+// List returns one page of transactions matching f with the owners of both sides, cached for cacheTTL
+func (s *transactionService) List(ctx context.Context, f repository.TransactionFilter, q TransactionQuery) (*TransactionList, bool, error) {
+	scope := listingScope(f, q) // Cursors are bound to the filters and sort they were issued for
+	cacheKey := scope + ":cursor=" + q.Cursor + ":page_size=" + strconv.Itoa(q.PageSize) + ":include_total=" + strconv.FormatBool(q.IncludeTotal)
+	var cached TransactionList
+	if found, err := s.cache.Get(ctx, cacheKey, &cached); err == nil && found {
+		return &cached, true, nil
+	}
+	var cursor *utils.Cursor // Position in the list
+	if q.Cursor != "" {
+		var err error
+		if cursor, err = utils.DecodeCursor(q.Cursor, scope, s.secret); err != nil {
+			return nil, false, ErrInvalidCursor
+		}
+	}
+	page, err := fetchPage(ctx, s.txs, f, repository.PageQuery{Column: q.Sort, Desc: q.Desc, Cursor: cursor, Limit: q.PageSize}, scope, s.secret)
+	if err != nil {
+		return nil, false, err
+	}
+	var ids []uint // Wallets on either side
+	for _, tx := range page.rows {
+		if tx.FromWalletID != nil {
+			ids = append(ids, *tx.FromWalletID)
+		}
+		if tx.ToWalletID != nil {
+			ids = append(ids, *tx.ToWalletID)
+		}
+	}
+	names, err := s.txs.WalletUsernames(ctx, ids) // Expand usernames on both sides
+	if err != nil {
+		return nil, false, err
+	}
+	list := &TransactionList{
+		Transactions: make([]AdminTransaction, len(page.rows)), // List of transactions
+		PageSize:     q.PageSize,                               // Page size
+		NextCursor:   page.next,                                // Cursor to the next page
+		PrevCursor:   page.prev,                                // Cursor to the previous page
+	}
+	for i, tx := range page.rows {
+		t := &list.Transactions[i]
+		t.Transaction = tx
+		t.FromBalance, t.ToBalance = tx.FromBalanceAfter, tx.ToBalanceAfter // Recorded balances
+		if tx.FromWalletID != nil {
+			t.FromUsername = names[*tx.FromWalletID] // Sender
+		}
+		if tx.ToWalletID != nil {
+			t.ToUsername = names[*tx.ToWalletID] // Receiver
+		}
+	}
+	// Counting scans every matching row, so it is opt-in
+	if q.IncludeTotal {
+		total, err := s.txs.Count(ctx, f)
+		if err != nil {
+			return nil, false, err
+		}
+		list.Total = &total
+	}
+	_ = s.cache.Set(ctx, cacheKey, list, s.cacheTTL) // Cache the page
+	return list, false, nil
+}
+
+// reverseTransaction reverses a transaction and records it in the audit log
+func reverseTransaction(ctx context.Context, tx repository.Store, id uint, meta audit.Entry) (*ReversalResult, error) {
+	res, err := tx.Transactions().Reverse(ctx, id) // Post the reversal
+	if err != nil {
+		return nil, err
+	}
+	result := &ReversalResult{
+		TransactionID: id,                     // Reversed transaction
+		ReversalID:    res.Transaction.ID,     // Reversal transaction
+		Amount:        res.Transaction.Amount, // Amount moved back
+	}
+	for _, w := range res.Wallets {
+		result.UserIDs = append(result.UserIDs, w.UserID) // Owners whose caches must be dropped
+	}
+	entry := meta                          // Actor and client details
+	entry.Action = audit.ActionTxReverse   // Action name
+	entry.TargetType = "transaction"       // Target kind
+	entry.TargetID = strconv.Itoa(int(id)) // Target transaction
+	entry.After = result                   // Outcome
+	return result, tx.Audit().Record(ctx, entry)
+}
+
+// Reverse reverses a completed deposit or transfer. Reversals up to the threshold are applied
+// immediately; larger ones are submitted for approval and returned as a pending action.
+func (s *transactionService) Reverse(ctx context.Context, id uint, note string, meta audit.Entry) (*ReversalResult, *domain.PendingAction, error) {
+	original, err := s.txs.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrTransactionNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	// Only completed deposits and transfers can be reversed
+	if !ledger.Reversible(original) {
+		return nil, nil, ledger.ErrNotReversible
+	}
+	// Large reversals need a second admin
+	if original.Amount > s.threshold {
+		action, err := s.store.Approvals().Propose(ctx, approval.Proposal{
+			Type:       ActionTypeTxReverse,                          // Action type
+			Payload:    txReversePayload{TransactionID: original.ID}, // Parameters
+			TargetType: "transaction",                                // Target kind
+			TargetID:   strconv.Itoa(int(original.ID)),               // Target transaction
+			Note:       note,                                         // Reason
+		}, meta, s.approvalTTL)
+		return nil, action, err
+	}
+	// Small reversals are applied directly
+	var result *ReversalResult // Outcome of the reversal
+	err = s.store.Atomic(ctx, func(tx repository.Store) error {
+		var err error
+		result, err = reverseTransaction(ctx, tx, original.ID, meta)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, ledger.ErrInsufficientFunds) && !errors.Is(err, ledger.ErrNotReversible) {
+			logrus.WithContext(ctx).WithFields(logrus.Fields{
+				"transaction_id": original.ID, // Transaction being reversed
+				"error":          err.Error(), // Error message
+			}).Error("Reversal failed")
+		}
+		return nil, nil, err
+	}
+	InvalidateWallets(ctx, s.cache, result.UserIDs...) // Balances changed
+	return result, nil, nil
+}
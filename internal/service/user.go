@@ -0,0 +1,373 @@
+package service
+
+import (
+	"context"                           // Request-scoped cancellation
+	"encoding/json"                     // Cache keys
+	"errors"                            // Error inspection
+	"strconv"                           // String conversion
+	"time"                              // Cache lifetimes
+	"wallet_system/internal/approval"   // Maker-checker workflow
+	"wallet_system/internal/audit"      // Audit log
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/ledger"     // Ledger postings
+	"wallet_system/internal/repository" // Persistence
+	"wallet_system/internal/utils"      // Session helpers
+
+	"github.com/redis/go-redis/v9" // Redis client
+)
+
+// recentActivityLimit is how many transactions and logins the user detail includes
+const recentActivityLimit = 20
+
+// UserSummary represents the user data returned to admins
+type UserSummary struct {
+	ID                uint          `json:"id"`                  // User ID
+	Username          string        `json:"username"`            // Username
+	Role              string        `json:"role"`                // User role
+	Status            string        `json:"status"`              // Account status
+	StatusReason      string        `json:"status_reason"`       // Why the status was set
+	MustResetPassword bool          `json:"must_reset_password"` // Pending forced reset
+	Wallet            domain.Wallet `json:"wallet"`              // Associated wallet
+}
+
+// Summarize maps a user to the admin view, leaving out the password hash
+func Summarize(u domain.User) UserSummary {
+	return UserSummary{
+		ID:                u.ID,                // User ID
+		Username:          u.Username,          // Username
+		Role:              u.Role,              // User role
+		Status:            u.Status,            // Account status
+		StatusReason:      u.StatusReason,      // Why the status was set
+		MustResetPassword: u.MustResetPassword, // Pending forced reset
+		Wallet:            u.Wallet,            // Associated wallet
+	}
+}
+
+// UserList is one page of an admin user listing
+type UserList struct {
+	Users      []UserSummary `json:"users"`       // List of users
+	Page       int           `json:"page"`        // Current page
+	PageSize   int           `json:"page_size"`   // Page size
+	Total      int64         `json:"total"`       // Total number of users
+	TotalPages int           `json:"total_pages"` // Total pages
+}
+
+// UserDetail is a single user with their wallet and recent activity
+type UserDetail struct {
+	User               *domain.User         // User and wallet
+	RecentTransactions []domain.Transaction // Latest transactions touching the wallet
+	RecentLogins       []domain.AuditLog    // Latest login attempts
+}
+
+// UserService covers admin access to users: listings, account restrictions, credentials and roles.
+// meta carries the acting admin and client details for the audit log.
+type UserService interface {
+	List(ctx context.Context, f repository.UserFilter, page, pageSize int) (*UserList, bool, error)               // One page of users, and whether it came from the cache
+	Get(ctx context.Context, id uint) (*UserDetail, error)                                                        // A user with recent activity
+	SetStatus(ctx context.Context, id uint, status, reason string, meta audit.Entry) error                        // Freeze or unfreeze an account
+	SetWalletStatus(ctx context.Context, walletID uint, status, reason string, meta audit.Entry) error            // Freeze or unfreeze a single wallet
+	Close(ctx context.Context, id uint, reason string, finalPayout bool, meta audit.Entry) (float64, error)       // Close an account, returning the amount paid out
+	ForcePasswordReset(ctx context.Context, id uint, reason string, meta audit.Entry) (string, error)             // Block login until a new password is set, returning the reset token
+	RevokeSessions(ctx context.Context, id uint, reason string, meta audit.Entry) error                           // Invalidate every token issued so far
+	ProposeRole(ctx context.Context, id uint, role, note string, meta audit.Entry) (*domain.PendingAction, error) // Submit a role change for approval
+}
+
+// userService is the default UserService
+type userService struct {
+	store       repository.Store          // Repositories and transactions
+	users       repository.UserRepository // Users
+	sessions    *redis.Client             // Session store
+	cache       Cache                     // Read cache
+	cacheTTL    time.Duration             // How long pages are cached
+	approvalTTL time.Duration             // How long role changes wait for approval
+}
+
+// NewUserService returns a UserService; pages are cached for cacheTTL, or DefaultCacheTTL if zero,
+// and role changes wait approvalTTL for a second admin
+func NewUserService(store repository.Store, sessions *redis.Client, cache Cache, cacheTTL, approvalTTL time.Duration) UserService {
+	return &userService{store: store, users: store.Users(), sessions: sessions, cache: cache, cacheTTL: cacheLifetime(cacheTTL), approvalTTL: approvalTTL}
+}
+
+// List returns one page of users matching f with their wallets, cached for cacheTTL
+func (s *userService) List(ctx context.Context, f repository.UserFilter, page, pageSize int) (*UserList, bool, error) {
+	key, _ := json.Marshal(f) // Filters identify the listing
+	cacheKey := "admin:users:" + string(key) + ":page=" + strconv.Itoa(page) + ":page_size=" + strconv.Itoa(pageSize)
+	var cached UserList
+	if found, err := s.cache.Get(ctx, cacheKey, &cached); err == nil && found {
+		return &cached, true, nil
+	}
+	users, total, err := s.users.List(ctx, f, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, false, err
+	}
+	list := &UserList{
+		Users:      make([]UserSummary, len(users)),        // List of users
+		Page:       page,                                   // Current page
+		PageSize:   pageSize,                               // Page size
+		Total:      total,                                  // Total number of users
+		TotalPages: (int(total) + pageSize - 1) / pageSize, // Total pages
+	}
+	for i, u := range users {
+		list.Users[i] = Summarize(u)
+	}
+	_ = s.cache.Set(ctx, cacheKey, list, s.cacheTTL) // Cache the page
+	return list, false, nil
+}
+
+// managed returns the non-system user with the given ID
+func (s *userService) managed(ctx context.Context, users repository.UserRepository, id uint) (*domain.User, error) {
+	user, err := users.FindManaged(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// isActor reports whether meta's actor is the user with the given ID
+func isActor(meta audit.Entry, id uint) bool {
+	return meta.ActorID != nil && *meta.ActorID == id
+}
+
+// Get returns a user with their wallet, latest transactions and login history
+func (s *userService) Get(ctx context.Context, id uint) (*UserDetail, error) {
+	user, err := s.managed(ctx, s.users, id)
+	if err != nil {
+		return nil, err
+	}
+	detail := &UserDetail{User: user, RecentTransactions: []domain.Transaction{}}
+	// Latest transactions touching the wallet, if the user has one
+	if user.Wallet.ID != 0 {
+		if detail.RecentTransactions, err = s.store.Transactions().Recent(ctx, user.Wallet.ID, recentActivityLimit); err != nil {
+			return nil, err
+		}
+	}
+	// Latest login attempts; failed attempts are recorded against the username
+	detail.RecentLogins, err = s.store.Audit().Latest(ctx, repository.AuditFilter{
+		Actions:    []string{audit.ActionLogin, audit.ActionLoginFailed}, // Successful and failed logins
+		TargetType: "user",                                               // Logins target a user account
+		TargetID:   user.Username,                                        // Username that was attempted
+	}, recentActivityLimit)
+	if err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// SetStatus freezes or unfreezes a user account; admins cannot change their own
+func (s *userService) SetStatus(ctx context.Context, id uint, status, reason string, meta audit.Entry) error {
+	if isActor(meta, id) {
+		return ErrSelfAction
+	}
+	user, err := s.managed(ctx, s.users, id)
+	if err != nil {
+		return err
+	}
+	// Closed accounts stay closed
+	if user.Status == domain.StatusClosed {
+		return ErrAccountClosed
+	}
+	// Update the status and record it in the audit log atomically
+	err = s.store.Atomic(ctx, func(tx repository.Store) error {
+		if err := tx.Users().SetStatus(ctx, user.ID, status, reason); err != nil {
+			return err // Return error to rollback
+		}
+		entry := meta                                                    // Actor and client details
+		entry.Action = audit.ActionUserStatus                            // Action name
+		entry.TargetType = "user"                                        // Target kind
+		entry.TargetID = strconv.Itoa(int(user.ID))                      // Target user
+		entry.Before = map[string]any{"status": user.Status}             // Status before
+		entry.After = map[string]any{"status": status, "reason": reason} // Status after
+		return tx.Audit().Record(ctx, entry)
+	})
+	if err != nil {
+		return err
+	}
+	dropUserState(ctx, s.cache, user.ID) // Enforce the new status on the next request
+	return nil
+}
+
+// SetWalletStatus freezes or unfreezes a single wallet. The wallet is locked so the change
+// serializes with in-flight postings.
+func (s *userService) SetWalletStatus(ctx context.Context, walletID uint, status, reason string, meta audit.Entry) error {
+	var wallet *domain.Wallet // Wallet being changed
+	err := s.store.Atomic(ctx, func(tx repository.Store) error {
+		wallets, err := tx.Wallets().Lock(ctx, walletID)
+		if err != nil {
+			return err
+		}
+		wallet = wallets[walletID]
+		if wallet.IsSystem {
+			return ErrWalletNotFound // System wallets are not managed here
+		}
+		if wallet.Status == domain.StatusClosed {
+			return ErrAlreadyClosed // Closed wallets stay closed
+		}
+		if err := tx.Wallets().SetStatus(ctx, walletID, status, reason); err != nil {
+			return err
+		}
+		entry := meta                                                    // Actor and client details
+		entry.Action = audit.ActionWalletStatus                          // Action name
+		entry.TargetType = "wallet"                                      // Target kind
+		entry.TargetID = strconv.Itoa(int(walletID))                     // Target wallet
+		entry.Before = map[string]any{"status": wallet.Status}           // Status before
+		entry.After = map[string]any{"status": status, "reason": reason} // Status after
+		return tx.Audit().Record(ctx, entry)
+	})
+	if errors.Is(err, ledger.ErrWalletNotFound) {
+		return ErrWalletNotFound
+	} else if err != nil {
+		return err
+	}
+	InvalidateWallets(ctx, s.cache, wallet.UserID) // Cached wallet shows the status
+	return nil
+}
+
+// Close permanently closes a user account and its wallet. The wallet must be empty unless
+// finalPayout is set, in which case the remaining balance is withdrawn before closing.
+func (s *userService) Close(ctx context.Context, id uint, reason string, finalPayout bool, meta audit.Entry) (float64, error) {
+	if isActor(meta, id) {
+		return 0, ErrSelfAction
+	}
+	var payout float64 // Amount paid out on closure
+	err := s.store.Atomic(ctx, func(tx repository.Store) error {
+		// Read the status inside the transaction
+		user, err := s.managed(ctx, tx.Users(), id)
+		if err != nil {
+			return err
+		}
+		if user.Status == domain.StatusClosed {
+			return ErrAlreadyClosed
+		}
+		var balance float64 // Balance before closing
+		if user.Wallet.ID != 0 {
+			wallets, err := tx.Wallets().Lock(ctx, user.Wallet.ID) // Lock against concurrent postings
+			if err != nil {
+				return err
+			}
+			locked := wallets[user.Wallet.ID]
+			balance = locked.Balance
+			if locked.Balance > 0 {
+				if !finalPayout {
+					return ErrBalanceNotZero // Refuse to strand money in a closed wallet
+				}
+				// Pay out the remaining balance
+				if _, err := tx.Transactions().Post(ctx, ledger.Posting{
+					FromWalletID:   &locked.ID,              // Empty the wallet
+					Amount:         locked.Balance,          // Entire balance
+					Type:           domain.TxTypeWithdrawal, // Money leaves the system
+					Note:           reason,                  // Closure reason
+					Administrative: true,                    // Allowed on frozen wallets
+				}); err != nil {
+					return err
+				}
+				payout = locked.Balance
+			}
+			if err := tx.Wallets().SetStatus(ctx, locked.ID, domain.StatusClosed, reason); err != nil {
+				return err
+			}
+		}
+		if err := tx.Users().SetStatus(ctx, user.ID, domain.StatusClosed, reason); err != nil {
+			return err
+		}
+		entry := meta                                                                                   // Actor and client details
+		entry.Action = audit.ActionUserClose                                                            // Action name
+		entry.TargetType = "user"                                                                       // Target kind
+		entry.TargetID = strconv.Itoa(int(user.ID))                                                     // Target user
+		entry.Before = map[string]any{"status": user.Status, "balance": balance}                        // State before
+		entry.After = map[string]any{"status": domain.StatusClosed, "payout": payout, "reason": reason} // State after
+		return tx.Audit().Record(ctx, entry)
+	})
+	if err != nil {
+		return 0, err
+	}
+	dropUserState(ctx, s.cache, id)     // Reject the user's tokens from now on
+	InvalidateWallets(ctx, s.cache, id) // Cached wallet shows the closure
+	return payout, nil
+}
+
+// ForcePasswordReset signs a user out everywhere and blocks login until they set a new password.
+// It returns the single-use reset token for the admin to hand over out of band.
+func (s *userService) ForcePasswordReset(ctx context.Context, id uint, reason string, meta audit.Entry) (string, error) {
+	user, err := s.managed(ctx, s.users, id)
+	if err != nil {
+		return "", err
+	}
+	if user.Status == domain.StatusClosed {
+		return "", ErrAccountClosed
+	}
+	// Flag the account and record it in the audit log atomically
+	err = s.store.Atomic(ctx, func(tx repository.Store) error {
+		if err := tx.Users().SetMustResetPassword(ctx, user.ID, true); err != nil {
+			return err // Return error to rollback
+		}
+		entry := meta                                                               // Actor and client details
+		entry.Action = audit.ActionPasswordReset                                    // Action name
+		entry.TargetType = "user"                                                   // Target kind
+		entry.TargetID = strconv.Itoa(int(user.ID))                                 // Target user
+		entry.After = map[string]any{"must_reset_password": true, "reason": reason} // Flag set
+		return tx.Audit().Record(ctx, entry)
+	})
+	if err != nil {
+		return "", err
+	}
+	// Sign the user out everywhere
+	if err := utils.RevokeUserSessions(ctx, s.sessions, user.ID); err != nil {
+		return "", ErrSessionStore
+	}
+	token, err := utils.CreatePasswordResetToken(ctx, s.sessions, user.ID) // Issue the reset token
+	if err != nil {
+		return "", ErrSessionStore
+	}
+	dropUserState(ctx, s.cache, user.ID) // Drop cached state
+	return token, nil
+}
+
+// RevokeSessions invalidates every access and refresh token issued to a user so far
+func (s *userService) RevokeSessions(ctx context.Context, id uint, reason string, meta audit.Entry) error {
+	user, err := s.managed(ctx, s.users, id)
+	if err != nil {
+		return err
+	}
+	// Revoke first so a failed audit write never leaves sessions alive
+	if err := utils.RevokeUserSessions(ctx, s.sessions, user.ID); err != nil {
+		return ErrSessionStore
+	}
+	entry := meta                                  // Actor and client details
+	entry.Action = audit.ActionSessionsRevoke      // Action name
+	entry.TargetType = "user"                      // Target kind
+	entry.TargetID = strconv.Itoa(int(user.ID))    // Target user
+	entry.After = map[string]any{"reason": reason} // Why
+	if err := s.store.Audit().Record(ctx, entry); err != nil {
+		return ErrAuditUnavailable
+	}
+	return nil
+}
+
+// ProposeRole submits a role change for approval. Once a second admin approves it, the user's
+// existing tokens stop passing permission checks until they log in again.
+func (s *userService) ProposeRole(ctx context.Context, id uint, role, note string, meta audit.Entry) (*domain.PendingAction, error) {
+	if !domain.IsValidRole(role) {
+		return nil, ErrUnknownRole
+	}
+	// Admins cannot change their own role and lock themselves out
+	if isActor(meta, id) {
+		return nil, ErrSelfAction
+	}
+	user, err := s.users.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	// Nothing to approve if the role is unchanged
+	if user.Role == role {
+		return nil, ErrRoleUnchanged
+	}
+	return s.store.Approvals().Propose(ctx, approval.Proposal{
+		Type:       ActionTypeRoleAssign,                           // Action type
+		Payload:    roleAssignPayload{UserID: user.ID, Role: role}, // Parameters
+		TargetType: "user",                                         // Target kind
+		TargetID:   strconv.Itoa(int(user.ID)),                     // Target user
+		Note:       note,                                           // Reason
+	}, meta, s.approvalTTL)
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"                           // Request-scoped cancellation
+	"errors"                            // Error inspection
+	"strconv"                           // String conversion
+	"time"                              // Timestamps
+	"wallet_system/internal/domain"     // Importing domain models
+	"wallet_system/internal/ledger"     // Ledger postings
+	"wallet_system/internal/metrics"    // Operation counters
+	"wallet_system/internal/repository" // Persistence
+	"wallet_system/internal/utils"      // Keyset cursors
+
+	"github.com/sirupsen/logrus" // Logging library
+)
+
+// Page size limits shared by the paginated listings
+const (
+	DefaultPageSize = 20  // Page size when none is given
+	MaxPageSize     = 100 // Largest page size accepted
+)
+
+// HistoryTransaction is a transaction as seen by the owner of one of its wallets
+type HistoryTransaction struct {
+	domain.Transaction          // Transaction fields
+	BalanceAfter       *float64 `json:"balance_after,omitempty"` // Wallet balance right after the posting
+}
+
+// HistoryQuery positions a page of a user's transaction history
+type HistoryQuery struct {
+	Cursor       string // next_cursor or prev_cursor of an earlier page, empty for the first page
+	PageSize     int    // Rows per page
+	IncludeTotal bool   // Count every transaction of the wallet
+}
+
+// HistoryPage is one page of a user's transaction history, newest first
+type HistoryPage struct {
+	Transactions []HistoryTransaction `json:"transactions"`    // List of transactions
+	PageSize     int                  `json:"page_size"`       // Page size
+	NextCursor   *string              `json:"next_cursor"`     // Cursor to the next page
+	PrevCursor   *string              `json:"prev_cursor"`     // Cursor to the previous page
+	Total        *int64               `json:"total,omitempty"` // Total transactions, if requested
+}
+
+// WalletService covers a customer's own wallet: creating it, moving money and reading history
+type WalletService interface {
+	Create(ctx context.Context, userID uint) (*domain.Wallet, error)                                               // Open the user's wallet
+	Get(ctx context.Context, userID uint) (*domain.Wallet, bool, error)                                            // The user's wallet, and whether it came from the cache
+	Deposit(ctx context.Context, userID uint, amount float64) (*domain.Transaction, error)                         // Credit the user's wallet
+	Transfer(ctx context.Context, fromUserID uint, toUsername string, amount float64) (*domain.Transaction, error) // Move money to another user
+	History(ctx context.Context, userID uint, q HistoryQuery) (*HistoryPage, bool, error)                          // One page of history, and whether it came from the cache
+}
+
+// walletService is the default WalletService
+type walletService struct {
+	users    repository.UserRepository        // Users
+	wallets  repository.WalletRepository      // Wallets
+	txs      repository.TransactionRepository // Transactions and postings
+	cache    Cache                            // Read cache
+	cacheTTL time.Duration                    // How long reads are cached
+	secret   string                           // Key signing pagination cursors
+}
+
+// NewWalletService returns a WalletService; reads are cached for cacheTTL, or DefaultCacheTTL
+// if zero, and secret signs pagination cursors
+func NewWalletService(store repository.Store, cache Cache, cacheTTL time.Duration, secret string) WalletService {
+	return &walletService{users: store.Users(), wallets: store.Wallets(), txs: store.Transactions(), cache: cache, cacheTTL: cacheLifetime(cacheTTL), secret: secret}
+}
+
+// Create opens a wallet with a zero balance; each user has at most one
+func (s *walletService) Create(ctx context.Context, userID uint) (*domain.Wallet, error) {
+	if _, err := s.wallets.FindByUserID(ctx, userID); err == nil {
+		return nil, ErrWalletExists
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	wallet := &domain.Wallet{UserID: userID, Balance: 0} // New wallet with zero balance
+	if err := s.wallets.Create(ctx, wallet); err != nil {
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"user_id": userID,      // User ID
+			"error":   err.Error(), // Error message
+		}).Error("Failed to create wallet") // Log failure
+		return nil, err
+	}
+	// Log successful wallet creation
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"user_id":   userID,                          // User ID
+		"wallet_id": wallet.ID,                       // Wallet ID
+		"type":      "create_wallet",                 // Transaction type
+		"timestamp": time.Now().Format(time.RFC3339), // Current timestamp
+	}).Info("Wallet created") // Log wallet creation
+	_ = s.cache.Delete(ctx, WalletCacheKey(userID)) // Drop a cached "not found"
+	return wallet, nil
+}
+
+// Get returns the user's wallet, served from the cache for up to cacheTTL
+func (s *walletService) Get(ctx context.Context, userID uint) (*domain.Wallet, bool, error) {
+	var wallet domain.Wallet // Wallet struct to hold data
+	if found, err := s.cache.Get(ctx, WalletCacheKey(userID), &wallet); err == nil && found {
+		return &wallet, true, nil
+	}
+	w, err := s.wallets.FindByUserID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, false, ErrWalletNotFound
+	} else if err != nil {
+		return nil, false, err
+	}
+	_ = s.cache.Set(ctx, WalletCacheKey(userID), w, s.cacheTTL) // Cache the wallet
+	return w, false, nil
+}
+
+// Deposit credits the user's wallet with money from outside the system
+func (s *walletService) Deposit(ctx context.Context, userID uint, amount float64) (_ *domain.Transaction, err error) {
+	defer func() { recordOperation(metrics.OpDeposit, amount, err) }()
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	// Fully frozen and closed accounts cannot receive money
+	if !domain.CanCredit(user.Status) {
+		return nil, ErrAccountFrozen
+	}
+	wallet, err := s.wallets.FindByUserID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrWalletNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	result, err := s.txs.Post(ctx, ledger.Posting{
+		ToWalletID: &wallet.ID,           // Credit the wallet
+		Amount:     amount,               // Deposit amount
+		Type:       domain.TxTypeDeposit, // Transaction type
+	})
+	if err != nil {
+		// Log the error with context
+		logrus.WithContext(ctx).WithFields(logrus.Fields{
+			"user_id": userID,      // User ID
+			"amount":  amount,      // Deposit amount
+			"error":   err.Error(), // Error message
+		}).Error("Deposit failed") // Log deposit failure
+		return nil, err
+	}
+	// Log successful deposit
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"user_id":   userID,                          // User ID
+		"amount":    amount,                          // Deposit amount
+		"type":      "deposit",                       // Transaction type
+		"timestamp": time.Now().Format(time.RFC3339), // Current timestamp
+	}).Info("Deposit transaction") // Log deposit success
+	InvalidateWallets(ctx, s.cache, userID) // Balance changed
+	return &result.Transaction, nil
+}
+
+// Transfer moves money from the user's wallet to another user's wallet
+func (s *walletService) Transfer(ctx context.Context, fromUserID uint, toUsername string, amount float64) (_ *domain.Transaction, err error) {
+	defer func() { recordOperation(metrics.OpTransfer, amount, err) }()
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	sender, err := s.users.FindByID(ctx, fromUserID)
+	if err != nil {
+		return nil, err
+	}
+	// Frozen and closed accounts cannot send money
+	if !domain.CanDebit(sender.Status) {
+		return nil, ErrAccountFrozen
+	}
+	// System accounts cannot receive transfers
+	toUser, err := s.users.FindByUsername(ctx, toUsername)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRecipientNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	// Recipient account must be able to receive money
+	if !domain.CanCredit(toUser.Status) {
+		return nil, ErrRecipientFrozen
+	}
+	// Prevent transferring to self
+	if toUser.ID == fromUserID {
+		return nil, ErrSelfTransfer
+	}
+	fromWallet, err := s.wallets.FindByUserID(ctx, fromUserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrWalletNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	toWallet, err := s.wallets.FindByUserID(ctx, toUser.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrRecipientWallet
+	} else if err != nil {
+		return nil, err
+	}
+	// Atomic transfer; funds are checked under the wallet locks
+	result, err := s.txs.Post(ctx, ledger.Posting{
+		FromWalletID: &fromWallet.ID,        // Debit the sender
+		ToWalletID:   &toWallet.ID,          // Credit the recipient
+		Amount:       amount,                // Transfer amount
+		Type:         domain.TxTypeTransfer, // Transaction type
+	})
+	if err != nil {
+		if !errors.Is(err, ledger.ErrInsufficientFunds) {
+			// Log the error with context
+			logrus.WithContext(ctx).WithFields(logrus.Fields{
+				"from_user_id": fromUserID,  // Sender user ID
+				"to_user_id":   toUser.ID,   // Recipient user ID
+				"amount":       amount,      // Transfer amount
+				"error":        err.Error(), // Error message
+			}).Error("Transfer failed") // Log transfer failure
+		}
+		return nil, err
+	}
+	// Log successful transfer
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"from_user_id": fromUserID,                      // Sender user ID
+		"to_user_id":   toUser.ID,                       // Recipient user ID
+		"amount":       amount,                          // Transfer amount
+		"type":         "transfer",                      // Transaction type
+		"timestamp":    time.Now().Format(time.RFC3339), // Current timestamp
+	}).Info("Transfer transaction") // Log transfer success
+	InvalidateWallets(ctx, s.cache, fromUserID, toUser.ID) // Both balances changed
+	return &result.Transaction, nil
+}
+
+// History returns one keyset page of the user's transactions, newest first. Only the default
+// first page is cached; it is the one requested most and is invalidated on postings.
+func (s *walletService) History(ctx context.Context, userID uint, q HistoryQuery) (*HistoryPage, bool, error) {
+	wallet, err := s.wallets.FindByUserID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, false, ErrWalletNotFound
+	} else if err != nil {
+		return nil, false, err
+	}
+	scope := "txhistory:wallet:" + strconv.Itoa(int(wallet.ID)) // Cursors only work for this wallet
+	var cursor *utils.Cursor                                    // Position in the list
+	if q.Cursor != "" {
+		if cursor, err = utils.DecodeCursor(q.Cursor, scope, s.secret); err != nil {
+			return nil, false, ErrInvalidCursor
+		}
+	}
+	cacheable := cursor == nil && q.PageSize == DefaultPageSize && !q.IncludeTotal
+	if cacheable {
+		var cached HistoryPage
+		if found, err := s.cache.Get(ctx, HistoryCacheKey(userID), &cached); err == nil && found {
+			return &cached, true, nil
+		}
+	}
+	filter := repository.TransactionFilter{WalletID: &wallet.ID} // Both sides of the wallet
+	page, err := fetchPage(ctx, s.txs, filter, repository.PageQuery{Column: "created_at", Desc: true, Cursor: cursor, Limit: q.PageSize}, scope, s.secret)
+	if err != nil {
+		return nil, false, err
+	}
+	result := &HistoryPage{
+		Transactions: make([]HistoryTransaction, len(page.rows)), // Transactions from the wallet's side
+		PageSize:     q.PageSize,                                 // Page size
+		NextCursor:   page.next,                                  // Cursor to the next page
+		PrevCursor:   page.prev,                                  // Cursor to the previous page
+	}
+	for i, tx := range page.rows {
+		result.Transactions[i] = HistoryTransaction{Transaction: tx, BalanceAfter: tx.BalanceAfter(wallet.ID)}
+	}
+	if q.IncludeTotal {
+		total, err := s.txs.Count(ctx, filter)
+		if err != nil {
+			return nil, false, err
+		}
+		result.Total = &total
+	}
+	if cacheable {
+		_ = s.cache.Set(ctx, HistoryCacheKey(userID), result, s.cacheTTL) // Cache the first page
+	}
+	return result, false, nil
+}
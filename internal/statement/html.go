@@ -0,0 +1,66 @@
+package statement
+
+import (
+	"html/template"                 // Escaped HTML rendering
+	"io"                            // Output stream
+	"strconv"                       // Number formatting
+	"time"                          // Timestamps
+	"wallet_system/internal/domain" // Importing domain models
+)
+
+// page is the data passed to the HTML template
+type page struct {
+	Statement *domain.Statement // Statement to render
+	Username  string            // Owner of the wallet
+}
+
+// funcs are the helpers available to the HTML template
+var funcs = template.FuncMap{
+	"money":    func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) },                 // Two decimals
+	"date":     func(ms int64) string { return time.UnixMilli(ms).UTC().Format("2006-01-02") },       // Calendar date
+	"datetime": func(ms int64) string { return time.UnixMilli(ms).UTC().Format("2006-01-02 15:04") }, // Date and time
+	"lastDay":  func(ms int64) string { return time.UnixMilli(ms - 1).UTC().Format("2006-01-02") },   // Last day of an exclusive end
+}
+
+// htmlTemplate renders a statement as a standalone page laid out for printing or saving as PDF
+var htmlTemplate = template.Must(template.New("statement").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Statement {{.Statement.ID}} &middot; {{date .Statement.PeriodStart}} to {{lastDay .Statement.PeriodEnd}}</title>
+<style>
+  body { font-family: Helvetica, Arial, sans-serif; font-size: 12px; color: #222; margin: 2em; }
+  h1 { font-size: 18px; margin-bottom: 0; }
+  table { width: 100%; border-collapse: collapse; margin-top: 1em; }
+  th, td { padding: 4px 6px; border-bottom: 1px solid #ddd; text-align: left; }
+  td.num, th.num { text-align: right; font-variant-numeric: tabular-nums; }
+  .summary td { border: none; padding: 2px 6px; }
+  @page { size: A4; margin: 15mm; }
+  @media print { body { margin: 0; } thead { display: table-header-group; } tr { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>Account statement</h1>
+<p>{{.Username}} &middot; Wallet {{.Statement.WalletID}} &middot; {{.Statement.Currency}}<br>
+Period {{date .Statement.PeriodStart}} to {{lastDay .Statement.PeriodEnd}} &middot; Issued {{datetime .Statement.CreatedAt}} UTC</p>
+<table class="summary">
+  <tr><td>Opening balance</td><td class="num">{{money .Statement.OpeningBalance}}</td></tr>
+  <tr><td>Money in</td><td class="num">{{money .Statement.TotalCredits}}</td></tr>
+  <tr><td>Money out</td><td class="num">{{money .Statement.TotalDebits}}</td></tr>
+  <tr><td><strong>Closing balance</strong></td><td class="num"><strong>{{money .Statement.ClosingBalance}}</strong></td></tr>
+</table>
+<table>
+  <thead><tr><th>Date</th><th>Reference</th><th>Description</th><th class="num">Amount</th><th class="num">Balance</th></tr></thead>
+  <tbody>
+  {{range .Statement.Lines}}<tr><td>{{datetime .PostedAt}}</td><td>{{.TransactionID}}</td><td>{{.Description}}</td><td class="num">{{money .Amount}}</td><td class="num">{{money .Balance}}</td></tr>
+  {{else}}<tr><td colspan="5">No transactions in this period.</td></tr>
+  {{end}}</tbody>
+</table>
+</body>
+</html>
+`))
+
+// RenderHTML writes a printable HTML rendering of a statement with its lines
+func RenderHTML(w io.Writer, st *domain.Statement, username string) error {
+	return htmlTemplate.Execute(w, page{Statement: st, Username: username})
+}
@@ -0,0 +1,258 @@
+package statement
+
+import (
+	"context"                       // Cancellation of the background job
+	"database/sql"                  // Nullable aggregates
+	"errors"                        // Error inspection
+	"strconv"                       // String conversion
+	"strings"                       // String building
+	"time"                          // Periods
+	"wallet_system/internal/domain" // Importing domain models
+	"wallet_system/internal/ledger" // Opening balances
+
+	"github.com/sirupsen/logrus" // Logging library
+	"gorm.io/gorm"               // GORM ORM library
+)
+
+// grace is how long after a period ends before its statements are generated, so that
+// postings started just before the boundary have committed
+const grace = 10 * time.Minute
+
+// MonthBounds returns the start of the month containing t and the start of the next month, in UTC
+func MonthBounds(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC) // First instant of the month
+	return start, start.AddDate(0, 1, 0)
+}
+
+// movement is a transaction joined with the owners of both wallets
+type movement struct {
+	domain.Transaction        // Transaction fields
+	FromUsername       string // Owner of the sending wallet
+	ToUsername         string // Owner of the receiving wallet
+}
+
+// describe returns a human-readable description of a movement from one side
+func describe(m *movement, credit bool) string {
+	var b strings.Builder
+	if m.Type != "" {
+		b.WriteString(strings.ToUpper(m.Type[:1]) + m.Type[1:]) // e.g. "Transfer"
+	}
+	switch {
+	case m.Type == domain.TxTypeTransfer && credit:
+		b.WriteString(" from " + m.FromUsername)
+	case m.Type == domain.TxTypeTransfer:
+		b.WriteString(" to " + m.ToUsername)
+	case m.Type == domain.TxTypeReversal && m.ReferenceID != nil:
+		b.WriteString(" of #" + strconv.Itoa(int(*m.ReferenceID)))
+	case m.Type == domain.TxTypeAdjustment && m.ReasonCode != "":
+		b.WriteString(" (" + m.ReasonCode + ")")
+	}
+	if m.Note != "" {
+		b.WriteString(": " + m.Note)
+	}
+	return b.String()
+}
+
+// Build computes the statement of a wallet for [start, end) without storing it
+func Build(db *gorm.DB, wallet *domain.Wallet, start, end time.Time, currency string) (*domain.Statement, error) {
+	startMs, endMs := start.UnixMilli(), end.UnixMilli() // Period in milliseconds
+	opening, err := ledger.BalanceBefore(db, wallet.ID, startMs)
+	if err != nil {
+		return nil, err
+	}
+	st := &domain.Statement{
+		WalletID:       wallet.ID,     // Wallet
+		UserID:         wallet.UserID, // Owner
+		PeriodStart:    startMs,       // Start, inclusive
+		PeriodEnd:      endMs,         // End, exclusive
+		Currency:       currency,      // Currency
+		OpeningBalance: opening,       // Balance before the period
+	}
+	var movements []movement // Movements in posting order
+	if err := db.Model(&domain.Transaction{}).
+		Select("transactions.*, fu.username AS from_username, tu.username AS to_username").
+		Joins("LEFT JOIN wallets fw ON fw.id = transactions.from_wallet_id").
+		Joins("LEFT JOIN users fu ON fu.id = fw.user_id").
+		Joins("LEFT JOIN wallets tw ON tw.id = transactions.to_wallet_id").
+		Joins("LEFT JOIN users tu ON tu.id = tw.user_id").
+		Where("(transactions.from_wallet_id = ? OR transactions.to_wallet_id = ?)", wallet.ID, wallet.ID).
+		Where("transactions.created_at >= ? AND transactions.created_at < ?", startMs, endMs).
+		Order("transactions.created_at asc").Order("transactions.id asc").
+		Scan(&movements).Error; err != nil {
+		return nil, err
+	}
+	balance := opening // Running balance
+	for i := range movements {
+		m := &movements[i]
+		credit := m.ToWalletID != nil && *m.ToWalletID == wallet.ID // Money in
+		line := domain.StatementLine{
+			TransactionID: m.ID,                // Transaction
+			PostedAt:      m.CreatedAt,         // Posting time
+			Type:          m.Type,              // Transaction type
+			Description:   describe(m, credit), // Description
+		}
+		if credit {
+			line.Amount, line.Counterparty = m.Amount, m.FromUsername
+			st.TotalCredits += m.Amount
+		} else {
+			line.Amount, line.Counterparty = -m.Amount, m.ToUsername
+			st.TotalDebits += m.Amount
+		}
+		balance += line.Amount
+		line.Balance = balance // Balance after this line
+		st.Lines = append(st.Lines, line)
+	}
+	st.ClosingBalance = balance
+	st.TransactionCount = len(st.Lines)
+	return st, nil
+}
+
+// find returns the stored statement of a wallet for the period starting at startMs, or nil
+func find(db *gorm.DB, walletID uint, startMs int64) (*domain.Statement, error) {
+	var existing domain.Statement // Statement generated earlier
+	err := db.Where("wallet_id = ? AND period_start = ?", walletID, startMs).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// store saves a built statement with its lines. If another instance stored the same
+// statement first, the unique index keeps one copy and that copy is returned.
+func store(db *gorm.DB, st *domain.Statement) (*domain.Statement, error) {
+	if err := db.Create(st).Error; err != nil {
+		if existing, findErr := find(db, st.WalletID, st.PeriodStart); findErr == nil && existing != nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return st, nil
+}
+
+// Generate returns the stored statement of a wallet for [start, end), building and storing it
+// first if it does not exist yet. Statements are never regenerated once stored.
+func Generate(db *gorm.DB, wallet *domain.Wallet, start, end time.Time, currency string) (*domain.Statement, error) {
+	existing, err := find(db, wallet.ID, start.UnixMilli())
+	if err != nil || existing != nil {
+		return existing, err
+	}
+	st, err := Build(db, wallet, start, end, currency)
+	if err != nil {
+		return nil, err
+	}
+	return store(db, st)
+}
+
+// GenerateMonth stores the statements of every customer wallet for the month containing start.
+// Wallets with neither a balance nor activity in the month have nothing to report and are skipped.
+func GenerateMonth(db *gorm.DB, start time.Time, currency string) (int, error) {
+	start, end := MonthBounds(start) // Normalize to the whole month
+	generated := 0                   // Statements stored by this run
+	var failed error                 // First generation error
+	var batch []domain.Wallet        // Wallets are processed in batches to bound memory
+	// generate stores one wallet's statement, skipping wallets already done or with nothing to report
+	generate := func(w *domain.Wallet) error {
+		existing, err := find(db, w.ID, start.UnixMilli())
+		if err != nil || existing != nil {
+			return err
+		}
+		st, err := Build(db, w, start, end, currency)
+		if err != nil {
+			return err
+		}
+		if st.TransactionCount == 0 && st.OpeningBalance == 0 {
+			return nil // Nothing to report
+		}
+		if _, err := store(db, st); err != nil {
+			return err
+		}
+		generated++
+		return nil
+	}
+	err := db.Where("is_system = ?", false).Order("id asc").FindInBatches(&batch, 200, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := generate(&batch[i]); err != nil {
+				logrus.WithFields(logrus.Fields{
+					"wallet_id": batch[i].ID, // Wallet
+					"error":     err.Error(), // Error message
+				}).Error("Failed to generate statement")
+				if failed == nil {
+					failed = err // Keep going; report the first failure
+				}
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return generated, err
+	}
+	return generated, failed
+}
+
+// lastComplete returns the start of the last month that ended at least grace before now
+func lastComplete(now time.Time) time.Time {
+	current, _ := MonthBounds(now)    // Start of the current month
+	last := current.AddDate(0, -1, 0) // The previous month
+	if now.Sub(current) < grace {
+		last = last.AddDate(0, -1, 0) // Postings at the boundary may still be committing
+	}
+	return last
+}
+
+// CatchUp generates the statements of every month from the last one generated up to the last
+// complete month, so months missed while no instance was running are not skipped. The last month
+// generated is checked again in case a run failed part way; without any statements only the last
+// complete month is generated. It stops at the first month that fails and returns the number of
+// statements stored.
+func CatchUp(db *gorm.DB, now time.Time, currency string) (int, error) {
+	last := lastComplete(now) // Newest month due
+	var latest sql.NullInt64  // Start of the newest month with statements
+	if err := db.Model(&domain.Statement{}).Select("MAX(period_start)").Row().Scan(&latest); err != nil {
+		return 0, err
+	}
+	month := last // First month to generate
+	if latest.Valid {
+		if start, _ := MonthBounds(time.UnixMilli(latest.Int64)); start.Before(last) {
+			month = start
+		}
+	}
+	total := 0 // Statements stored by this run
+	for ; !month.After(last); month = month.AddDate(0, 1, 0) {
+		n, err := GenerateMonth(db, month, currency)
+		total += n
+		if err != nil {
+			return total, err // Later months wait until this one succeeds
+		}
+		logrus.WithFields(logrus.Fields{
+			"period": month.Format("2006-01"), // Month generated
+			"count":  n,                       // Statements stored by this run
+		}).Info("Generated monthly statements")
+	}
+	return total, nil
+}
+
+// RunMonthly generates statements shortly after each month ends, catching up on any months missed
+// since the last run, checking every interval until ctx is cancelled. Runs are idempotent, so
+// several instances may run the job.
+func RunMonthly(ctx context.Context, db *gorm.DB, currency string, interval time.Duration) {
+	ticker := time.NewTicker(interval) // Check interval
+	defer ticker.Stop()
+	var done time.Time // Last complete month fully generated by this process
+	for {
+		if due := lastComplete(time.Now()); !due.Equal(done) {
+			if _, err := CatchUp(db, time.Now(), currency); err != nil {
+				logrus.WithField("error", err.Error()).Error("Failed to generate monthly statements")
+			} else {
+				done = due // Retry on the next tick if anything failed
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return // Stop when the server shuts down
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package tracing
+
+import (
+	"context"                       // Exporter setup and shutdown
+	"fmt"                           // Error messages
+	"os"                            // Stdout exporter
+	"wallet_system/internal/config" // Tracing settings
+
+	"github.com/gin-gonic/gin"                                                     // Gin web framework
+	"github.com/redis/go-redis/extra/redisotel/v9"                                 // Redis instrumentation
+	"github.com/redis/go-redis/v9"                                                 // Redis client
+	"github.com/sirupsen/logrus"                                                   // Logging library
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin" // Gin instrumentation
+	"go.opentelemetry.io/otel"                                                     // Global tracer provider
+	"go.opentelemetry.io/otel/attribute"                                           // Resource attributes
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"              // OTLP exporter
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"                        // Stdout exporter
+	"go.opentelemetry.io/otel/propagation"                                         // Trace context headers
+	"go.opentelemetry.io/otel/sdk/resource"                                        // Service identity
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"                                  // Tracer provider
+	"go.opentelemetry.io/otel/trace"                                               // Span context
+	"gorm.io/gorm"                                                                 // GORM ORM library
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"                             // GORM instrumentation
+)
+
+// Supported values of OTEL_TRACES_EXPORTER
+const (
+	ExporterNone   = "none"   // Tracing disabled
+	ExporterStdout = "stdout" // Spans printed as JSON, for local debugging
+	ExporterOTLP   = "otlp"   // Spans sent over gRPC to OTEL_EXPORTER_OTLP_ENDPOINT
+)
+
+// TraceIDHeader carries the trace ID of every response, so a failing request can be found in the tracing backend
+const TraceIDHeader = "X-Trace-Id"
+
+// untraced are routes polled by infrastructure, which would drown the interesting traces
+var untraced = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// Setup installs the global tracer provider and the W3C trace context propagator. With the
+// none exporter spans are not recorded, but incoming trace context is still passed on. The
+// returned function flushes buffered spans; call it on shutdown.
+func Setup(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	var exporter sdktrace.SpanExporter // Where finished spans go
+	switch cfg.TracesExporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		e, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, err
+		}
+		exporter = e
+	case ExporterOTLP:
+		// Endpoint, headers and TLS come from the standard OTEL_EXPORTER_OTLP_* variables
+		e, err := otlptracegrpc.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		exporter = e
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q; use none, stdout or otlp", cfg.TracesExporter)
+	}
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),      // OTEL_RESOURCE_ATTRIBUTES
+		resource.WithTelemetrySDK(), // SDK name and version
+		resource.WithAttributes(attribute.String("service.name", cfg.ServiceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter), // Export in the background
+		sdktrace.WithResource(res),     // Identify this service
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracesSampleRatio))), // Follow the caller's decision
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Middleware starts a server span for every request, continuing the trace of the caller if
+// the request carries a traceparent header, and returns the trace ID in TraceIDHeader
+func Middleware(serviceName string) []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		otelgin.Middleware(serviceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+			return !untraced[c.FullPath()]
+		})),
+		func(c *gin.Context) {
+			if id := TraceID(c.Request.Context()); id != "" {
+				c.Header(TraceIDHeader, id)
+			}
+			c.Next()
+		},
+	}
+}
+
+// InstrumentDB adds a span to every GORM query. Query arguments are left out of the spans,
+// since they include password hashes and personal data.
+func InstrumentDB(db *gorm.DB) error {
+	return db.Use(gormtracing.NewPlugin(gormtracing.WithoutQueryVariables(), gormtracing.WithoutMetrics()))
+}
+
+// InstrumentRedis adds a span to every Redis command. Commands are left out of the spans,
+// since keys and values include session tokens.
+func InstrumentRedis(rdb *redis.Client) error {
+	return redisotel.InstrumentTracing(rdb, redisotel.WithDBStatement(false))
+}
+
+// TraceID returns the ID of the trace in ctx, or an empty string if there is none
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// LogHook adds trace_id and span_id to log entries made with a traced context, via logrus.WithContext
+type LogHook struct{}
+
+// Levels returns every level; the hook applies to all of them
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire copies the span context of the entry into its fields
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	sc := trace.SpanContextFromContext(entry.Context)
+	if !sc.IsValid() {
+		return nil
+	}
+	entry.Data["trace_id"] = sc.TraceID().String()
+	entry.Data["span_id"] = sc.SpanID().String()
+	return nil
+}
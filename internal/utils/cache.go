@@ -1,9 +1,10 @@
 package utils
 
 import (
-	"context"       // Context for Redis operations
-	"encoding/json" // JSON encoding/decoding
-	"time"          // Time durations
+	"context"                        // Context for Redis operations
+	"encoding/json"                  // JSON encoding/decoding
+	"time"                           // Time durations
+	"wallet_system/internal/metrics" // Cache hit and miss counters
 
 	"github.com/redis/go-redis/v9" // Redis client
 )
@@ -12,10 +13,13 @@ import (
 func GetCache(ctx context.Context, rdb *redis.Client, key string, dest any) (bool, error) {
 	val, err := rdb.Get(ctx, key).Result() // Get value from Redis
 	if err == redis.Nil {
+		metrics.CacheLookup(metrics.CacheMiss)
 		return false, nil // Key does not exist
 	} else if err != nil {
+		metrics.CacheLookup(metrics.CacheError)
 		return false, err // Other Redis error
 	}
+	metrics.CacheLookup(metrics.CacheHit)
 	return true, json.Unmarshal([]byte(val), dest) // Unmarshal JSON into dest
 }
 
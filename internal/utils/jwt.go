@@ -1,37 +1,117 @@
 package utils
 
 import (
-	"time" // Time for token expiration
+	"crypto/rand"  // Secure random token IDs
+	"encoding/hex" // Hex encoding for token IDs
+	"errors"       // Error values
+	"time"         // Time for token expiration
 
 	"github.com/golang-jwt/jwt/v5" // JWT library
 )
 
+// Token types carried in the "typ" claim
+const (
+	TokenTypeAccess  = "access"  // Short-lived token sent with every request
+	TokenTypeRefresh = "refresh" // Long-lived token exchanged for a new pair
+)
+
+// Token lifetimes, set from the configuration by SetTokenTTLs
+var (
+	AccessTokenTTL  = 1 * time.Hour      // Access tokens expire after one hour
+	RefreshTokenTTL = 7 * 24 * time.Hour // Refresh tokens expire after seven days
+)
+
+// SetTokenTTLs changes the token lifetimes; zero keeps the current value. Call it at startup,
+// before any token is issued.
+func SetTokenTTLs(access, refresh time.Duration) {
+	if access > 0 {
+		AccessTokenTTL = access
+	}
+	if refresh > 0 {
+		RefreshTokenTTL = refresh
+	}
+}
+
+// ErrWrongTokenType is returned when a token of one type is used where another is expected
+var ErrWrongTokenType = errors.New("wrong token type")
+
 // JWT Claims
 type Claims struct {
-	UserID               uint `json:"user_id"` // Custom claim for user ID
-	jwt.RegisteredClaims      // Standard JWT claims
+	UserID               uint   `json:"user_id"`        // Custom claim for user ID
+	Role                 string `json:"role,omitempty"` // Role at the time the token was issued
+	TokenType            string `json:"typ,omitempty"`  // Token type: access or refresh
+	SessionVersion       int64  `json:"sv,omitempty"`   // User's session version at the time the token was issued
+	jwt.RegisteredClaims        // Standard JWT claims
+}
+
+// TokenPair holds an access token and the refresh token issued alongside it
+type TokenPair struct {
+	AccessToken   string  // Signed access token
+	RefreshToken  string  // Signed refresh token
+	AccessClaims  *Claims // Claims of the access token
+	RefreshClaims *Claims // Claims of the refresh token
+}
+
+// newTokenID returns a random identifier used as the "jti" claim
+func newTokenID() (string, error) {
+	b := make([]byte, 16) // 128 bits of randomness
+	if _, err := rand.Read(b); err != nil {
+		return "", err // Return error if randomness is unavailable
+	}
+	return hex.EncodeToString(b), nil // Return hex encoded ID
 }
 
-// GenerateJWT creates a JWT token for a given user ID
-func GenerateJWT(userID uint, secret string) (string, error) {
+// GenerateJWT creates a signed JWT of the given type for a user ID, role and session version
+func GenerateJWT(userID uint, role string, sessionVersion int64, tokenType string, ttl time.Duration, secret string) (string, *Claims, error) {
+	jti, err := newTokenID() // Unique token ID used for revocation
+	if err != nil {
+		return "", nil, err // Return error if ID generation fails
+	}
+	now := time.Now() // Current time
 	// Set token claims
-	claims := Claims{
-		UserID: userID, // Custom claim for user ID
+	claims := &Claims{
+		UserID:         userID,         // Custom claim for user ID
+		Role:           role,           // Role claim
+		TokenType:      tokenType,      // Token type
+		SessionVersion: sessionVersion, // Session version
 		// Standard claims
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // Token expires in 24 hours
-			IssuedAt:  jwt.NewNumericDate(time.Now()),                     // Issued at current time
+			ID:        jti,                              // Token ID
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)), // Token expiry
+			IssuedAt:  jwt.NewNumericDate(now),          // Issued at current time
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims) // Create token with claims
-	return token.SignedString([]byte(secret))                  // Sign the token with the secret
+	signed, err := token.SignedString([]byte(secret))          // Sign the token with the secret
+	if err != nil {
+		return "", nil, err // Return error if signing fails
+	}
+	return signed, claims, nil // Return signed token and its claims
+}
+
+// GenerateTokenPair creates an access token and a refresh token for a user ID, role and session version
+func GenerateTokenPair(userID uint, role string, sessionVersion int64, secret string) (*TokenPair, error) {
+	access, accessClaims, err := GenerateJWT(userID, role, sessionVersion, TokenTypeAccess, AccessTokenTTL, secret) // Access token
+	if err != nil {
+		return nil, err // Return error if generation fails
+	}
+	refresh, refreshClaims, err := GenerateJWT(userID, role, sessionVersion, TokenTypeRefresh, RefreshTokenTTL, secret) // Refresh token
+	if err != nil {
+		return nil, err // Return error if generation fails
+	}
+	return &TokenPair{
+		AccessToken:   access,        // Access token
+		RefreshToken:  refresh,       // Refresh token
+		AccessClaims:  accessClaims,  // Access token claims
+		RefreshClaims: refreshClaims, // Refresh token claims
+	}, nil
 }
 
 // ParseJWT parses and validates a JWT token string
 func ParseJWT(tokenStr, secret string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (any, error) {
 		return []byte(secret), nil // Return the secret key for validation
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})) // Only accept HS256
 	// Check for parsing errors
 	if err != nil {
 		return nil, err // Return error if parsing fails
@@ -43,3 +123,20 @@ func ParseJWT(tokenStr, secret string) (*Claims, error) {
 	// Return error if token is invalid
 	return nil, jwt.ErrSignatureInvalid
 }
+
+// ParseTokenOfType parses a JWT and checks that it carries the expected token type
+func ParseTokenOfType(tokenStr, tokenType, secret string) (*Claims, error) {
+	claims, err := ParseJWT(tokenStr, secret) // Parse and validate signature
+	if err != nil {
+		return nil, err // Return error if parsing fails
+	}
+	typ := claims.TokenType // Token type from claims
+	// Tokens issued before typed tokens existed are access tokens
+	if typ == "" {
+		typ = TokenTypeAccess
+	}
+	if typ != tokenType {
+		return nil, ErrWrongTokenType // Reject refresh tokens used as access tokens and vice versa
+	}
+	return claims, nil // Return claims if type matches
+}
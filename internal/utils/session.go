@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"context" // Context for Redis operations
+	"strconv" // String conversion
+	"time"    // Time durations
+
+	"github.com/redis/go-redis/v9" // Redis client
+)
+
+// refreshKey returns the Redis key tracking an issued refresh token
+func refreshKey(jti string) string {
+	return "auth:refresh:" + jti
+}
+
+// revokedKey returns the Redis key marking an access token as revoked
+func revokedKey(jti string) string {
+	return "auth:revoked:" + jti
+}
+
+// sessionVersionKey returns the Redis key holding a user's session version
+func sessionVersionKey(userID uint) string {
+	return "auth:session_version:user:" + strconv.Itoa(int(userID))
+}
+
+// UserStateCacheKey returns the Redis key caching a user's current role and status
+func UserStateCacheKey(userID uint) string {
+	return "auth:state:user:" + strconv.Itoa(int(userID))
+}
+
+// ttlUntil returns the time left until the claims expire, never less than one second
+func ttlUntil(claims *Claims) time.Duration {
+	ttl := time.Until(claims.ExpiresAt.Time) // Remaining lifetime
+	if ttl < time.Second {
+		ttl = time.Second // Redis requires a positive TTL
+	}
+	return ttl
+}
+
+// StoreRefreshToken records an issued refresh token so it can be used exactly once
+func StoreRefreshToken(ctx context.Context, rdb *redis.Client, claims *Claims) error {
+	return rdb.Set(ctx, refreshKey(claims.ID), strconv.Itoa(int(claims.UserID)), ttlUntil(claims)).Err()
+}
+
+// ConsumeRefreshToken deletes a stored refresh token and reports whether it was still valid
+func ConsumeRefreshToken(ctx context.Context, rdb *redis.Client, claims *Claims) (bool, error) {
+	n, err := rdb.Del(ctx, refreshKey(claims.ID)).Result() // Delete so the token cannot be reused
+	if err != nil {
+		return false, err // Redis error
+	}
+	return n == 1, nil // Token was valid only if it existed
+}
+
+// RevokeAccessToken marks an access token as revoked until it expires
+func RevokeAccessToken(ctx context.Context, rdb *redis.Client, claims *Claims) error {
+	// Tokens without an ID cannot be tracked individually
+	if claims.ID == "" {
+		return nil
+	}
+	return rdb.Set(ctx, revokedKey(claims.ID), "1", ttlUntil(claims)).Err()
+}
+
+// SessionVersion returns a user's current session version; tokens carry the version they were issued under
+func SessionVersion(ctx context.Context, rdb *redis.Client, userID uint) (int64, error) {
+	v, err := rdb.Get(ctx, sessionVersionKey(userID)).Int64()
+	if err == redis.Nil {
+		return 0, nil // Sessions never revoked
+	}
+	return v, err
+}
+
+// RevokeUserSessions invalidates every token issued to a user so far by bumping their session version
+func RevokeUserSessions(ctx context.Context, rdb *redis.Client, userID uint) error {
+	// The key never expires: resetting the version would revive old tokens
+	return rdb.Incr(ctx, sessionVersionKey(userID)).Err()
+}
+
+// IsTokenRevoked reports whether a token has been revoked, either individually
+// (access tokens on logout) or together with all of the user's sessions
+func IsTokenRevoked(ctx context.Context, rdb *redis.Client, claims *Claims) (bool, error) {
+	// Check the individual revocation marker; tokens without an ID cannot be tracked individually
+	if claims.ID != "" {
+		n, err := rdb.Exists(ctx, revokedKey(claims.ID)).Result()
+		if err != nil {
+			return false, err // Redis error
+		}
+		if n == 1 {
+			return true, nil // Revoked by logout
+		}
+	}
+	// Tokens issued under an older session version are revoked
+	version, err := SessionVersion(ctx, rdb, claims.UserID)
+	if err != nil {
+		return false, err // Redis error
+	}
+	return claims.SessionVersion != version, nil
+}
+
+// ResetTokenTTL is how long a password reset token stays valid
+const ResetTokenTTL = 24 * time.Hour
+
+// passwordResetKey returns the Redis key holding a password reset token
+func passwordResetKey(token string) string {
+	return "auth:pwreset:" + token
+}
+
+// CreatePasswordResetToken issues a single-use password reset token for a user
+func CreatePasswordResetToken(ctx context.Context, rdb *redis.Client, userID uint) (string, error) {
+	token, err := newTokenID() // Random, unguessable token
+	if err != nil {
+		return "", err
+	}
+	if err := rdb.Set(ctx, passwordResetKey(token), strconv.Itoa(int(userID)), ResetTokenTTL).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConsumePasswordResetToken deletes a reset token and returns the user it was issued for
+func ConsumePasswordResetToken(ctx context.Context, rdb *redis.Client, token string) (uint, bool, error) {
+	val, err := rdb.GetDel(ctx, passwordResetKey(token)).Result() // Read and delete atomically
+	if err == redis.Nil {
+		return 0, false, nil // Unknown, used or expired token
+	} else if err != nil {
+		return 0, false, err // Redis error
+	}
+	id, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, false, err
+	}
+	return uint(id), true, nil
+}